@@ -93,6 +93,230 @@ const docTemplate = `{
                 }
             }
         },
+        "/api/v1/admin/red-envelopes/export": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "envelopes",
+                            "claims"
+                        ],
+                        "type": "string",
+                        "name": "table",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/util.ResponseAny"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/admin/red-envelopes/exposure": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "name": "creator_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/util.ResponseAny"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/admin/red-envelopes/refund-pause": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/util.Response-red_envelope_refundPause"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "parameters": [
+                    {
+                        "description": "请求参数",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/red_envelope.updateRefundPauseRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/util.Response-red_envelope_refundPause"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/admin/red-envelopes/shared-devices": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "parameters": [
+                    {
+                        "enum": [
+                            "ip",
+                            "device"
+                        ],
+                        "type": "string",
+                        "name": "by",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 90,
+                        "minimum": 1,
+                        "type": "integer",
+                        "name": "days",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 100,
+                        "minimum": 1,
+                        "type": "integer",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 100,
+                        "minimum": 2,
+                        "type": "integer",
+                        "name": "min_claimers",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/util.ResponseAny"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/admin/red-envelopes/{id}/bulk-claim": {
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "红包ID或红包码",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "批量代领请求",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/redenvelope.BulkClaimRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/util.Response-array_redenvelope_BulkClaimItem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/admin/red-envelopes/{id}/events": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "红包ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/util.Response-array_model_RedEnvelopeEvent"
+                        }
+                    }
+                }
+            }
+        },
         "/api/v1/admin/system-configs": {
             "get": {
                 "produces": [
@@ -1438,6 +1662,33 @@ const docTemplate = `{
                 }
             }
         },
+        "/api/v1/redenvelope/by-order/{order_id}": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "redenvelope"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "订单ID",
+                        "name": "order_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/util.ResponseAny"
+                        }
+                    }
+                }
+            }
+        },
         "/api/v1/redenvelope/claim": {
             "post": {
                 "consumes": [
@@ -1470,7 +1721,7 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/redenvelope/create": {
+        "/api/v1/redenvelope/claim-batch": {
             "post": {
                 "consumes": [
                     "application/json"
@@ -1483,12 +1734,12 @@ const docTemplate = `{
                 ],
                 "parameters": [
                     {
-                        "description": "创建红包请求",
+                        "description": "批量领取请求",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/redenvelope.CreateRequest"
+                            "$ref": "#/definitions/redenvelope.ClaimBatchRequest"
                         }
                     }
                 ],
@@ -1496,13 +1747,13 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/util.ResponseAny"
+                            "$ref": "#/definitions/util.Response-array_redenvelope_ClaimBatchItem"
                         }
                     }
                 }
             }
         },
-        "/api/v1/redenvelope/list": {
+        "/api/v1/redenvelope/claim/return": {
             "post": {
                 "consumes": [
                     "application/json"
@@ -1515,12 +1766,12 @@ const docTemplate = `{
                 ],
                 "parameters": [
                     {
-                        "description": "列表请求",
+                        "description": "退回请求",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/redenvelope.ListRequest"
+                            "$ref": "#/definitions/redenvelope.ReturnClaimRequest"
                         }
                     }
                 ],
@@ -1534,7 +1785,7 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/redenvelope/{id}": {
+        "/api/v1/redenvelope/closing-soon": {
             "get": {
                 "produces": [
                     "application/json"
@@ -1545,55 +1796,52 @@ const docTemplate = `{
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "红包ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "查询范围：mine 自己发出的红包（默认），public 公开红包",
+                        "name": "scope",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "截止时间窗口（分钟），默认60分钟",
+                        "name": "within_minutes",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "返回条数，默认20条",
+                        "name": "limit",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/util.ResponseAny"
+                            "$ref": "#/definitions/util.Response-array_redenvelope_ClosingSoonItem"
                         }
                     }
                 }
             }
         },
-        "/api/v1/user/pay-key": {
-            "put": {
-                "consumes": [
-                    "application/json"
-                ],
+        "/api/v1/redenvelope/config": {
+            "get": {
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "user"
-                ],
-                "parameters": [
-                    {
-                        "description": "request body",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/user.UpdatePayKeyRequest"
-                        }
-                    }
+                    "redenvelope"
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/util.ResponseAny"
+                            "$ref": "#/definitions/util.Response-redenvelope_ConstraintsResponse"
                         }
                     }
                 }
             }
         },
-        "/pay/distribute": {
+        "/api/v1/redenvelope/confirm": {
             "post": {
                 "consumes": [
                     "application/json"
@@ -1602,23 +1850,16 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "payment"
+                    "redenvelope"
                 ],
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Basic Auth (base64(client_id:client_secret))",
-                        "name": "Authorization",
-                        "in": "header",
-                        "required": true
-                    },
-                    {
-                        "description": "分发请求",
+                        "description": "确认领取请求",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/payment.MerchantDistributeRequest"
+                            "$ref": "#/definitions/redenvelope.ConfirmRequest"
                         }
                     }
                 ],
@@ -1632,25 +1873,25 @@ const docTemplate = `{
                 }
             }
         },
-        "/pay/submit.php": {
-            "get": {
+        "/api/v1/redenvelope/create": {
+            "post": {
                 "consumes": [
-                    "application/x-www-form-urlencoded"
+                    "application/json"
                 ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "payment"
+                    "redenvelope"
                 ],
                 "parameters": [
                     {
-                        "description": "request body",
+                        "description": "创建红包请求",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/payment.CreateOrderRequest"
+                            "$ref": "#/definitions/redenvelope.CreateRequest"
                         }
                     }
                 ],
@@ -1662,25 +1903,27 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
+            }
+        },
+        "/api/v1/redenvelope/deep-link/claim": {
             "post": {
                 "consumes": [
-                    "application/x-www-form-urlencoded"
+                    "application/json"
                 ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "payment"
+                    "redenvelope"
                 ],
                 "parameters": [
                     {
-                        "description": "request body",
+                        "description": "一键领取请求",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/payment.CreateOrderRequest"
+                            "$ref": "#/definitions/redenvelope.DeepLinkClaimRequest"
                         }
                     }
                 ],
@@ -1693,514 +1936,2734 @@ const docTemplate = `{
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "api_key.CreateAPIKeyRequest": {
-            "type": "object",
-            "required": [
-                "app_homepage_url",
-                "app_name",
-                "notify_url"
-            ],
-            "properties": {
-                "app_description": {
-                    "type": "string",
-                    "maxLength": 100
-                },
-                "app_homepage_url": {
-                    "type": "string",
-                    "maxLength": 100
-                },
-                "app_name": {
-                    "type": "string",
-                    "maxLength": 20
-                },
-                "notify_url": {
-                    "type": "string",
-                    "maxLength": 100
-                },
-                "redirect_uri": {
-                    "type": "string",
-                    "maxLength": 100
-                },
-                "test_mode": {
-                    "type": "boolean"
+        },
+        "/api/v1/redenvelope/error-codes": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "redenvelope"
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/util.Response-array_redenvelope_ErrorCodeItem"
+                        }
+                    }
                 }
             }
         },
-        "api_key.UpdateAPIKeyRequest": {
-            "type": "object",
-            "properties": {
-                "app_description": {
-                    "type": "string",
-                    "maxLength": 100
-                },
-                "app_homepage_url": {
-                    "type": "string",
-                    "maxLength": 100
-                },
-                "app_name": {
-                    "type": "string",
-                    "maxLength": 20
-                },
-                "notify_url": {
-                    "type": "string",
-                    "maxLength": 100
-                },
-                "redirect_uri": {
-                    "type": "string",
-                    "maxLength": 100
-                },
-                "test_mode": {
-                    "type": "boolean"
+        "/api/v1/redenvelope/escrow": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "redenvelope"
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/util.ResponseAny"
+                        }
+                    }
                 }
             }
         },
-        "dashboard.UserBalanceStatsResponse": {
-            "type": "object",
-            "properties": {
-                "avg_amount": {
-                    "type": "number"
-                },
-                "max_amount": {
-                    "type": "number"
-                },
-                "median_amount": {
-                    "type": "number"
-                },
-                "min_amount": {
-                    "type": "number"
-                },
-                "std_dev": {
-                    "type": "number"
-                },
-                "total_amount": {
-                    "type": "number"
-                },
-                "total_count": {
-                    "type": "integer"
+        "/api/v1/redenvelope/escrow/deposit": {
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "redenvelope"
+                ],
+                "parameters": [
+                    {
+                        "description": "存入请求",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/redenvelope.EscrowRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/util.ResponseAny"
+                        }
+                    }
                 }
             }
         },
-        "dispute.CloseDisputeRequest": {
-            "type": "object",
-            "required": [
-                "dispute_id"
-            ],
-            "properties": {
-                "dispute_id": {
-                    "type": "string",
-                    "example": "0"
+        "/api/v1/redenvelope/escrow/withdraw": {
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "redenvelope"
+                ],
+                "parameters": [
+                    {
+                        "description": "取回请求",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/redenvelope.EscrowRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/util.ResponseAny"
+                        }
+                    }
                 }
             }
         },
-        "dispute.CreateDisputeRequest": {
-            "type": "object",
-            "required": [
-                "order_id",
-                "reason"
-            ],
-            "properties": {
-                "order_id": {
-                    "type": "string",
-                    "example": "0"
-                },
-                "reason": {
-                    "type": "string",
-                    "maxLength": 100
+        "/api/v1/redenvelope/export": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "redenvelope"
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/util.ResponseAny"
+                        }
+                    }
                 }
             }
         },
-        "dispute.ListDisputesRequest": {
-            "type": "object",
-            "properties": {
-                "dispute_id": {
-                    "type": "string",
-                    "example": "0"
-                },
-                "page": {
-                    "type": "integer",
-                    "minimum": 1
-                },
-                "page_size": {
-                    "type": "integer",
-                    "maximum": 100,
-                    "minimum": 1
-                },
-                "status": {
-                    "type": "string",
-                    "enum": [
-                        "disputing",
+        "/api/v1/redenvelope/list": {
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "redenvelope"
+                ],
+                "parameters": [
+                    {
+                        "description": "列表请求",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/redenvelope.ListRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/util.ResponseAny"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/redenvelope/locked": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "redenvelope"
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/util.ResponseAny"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/redenvelope/my-claims": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "redenvelope"
+                ],
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "页码",
+                        "name": "page",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "每页数量",
+                        "name": "page_size",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "开始时间",
+                        "name": "start_time",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "结束时间",
+                        "name": "end_time",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/util.ResponseAny"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/redenvelope/preview": {
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "redenvelope"
+                ],
+                "parameters": [
+                    {
+                        "description": "红包费用预估请求",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/redenvelope.PreviewRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/util.Response-redenvelope_PreviewResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/redenvelope/public": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "redenvelope"
+                ],
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "页码",
+                        "name": "page",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "每页数量",
+                        "name": "page_size",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/util.ResponseAny"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/redenvelope/ready": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "redenvelope"
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/util.ResponseAny"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/util.ResponseAny"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/redenvelope/receipts/verify": {
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "redenvelope"
+                ],
+                "parameters": [
+                    {
+                        "description": "校验请求",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/redenvelope.VerifyReceiptRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/util.Response-redenvelope_ClaimReceipt"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/redenvelope/recurring": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "redenvelope"
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/util.Response-array_redenvelope_RecurringEnvelopeItem"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "redenvelope"
+                ],
+                "parameters": [
+                    {
+                        "description": "创建定期发放计划请求",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/redenvelope.CreateRecurringRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/util.Response-redenvelope_RecurringEnvelopeItem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/redenvelope/recurring/{id}": {
+            "put": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "redenvelope"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "定期发放计划ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "修改定期发放计划请求",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/redenvelope.UpdateRecurringRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/util.Response-redenvelope_RecurringEnvelopeItem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/redenvelope/refunds": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "redenvelope"
+                ],
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "页码",
+                        "name": "page",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "每页数量",
+                        "name": "page_size",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "退款开始时间",
+                        "name": "start_time",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "退款结束时间",
+                        "name": "end_time",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/util.ResponseAny"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/redenvelope/reserve": {
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "redenvelope"
+                ],
+                "parameters": [
+                    {
+                        "description": "预约请求",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/redenvelope.ReserveRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/util.ResponseAny"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/redenvelope/search": {
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "redenvelope"
+                ],
+                "parameters": [
+                    {
+                        "description": "搜索请求",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/redenvelope.SearchRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/util.ResponseAny"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/redenvelope/top-senders": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "redenvelope"
+                ],
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "统计天数，默认7天",
+                        "name": "days",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "返回条数，默认10条",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/util.ResponseAny"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/redenvelope/wallets": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "redenvelope"
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/util.ResponseAny"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/redenvelope/wallets/deposit": {
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "redenvelope"
+                ],
+                "parameters": [
+                    {
+                        "description": "存入请求",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/redenvelope.WalletTransferRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/util.ResponseAny"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/redenvelope/wallets/withdraw": {
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "redenvelope"
+                ],
+                "parameters": [
+                    {
+                        "description": "取回请求",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/redenvelope.WalletTransferRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/util.ResponseAny"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/redenvelope/webhook/claim": {
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "redenvelope"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "请求体的 HMAC-SHA256 十六进制签名",
+                        "name": "X-Signature",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "回调领取请求",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/redenvelope.WebhookClaimRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/util.ResponseAny"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/redenvelope/webhook/deep-link": {
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "redenvelope"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "请求体的 HMAC-SHA256 十六进制签名",
+                        "name": "X-Signature",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "签发请求",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/redenvelope.WebhookClaimRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/util.Response-redenvelope_DeepLinkResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/redenvelope/{id}": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "redenvelope"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "红包ID或红包码",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "展示货币",
+                        "name": "currency",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "领取记录排序字段：time（默认）或 amount",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "领取记录排序方向：desc（默认）或 asc",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "领取记录分页游标，取上一页的 next_cursor",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "是否将当前用户最近一次领取置顶",
+                        "name": "pin_mine",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/util.ResponseAny"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/redenvelope/{id}/bulk-claim": {
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "redenvelope"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "红包ID或红包码",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "批量代领请求",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/redenvelope.BulkClaimRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/util.Response-array_redenvelope_BulkClaimItem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/redenvelope/{id}/can-claim/{user_id}": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "redenvelope"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "红包ID或红包码",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "用户ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/util.Response-redenvelope_EligibilityResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/redenvelope/{id}/channels": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "redenvelope"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "红包ID或红包码",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/util.Response-array_redenvelope_ChannelStat"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/redenvelope/{id}/events": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "redenvelope"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "红包ID或红包码",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/util.Response-array_model_RedEnvelopeEvent"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/redenvelope/{id}/pause": {
+            "post": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "redenvelope"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "红包ID或红包码",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/util.ResponseAny"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/redenvelope/{id}/results": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "redenvelope"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "红包ID或红包码",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/util.ResponseAny"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/redenvelope/{id}/resume": {
+            "post": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "redenvelope"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "红包ID或红包码",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/util.ResponseAny"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/redenvelope/{id}/rotate": {
+            "post": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "redenvelope"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "红包ID或红包码",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/util.ResponseAny"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/redenvelope/{id}/stream": {
+            "get": {
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "redenvelope"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "红包ID或红包码",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/redenvelope.ClaimStreamEvent"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/user/pay-key": {
+            "put": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "parameters": [
+                    {
+                        "description": "request body",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/user.UpdatePayKeyRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/util.ResponseAny"
+                        }
+                    }
+                }
+            }
+        },
+        "/pay/distribute": {
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "payment"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Basic Auth (base64(client_id:client_secret))",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "分发请求",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/payment.MerchantDistributeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/util.ResponseAny"
+                        }
+                    }
+                }
+            }
+        },
+        "/pay/submit.php": {
+            "get": {
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "payment"
+                ],
+                "parameters": [
+                    {
+                        "description": "request body",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/payment.CreateOrderRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/util.ResponseAny"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "payment"
+                ],
+                "parameters": [
+                    {
+                        "description": "request body",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/payment.CreateOrderRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/util.ResponseAny"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "api_key.CreateAPIKeyRequest": {
+            "type": "object",
+            "required": [
+                "app_homepage_url",
+                "app_name",
+                "notify_url"
+            ],
+            "properties": {
+                "app_description": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "app_homepage_url": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "app_name": {
+                    "type": "string",
+                    "maxLength": 20
+                },
+                "notify_url": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "redirect_uri": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "test_mode": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "api_key.UpdateAPIKeyRequest": {
+            "type": "object",
+            "properties": {
+                "app_description": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "app_homepage_url": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "app_name": {
+                    "type": "string",
+                    "maxLength": 20
+                },
+                "notify_url": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "redirect_uri": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "test_mode": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "dashboard.UserBalanceStatsResponse": {
+            "type": "object",
+            "properties": {
+                "avg_amount": {
+                    "type": "number"
+                },
+                "max_amount": {
+                    "type": "number"
+                },
+                "median_amount": {
+                    "type": "number"
+                },
+                "min_amount": {
+                    "type": "number"
+                },
+                "std_dev": {
+                    "type": "number"
+                },
+                "total_amount": {
+                    "type": "number"
+                },
+                "total_count": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dispute.CloseDisputeRequest": {
+            "type": "object",
+            "required": [
+                "dispute_id"
+            ],
+            "properties": {
+                "dispute_id": {
+                    "type": "string",
+                    "example": "0"
+                }
+            }
+        },
+        "dispute.CreateDisputeRequest": {
+            "type": "object",
+            "required": [
+                "order_id",
+                "reason"
+            ],
+            "properties": {
+                "order_id": {
+                    "type": "string",
+                    "example": "0"
+                },
+                "reason": {
+                    "type": "string",
+                    "maxLength": 100
+                }
+            }
+        },
+        "dispute.ListDisputesRequest": {
+            "type": "object",
+            "properties": {
+                "dispute_id": {
+                    "type": "string",
+                    "example": "0"
+                },
+                "page": {
+                    "type": "integer",
+                    "minimum": 1
+                },
+                "page_size": {
+                    "type": "integer",
+                    "maximum": 100,
+                    "minimum": 1
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "disputing",
+                        "refund",
+                        "closed"
+                    ]
+                }
+            }
+        },
+        "dispute.RefundReviewRequest": {
+            "type": "object",
+            "required": [
+                "dispute_id",
+                "status"
+            ],
+            "properties": {
+                "dispute_id": {
+                    "type": "string",
+                    "example": "0"
+                },
+                "reason": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "refund",
+                        "closed"
+                    ]
+                }
+            }
+        },
+        "link.PayByLinkRequest": {
+            "type": "object",
+            "required": [
+                "pay_key",
+                "token"
+            ],
+            "properties": {
+                "pay_key": {
+                    "type": "string",
+                    "maxLength": 6
+                },
+                "remark": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "token": {
+                    "type": "string"
+                }
+            }
+        },
+        "link.PaymentLinkRequest": {
+            "type": "object",
+            "required": [
+                "amount",
+                "product_name"
+            ],
+            "properties": {
+                "amount": {
+                    "type": "number"
+                },
+                "product_name": {
+                    "type": "string",
+                    "maxLength": 30
+                },
+                "remark": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "total_limit": {
+                    "type": "integer",
+                    "minimum": 1
+                },
+                "user_limit": {
+                    "type": "integer",
+                    "minimum": 1
+                }
+            }
+        },
+        "model.PayLevel": {
+            "type": "integer",
+            "format": "int32",
+            "enum": [
+                0,
+                1,
+                2,
+                3
+            ],
+            "x-enum-varnames": [
+                "PayLevelFree",
+                "PayLevelBasic",
+                "PayLevelStandard",
+                "PayLevelPremium"
+            ]
+        },
+        "model.RedEnvelopeEvent": {
+            "type": "object",
+            "properties": {
+                "actor_id": {
+                    "type": "string",
+                    "example": "0"
+                },
+                "actor_type": {
+                    "$ref": "#/definitions/model.RedEnvelopeEventActor"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "from_status": {
+                    "$ref": "#/definitions/model.RedEnvelopeStatus"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "0"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "red_envelope_id": {
+                    "type": "string",
+                    "example": "0"
+                },
+                "to_status": {
+                    "$ref": "#/definitions/model.RedEnvelopeStatus"
+                }
+            }
+        },
+        "model.RedEnvelopeEventActor": {
+            "type": "string",
+            "enum": [
+                "system",
+                "creator",
+                "claimer"
+            ],
+            "x-enum-comments": {
+                "RedEnvelopeEventActorClaimer": "领取者，如领完或退回",
+                "RedEnvelopeEventActorCreator": "红包创建者",
+                "RedEnvelopeEventActorSystem": "系统任务，如过期退款"
+            },
+            "x-enum-descriptions": [
+                "系统任务，如过期退款",
+                "红包创建者",
+                "领取者，如领完或退回"
+            ],
+            "x-enum-varnames": [
+                "RedEnvelopeEventActorSystem",
+                "RedEnvelopeEventActorCreator",
+                "RedEnvelopeEventActorClaimer"
+            ]
+        },
+        "model.RedEnvelopeSplit": {
+            "type": "string",
+            "enum": [
+                "uniform",
+                "trust_weighted"
+            ],
+            "x-enum-comments": {
+                "RedEnvelopeSplitTrustWeighted": "按领取者信任等级加权，等级越高期望金额越大",
+                "RedEnvelopeSplitUniform": "二倍均值，每位领取者期望金额相同"
+            },
+            "x-enum-descriptions": [
+                "二倍均值，每位领取者期望金额相同",
+                "按领取者信任等级加权，等级越高期望金额越大"
+            ],
+            "x-enum-varnames": [
+                "RedEnvelopeSplitUniform",
+                "RedEnvelopeSplitTrustWeighted"
+            ]
+        },
+        "model.RedEnvelopeStatus": {
+            "type": "string",
+            "enum": [
+                "active",
+                "finished",
+                "expired",
+                "paused"
+            ],
+            "x-enum-varnames": [
+                "RedEnvelopeStatusActive",
+                "RedEnvelopeStatusFinished",
+                "RedEnvelopeStatusExpired",
+                "RedEnvelopeStatusPaused"
+            ]
+        },
+        "model.RedEnvelopeType": {
+            "type": "string",
+            "enum": [
+                "fixed",
+                "random",
+                "hybrid"
+            ],
+            "x-enum-varnames": [
+                "RedEnvelopeTypeFixed",
+                "RedEnvelopeTypeRandom",
+                "RedEnvelopeTypeHybrid"
+            ]
+        },
+        "model.RedEnvelopeVisibility": {
+            "type": "string",
+            "enum": [
+                "public",
+                "unlisted",
+                "private"
+            ],
+            "x-enum-comments": {
+                "RedEnvelopeVisibilityPrivate": "私密，仅可领取名单内的用户领取",
+                "RedEnvelopeVisibilityPublic": "公开，出现在红包广场",
+                "RedEnvelopeVisibilityUnlisted": "不公开，仅凭链接领取"
+            },
+            "x-enum-descriptions": [
+                "公开，出现在红包广场",
+                "不公开，仅凭链接领取",
+                "私密，仅可领取名单内的用户领取"
+            ],
+            "x-enum-varnames": [
+                "RedEnvelopeVisibilityPublic",
+                "RedEnvelopeVisibilityUnlisted",
+                "RedEnvelopeVisibilityPrivate"
+            ]
+        },
+        "oauth.CallbackRequest": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string"
+                },
+                "state": {
+                    "type": "string"
+                }
+            }
+        },
+        "order.TransactionListRequest": {
+            "type": "object",
+            "properties": {
+                "client_id": {
+                    "type": "string"
+                },
+                "endTime": {
+                    "type": "string"
+                },
+                "group_by_envelope": {
+                    "description": "GroupByEnvelope 为 true 时按红包分组返回，分页及总数按分组计算",
+                    "type": "boolean"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "0"
+                },
+                "order_name": {
+                    "type": "string"
+                },
+                "page": {
+                    "type": "integer",
+                    "minimum": 1
+                },
+                "page_size": {
+                    "type": "integer",
+                    "maximum": 100,
+                    "minimum": 1
+                },
+                "payee_username": {
+                    "type": "string"
+                },
+                "payer_username": {
+                    "type": "string"
+                },
+                "startTime": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "success",
+                        "pending",
+                        "failed",
+                        "expired",
+                        "disputing",
                         "refund",
-                        "closed"
+                        "refused"
+                    ]
+                },
+                "type": {
+                    "type": "string",
+                    "enum": [
+                        "receive",
+                        "payment",
+                        "transfer",
+                        "community",
+                        "online",
+                        "test",
+                        "distribute",
+                        "red_envelope_send",
+                        "red_envelope_receive",
+                        "red_envelope_refund",
+                        "red_envelope_escrow",
+                        "red_envelope_return",
+                        "red_envelope_wallet",
+                        "red_envelope_jackpot"
                     ]
                 }
             }
         },
-        "dispute.RefundReviewRequest": {
+        "payment.CreateOrderRequest": {
+            "type": "object",
+            "required": [
+                "amount",
+                "order_name"
+            ],
+            "properties": {
+                "amount": {
+                    "type": "number"
+                },
+                "merchant_order_no": {
+                    "type": "string",
+                    "maxLength": 64,
+                    "minLength": 1
+                },
+                "order_name": {
+                    "type": "string",
+                    "maxLength": 64
+                },
+                "payment_type": {
+                    "type": "string"
+                },
+                "remark": {
+                    "type": "string",
+                    "maxLength": 100
+                }
+            }
+        },
+        "payment.MerchantDistributeRequest": {
+            "type": "object",
+            "required": [
+                "amount",
+                "user_id",
+                "username"
+            ],
+            "properties": {
+                "amount": {
+                    "type": "number"
+                },
+                "out_trade_no": {
+                    "type": "string",
+                    "maxLength": 64,
+                    "minLength": 1
+                },
+                "remark": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "user_id": {
+                    "type": "integer"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "payment.PayOrderRequest": {
+            "type": "object",
+            "required": [
+                "order_no",
+                "pay_key"
+            ],
+            "properties": {
+                "order_no": {
+                    "type": "string"
+                },
+                "pay_key": {
+                    "type": "string",
+                    "maxLength": 6
+                }
+            }
+        },
+        "payment.QueryMerchantOrderResponse": {
+            "type": "object",
+            "properties": {
+                "addtime": {
+                    "type": "string",
+                    "example": "2023-12-08 12:00:00"
+                },
+                "code": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "endtime": {
+                    "type": "string",
+                    "example": "2023-12-08 12:05:00"
+                },
+                "money": {
+                    "type": "string",
+                    "example": "10.00"
+                },
+                "msg": {
+                    "type": "string",
+                    "example": "查询订单号成功！"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "商品名称"
+                },
+                "out_trade_no": {
+                    "type": "string",
+                    "example": "M202312080001"
+                },
+                "pid": {
+                    "type": "string",
+                    "example": "1001"
+                },
+                "status": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "trade_no": {
+                    "type": "string",
+                    "example": "123456"
+                },
+                "type": {
+                    "type": "string",
+                    "example": "epay"
+                }
+            }
+        },
+        "payment.RefundMerchantOrderResponse": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "msg": {
+                    "type": "string",
+                    "example": "退款成功"
+                }
+            }
+        },
+        "payment.RefundOrderRequest": {
+            "type": "object",
+            "required": [
+                "key",
+                "money",
+                "pid",
+                "trade_no"
+            ],
+            "properties": {
+                "key": {
+                    "type": "string"
+                },
+                "money": {
+                    "type": "number"
+                },
+                "out_trade_no": {
+                    "type": "string"
+                },
+                "pid": {
+                    "type": "string"
+                },
+                "trade_no": {
+                    "type": "integer"
+                }
+            }
+        },
+        "payment.TransferRequest": {
+            "type": "object",
+            "required": [
+                "amount",
+                "pay_key",
+                "recipient_id",
+                "recipient_username"
+            ],
+            "properties": {
+                "amount": {
+                    "type": "number"
+                },
+                "pay_key": {
+                    "type": "string",
+                    "maxLength": 6
+                },
+                "recipient_id": {
+                    "type": "string",
+                    "example": "0"
+                },
+                "recipient_username": {
+                    "type": "string"
+                },
+                "remark": {
+                    "type": "string",
+                    "maxLength": 100
+                }
+            }
+        },
+        "red_envelope.refundPause": {
+            "type": "object",
+            "properties": {
+                "paused": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "red_envelope.updateRefundPauseRequest": {
+            "type": "object",
+            "required": [
+                "paused"
+            ],
+            "properties": {
+                "paused": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "redenvelope.BulkClaimItem": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "number"
+                },
+                "user_id": {
+                    "type": "string",
+                    "example": "0"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "redenvelope.BulkClaimRequest": {
+            "type": "object",
+            "required": [
+                "usernames"
+            ],
+            "properties": {
+                "usernames": {
+                    "type": "array",
+                    "maxItems": 100,
+                    "minItems": 1,
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "redenvelope.ChannelStat": {
+            "type": "object",
+            "properties": {
+                "channel": {
+                    "type": "string"
+                },
+                "claim_count": {
+                    "type": "integer"
+                },
+                "total_amount": {
+                    "type": "number"
+                }
+            }
+        },
+        "redenvelope.ClaimBatchEntry": {
+            "type": "object",
+            "required": [
+                "code"
+            ],
+            "properties": {
+                "claim_token": {
+                    "type": "string",
+                    "maxLength": 64
+                },
+                "code": {
+                    "type": "string",
+                    "maxLength": 32
+                }
+            }
+        },
+        "redenvelope.ClaimBatchItem": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "number"
+                },
+                "code": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "red_envelope_id": {
+                    "type": "string",
+                    "example": "0"
+                }
+            }
+        },
+        "redenvelope.ClaimBatchRequest": {
+            "type": "object",
+            "required": [
+                "items"
+            ],
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "maxItems": 20,
+                    "minItems": 1,
+                    "items": {
+                        "$ref": "#/definitions/redenvelope.ClaimBatchEntry"
+                    }
+                }
+            }
+        },
+        "redenvelope.ClaimReceipt": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "string"
+                },
+                "iat": {
+                    "type": "integer"
+                },
+                "iss": {
+                    "type": "string"
+                },
+                "jti": {
+                    "type": "string"
+                },
+                "red_envelope_id": {
+                    "type": "string"
+                },
+                "sub": {
+                    "type": "string"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "redenvelope.ClaimRequest": {
+            "type": "object",
+            "properties": {
+                "channel": {
+                    "type": "string",
+                    "maxLength": 32
+                },
+                "claim_token": {
+                    "type": "string",
+                    "maxLength": 64
+                },
+                "code": {
+                    "type": "string",
+                    "maxLength": 64
+                },
+                "currency": {
+                    "type": "string",
+                    "maxLength": 8
+                },
+                "forward_to_new_envelope": {
+                    "$ref": "#/definitions/redenvelope.ForwardEnvelopeRequest"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "0"
+                }
+            }
+        },
+        "redenvelope.ClaimStreamEvent": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "number"
+                },
+                "avatar_url": {
+                    "type": "string"
+                },
+                "event": {
+                    "type": "string"
+                },
+                "remaining_amount": {
+                    "type": "number"
+                },
+                "remaining_count": {
+                    "type": "integer"
+                },
+                "status": {
+                    "$ref": "#/definitions/model.RedEnvelopeStatus"
+                },
+                "user_id": {
+                    "type": "string",
+                    "example": "0"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "redenvelope.ClosingSoonItem": {
+            "type": "object",
+            "properties": {
+                "base_amount": {
+                    "type": "number"
+                },
+                "claim_deadline": {
+                    "type": "string"
+                },
+                "claim_message": {
+                    "type": "string"
+                },
+                "claims_archived_at": {
+                    "type": "string"
+                },
+                "code": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "creator_avatar_url": {
+                    "type": "string"
+                },
+                "creator_id": {
+                    "type": "string",
+                    "example": "0"
+                },
+                "creator_username": {
+                    "type": "string"
+                },
+                "denomination": {
+                    "type": "number"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "expiry_notified_at": {
+                    "type": "string"
+                },
+                "fairness_gini": {
+                    "type": "number"
+                },
+                "fairness_ratio": {
+                    "type": "number"
+                },
+                "funded_by_escrow": {
+                    "type": "boolean"
+                },
+                "greeting": {
+                    "type": "string"
+                },
+                "greeting_hidden": {
+                    "type": "boolean"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "0"
+                },
+                "max_claims_per_user": {
+                    "type": "integer"
+                },
+                "min_claim_amount": {
+                    "type": "number"
+                },
+                "paused_at": {
+                    "type": "string"
+                },
+                "refunded_at": {
+                    "type": "string"
+                },
+                "remaining_amount": {
+                    "type": "number"
+                },
+                "remaining_count": {
+                    "type": "integer"
+                },
+                "require_confirm": {
+                    "type": "boolean"
+                },
+                "reserved_amount": {
+                    "type": "number"
+                },
+                "reserved_count": {
+                    "type": "integer"
+                },
+                "seconds_left": {
+                    "type": "integer"
+                },
+                "signed_receipt": {
+                    "type": "boolean"
+                },
+                "split": {
+                    "$ref": "#/definitions/model.RedEnvelopeSplit"
+                },
+                "status": {
+                    "$ref": "#/definitions/model.RedEnvelopeStatus"
+                },
+                "target_wallet": {
+                    "type": "string"
+                },
+                "test_mode": {
+                    "type": "boolean"
+                },
+                "total_amount": {
+                    "type": "number"
+                },
+                "total_count": {
+                    "type": "integer"
+                },
+                "type": {
+                    "$ref": "#/definitions/model.RedEnvelopeType"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "visibility": {
+                    "$ref": "#/definitions/model.RedEnvelopeVisibility"
+                },
+                "warnings": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "redenvelope.ConfirmRequest": {
             "type": "object",
             "required": [
-                "dispute_id",
-                "status"
+                "id",
+                "reservation_token"
             ],
             "properties": {
-                "dispute_id": {
+                "currency": {
                     "type": "string",
-                    "example": "0"
+                    "maxLength": 8
                 },
-                "reason": {
+                "id": {
                     "type": "string",
-                    "maxLength": 100
+                    "example": "0"
                 },
-                "status": {
+                "reservation_token": {
                     "type": "string",
-                    "enum": [
-                        "refund",
-                        "closed"
-                    ]
+                    "maxLength": 64
                 }
             }
         },
-        "link.PayByLinkRequest": {
+        "redenvelope.ConstraintsResponse": {
+            "type": "object",
+            "properties": {
+                "amount_decimal_places": {
+                    "description": "金额最大小数位数",
+                    "type": "integer"
+                },
+                "daily_limit": {
+                    "description": "每日发红包的个数限制",
+                    "type": "integer"
+                },
+                "denomination": {
+                    "description": "领取金额取整面额",
+                    "type": "number"
+                },
+                "expiry_hours": {
+                    "description": "红包有效期（小时）",
+                    "type": "integer"
+                },
+                "fee_rate": {
+                    "description": "手续费率",
+                    "type": "number"
+                },
+                "jackpot_rate": {
+                    "description": "奖池抽成比例",
+                    "type": "number"
+                },
+                "max_amount": {
+                    "description": "单个红包的最大金额",
+                    "type": "number"
+                },
+                "max_claim_message_length": {
+                    "description": "领取成功提示语最大字符数",
+                    "type": "integer"
+                },
+                "max_count": {
+                    "description": "红包个数上限",
+                    "type": "integer"
+                },
+                "max_greeting_graphemes": {
+                    "description": "祝福语按字形簇计算的长度上限（0表示不限制）",
+                    "type": "integer"
+                },
+                "max_greeting_length": {
+                    "description": "祝福语最大字符数",
+                    "type": "integer"
+                },
+                "min_amount": {
+                    "description": "红包最低金额",
+                    "type": "number"
+                },
+                "min_count": {
+                    "description": "红包个数下限",
+                    "type": "integer"
+                },
+                "min_per_envelope": {
+                    "description": "每个红包的最低平均金额",
+                    "type": "number"
+                }
+            }
+        },
+        "redenvelope.CostProjection": {
+            "type": "object",
+            "properties": {
+                "net_cost": {
+                    "type": "number"
+                },
+                "refund_amount": {
+                    "type": "number"
+                }
+            }
+        },
+        "redenvelope.CreateRecurringRequest": {
             "type": "object",
             "required": [
+                "cron",
                 "pay_key",
-                "token"
+                "spec"
             ],
             "properties": {
-                "pay_key": {
+                "cron": {
                     "type": "string",
-                    "maxLength": 6
+                    "maxLength": 64
                 },
-                "remark": {
+                "ends_at": {
+                    "type": "string"
+                },
+                "pay_key": {
                     "type": "string",
-                    "maxLength": 100
+                    "maxLength": 10
                 },
-                "token": {
-                    "type": "string"
+                "spec": {
+                    "$ref": "#/definitions/redenvelope.EnvelopeSpec"
                 }
             }
         },
-        "link.PaymentLinkRequest": {
+        "redenvelope.CreateRequest": {
             "type": "object",
             "required": [
-                "amount",
-                "product_name"
+                "pay_key",
+                "total_count",
+                "type"
             ],
             "properties": {
-                "amount": {
+                "allowed_usernames": {
+                    "type": "array",
+                    "maxItems": 200,
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "base_amount": {
                     "type": "number"
                 },
-                "product_name": {
+                "claim_message": {
                     "type": "string",
-                    "maxLength": 30
+                    "maxLength": 100
                 },
-                "remark": {
+                "from_escrow": {
+                    "type": "boolean"
+                },
+                "greeting": {
                     "type": "string",
                     "maxLength": 100
                 },
-                "total_limit": {
+                "greeting_hidden": {
+                    "type": "boolean"
+                },
+                "max_claims_per_user": {
                     "type": "integer",
                     "minimum": 1
                 },
-                "user_limit": {
+                "min_claim_amount": {
+                    "type": "number"
+                },
+                "opaque_link": {
+                    "type": "boolean"
+                },
+                "pay_key": {
+                    "type": "string",
+                    "maxLength": 10
+                },
+                "per_amount": {
+                    "type": "number"
+                },
+                "require_confirm": {
+                    "type": "boolean"
+                },
+                "reserved_allocations": {
+                    "description": "ReservedAllocations 为指定用户名预留的领取金额，每人占用一个名额",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "number"
+                    }
+                },
+                "signed_receipt": {
+                    "type": "boolean"
+                },
+                "split": {
+                    "enum": [
+                        "uniform",
+                        "trust_weighted"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/model.RedEnvelopeSplit"
+                        }
+                    ]
+                },
+                "target_wallet": {
+                    "type": "string",
+                    "maxLength": 32
+                },
+                "test_mode": {
+                    "type": "boolean"
+                },
+                "total_amount": {
+                    "type": "number"
+                },
+                "total_count": {
                     "type": "integer",
                     "minimum": 1
+                },
+                "type": {
+                    "enum": [
+                        "fixed",
+                        "random",
+                        "hybrid"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/model.RedEnvelopeType"
+                        }
+                    ]
+                },
+                "visibility": {
+                    "enum": [
+                        "public",
+                        "unlisted",
+                        "private"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/model.RedEnvelopeVisibility"
+                        }
+                    ]
                 }
             }
         },
-        "model.PayLevel": {
-            "type": "integer",
-            "format": "int32",
-            "enum": [
-                0,
-                1,
-                2,
-                3
-            ],
-            "x-enum-varnames": [
-                "PayLevelFree",
-                "PayLevelBasic",
-                "PayLevelStandard",
-                "PayLevelPremium"
-            ]
-        },
-        "model.RedEnvelopeType": {
-            "type": "string",
-            "enum": [
-                "fixed",
-                "random"
+        "redenvelope.DeepLinkClaimRequest": {
+            "type": "object",
+            "required": [
+                "id",
+                "token"
             ],
-            "x-enum-varnames": [
-                "RedEnvelopeTypeFixed",
-                "RedEnvelopeTypeRandom"
-            ]
+            "properties": {
+                "currency": {
+                    "type": "string",
+                    "maxLength": 8
+                },
+                "id": {
+                    "type": "string",
+                    "example": "0"
+                },
+                "token": {
+                    "type": "string",
+                    "maxLength": 256
+                }
+            }
         },
-        "oauth.CallbackRequest": {
+        "redenvelope.DeepLinkResponse": {
             "type": "object",
             "properties": {
-                "code": {
+                "expires_at": {
                     "type": "string"
                 },
-                "state": {
+                "link": {
+                    "type": "string"
+                },
+                "token": {
                     "type": "string"
                 }
             }
         },
-        "order.TransactionListRequest": {
+        "redenvelope.EligibilityResponse": {
             "type": "object",
             "properties": {
-                "client_id": {
-                    "type": "string"
+                "eligible": {
+                    "type": "boolean"
                 },
-                "endTime": {
+                "reason": {
                     "type": "string"
                 },
-                "id": {
+                "user_id": {
                     "type": "string",
                     "example": "0"
                 },
-                "order_name": {
+                "username": {
                     "type": "string"
+                }
+            }
+        },
+        "redenvelope.EnvelopeSpec": {
+            "type": "object",
+            "required": [
+                "total_count",
+                "type"
+            ],
+            "properties": {
+                "allowed_usernames": {
+                    "type": "array",
+                    "maxItems": 200,
+                    "items": {
+                        "type": "string"
+                    }
                 },
-                "page": {
-                    "type": "integer",
-                    "minimum": 1
+                "base_amount": {
+                    "type": "number"
                 },
-                "page_size": {
+                "claim_message": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "from_escrow": {
+                    "type": "boolean"
+                },
+                "greeting": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "greeting_hidden": {
+                    "type": "boolean"
+                },
+                "max_claims_per_user": {
                     "type": "integer",
-                    "maximum": 100,
                     "minimum": 1
                 },
-                "payee_username": {
-                    "type": "string"
+                "min_claim_amount": {
+                    "type": "number"
                 },
-                "payer_username": {
-                    "type": "string"
+                "opaque_link": {
+                    "type": "boolean"
                 },
-                "startTime": {
-                    "type": "string"
+                "per_amount": {
+                    "type": "number"
                 },
-                "status": {
-                    "type": "string",
+                "require_confirm": {
+                    "type": "boolean"
+                },
+                "reserved_allocations": {
+                    "description": "ReservedAllocations 为指定用户名预留的领取金额，每人占用一个名额",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "number"
+                    }
+                },
+                "signed_receipt": {
+                    "type": "boolean"
+                },
+                "split": {
                     "enum": [
-                        "success",
-                        "pending",
-                        "failed",
-                        "expired",
-                        "disputing",
-                        "refund",
-                        "refused"
+                        "uniform",
+                        "trust_weighted"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/model.RedEnvelopeSplit"
+                        }
                     ]
                 },
-                "type": {
+                "target_wallet": {
                     "type": "string",
+                    "maxLength": 32
+                },
+                "test_mode": {
+                    "type": "boolean"
+                },
+                "total_amount": {
+                    "type": "number"
+                },
+                "total_count": {
+                    "type": "integer",
+                    "minimum": 1
+                },
+                "type": {
                     "enum": [
-                        "receive",
-                        "payment",
-                        "transfer",
-                        "community",
-                        "online",
-                        "test",
-                        "distribute",
-                        "red_envelope_send",
-                        "red_envelope_receive",
-                        "red_envelope_refund"
+                        "fixed",
+                        "random",
+                        "hybrid"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/model.RedEnvelopeType"
+                        }
+                    ]
+                },
+                "visibility": {
+                    "enum": [
+                        "public",
+                        "unlisted",
+                        "private"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/model.RedEnvelopeVisibility"
+                        }
                     ]
                 }
             }
         },
-        "payment.CreateOrderRequest": {
+        "redenvelope.ErrorCodeItem": {
             "type": "object",
-            "required": [
-                "amount",
-                "order_name"
-            ],
             "properties": {
-                "amount": {
-                    "type": "number"
-                },
-                "merchant_order_no": {
-                    "type": "string",
-                    "maxLength": 64,
-                    "minLength": 1
-                },
-                "order_name": {
-                    "type": "string",
-                    "maxLength": 64
-                },
-                "payment_type": {
+                "code": {
                     "type": "string"
                 },
-                "remark": {
-                    "type": "string",
-                    "maxLength": 100
+                "messages": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
                 }
             }
         },
-        "payment.MerchantDistributeRequest": {
+        "redenvelope.EscrowRequest": {
             "type": "object",
             "required": [
                 "amount",
-                "user_id",
-                "username"
+                "pay_key"
             ],
             "properties": {
                 "amount": {
                     "type": "number"
                 },
-                "out_trade_no": {
-                    "type": "string",
-                    "maxLength": 64,
-                    "minLength": 1
-                },
-                "remark": {
+                "pay_key": {
                     "type": "string",
-                    "maxLength": 100
-                },
-                "user_id": {
-                    "type": "integer"
-                },
-                "username": {
-                    "type": "string"
+                    "maxLength": 10
                 }
             }
         },
-        "payment.PayOrderRequest": {
+        "redenvelope.ForwardEnvelopeRequest": {
             "type": "object",
             "required": [
-                "order_no",
-                "pay_key"
+                "pay_key",
+                "total_count",
+                "type"
             ],
             "properties": {
-                "order_no": {
-                    "type": "string"
+                "greeting": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "greeting_hidden": {
+                    "type": "boolean"
                 },
                 "pay_key": {
                     "type": "string",
-                    "maxLength": 6
+                    "maxLength": 10
+                },
+                "total_count": {
+                    "type": "integer",
+                    "minimum": 1
+                },
+                "type": {
+                    "enum": [
+                        "fixed",
+                        "random"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/model.RedEnvelopeType"
+                        }
+                    ]
                 }
             }
         },
-        "payment.QueryMerchantOrderResponse": {
+        "redenvelope.ListRequest": {
             "type": "object",
             "properties": {
-                "addtime": {
-                    "type": "string",
-                    "example": "2023-12-08 12:00:00"
-                },
-                "code": {
+                "page": {
                     "type": "integer",
-                    "example": 1
-                },
-                "endtime": {
-                    "type": "string",
-                    "example": "2023-12-08 12:05:00"
-                },
-                "money": {
-                    "type": "string",
-                    "example": "10.00"
-                },
-                "msg": {
-                    "type": "string",
-                    "example": "查询订单号成功！"
-                },
-                "name": {
-                    "type": "string",
-                    "example": "商品名称"
-                },
-                "out_trade_no": {
-                    "type": "string",
-                    "example": "M202312080001"
-                },
-                "pid": {
-                    "type": "string",
-                    "example": "1001"
+                    "minimum": 1
                 },
-                "status": {
+                "page_size": {
                     "type": "integer",
-                    "example": 1
-                },
-                "trade_no": {
-                    "type": "string",
-                    "example": "123456"
+                    "maximum": 100,
+                    "minimum": 1
                 },
                 "type": {
                     "type": "string",
-                    "example": "epay"
+                    "enum": [
+                        "sent",
+                        "received"
+                    ]
                 }
             }
         },
-        "payment.RefundMerchantOrderResponse": {
+        "redenvelope.PreviewItem": {
             "type": "object",
             "properties": {
-                "code": {
-                    "type": "integer",
-                    "example": 1
+                "best_case": {
+                    "$ref": "#/definitions/redenvelope.CostProjection"
                 },
-                "msg": {
-                    "type": "string",
-                    "example": "退款成功"
+                "error": {
+                    "type": "string"
+                },
+                "fee_amount": {
+                    "type": "number"
+                },
+                "jackpot_amount": {
+                    "type": "number"
+                },
+                "per_amount": {
+                    "type": "number"
+                },
+                "total_amount": {
+                    "type": "number"
+                },
+                "total_deduction": {
+                    "type": "number"
+                },
+                "warnings": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "worst_case": {
+                    "$ref": "#/definitions/redenvelope.CostProjection"
                 }
             }
         },
-        "payment.RefundOrderRequest": {
+        "redenvelope.PreviewRequest": {
             "type": "object",
             "required": [
-                "key",
-                "money",
-                "pid",
-                "trade_no"
+                "envelopes"
             ],
             "properties": {
-                "key": {
-                    "type": "string"
+                "envelopes": {
+                    "type": "array",
+                    "maxItems": 20,
+                    "minItems": 1,
+                    "items": {
+                        "$ref": "#/definitions/redenvelope.EnvelopeSpec"
+                    }
+                }
+            }
+        },
+        "redenvelope.PreviewResponse": {
+            "type": "object",
+            "properties": {
+                "envelopes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/redenvelope.PreviewItem"
+                    }
                 },
-                "money": {
+                "grand_total": {
                     "type": "number"
                 },
-                "out_trade_no": {
-                    "type": "string"
+                "total_amount": {
+                    "type": "number"
                 },
-                "pid": {
-                    "type": "string"
+                "total_fee": {
+                    "type": "number"
                 },
-                "trade_no": {
-                    "type": "integer"
+                "valid": {
+                    "type": "boolean"
                 }
             }
         },
-        "payment.TransferRequest": {
+        "redenvelope.RecurringEnvelopeItem": {
             "type": "object",
-            "required": [
-                "amount",
-                "pay_key",
-                "recipient_id",
-                "recipient_username"
-            ],
             "properties": {
-                "amount": {
-                    "type": "number"
+                "created_at": {
+                    "type": "string"
                 },
-                "pay_key": {
+                "creator_id": {
                     "type": "string",
-                    "maxLength": 6
+                    "example": "0"
                 },
-                "recipient_id": {
+                "cron": {
+                    "type": "string"
+                },
+                "enabled": {
+                    "type": "boolean"
+                },
+                "ends_at": {
+                    "type": "string"
+                },
+                "id": {
                     "type": "string",
                     "example": "0"
                 },
-                "recipient_username": {
+                "last_error": {
                     "type": "string"
                 },
-                "remark": {
+                "last_red_envelope_id": {
                     "type": "string",
-                    "maxLength": 100
+                    "example": "0"
+                },
+                "last_run_at": {
+                    "type": "string"
+                },
+                "next_run_at": {
+                    "type": "string"
+                },
+                "spec": {
+                    "$ref": "#/definitions/redenvelope.EnvelopeSpec"
+                },
+                "updated_at": {
+                    "type": "string"
                 }
             }
         },
-        "redenvelope.ClaimRequest": {
+        "redenvelope.ReserveRequest": {
             "type": "object",
             "required": [
                 "id"
@@ -2212,50 +4675,34 @@ const docTemplate = `{
                 }
             }
         },
-        "redenvelope.CreateRequest": {
+        "redenvelope.ReturnClaimRequest": {
             "type": "object",
             "required": [
-                "pay_key",
-                "total_amount",
-                "total_count",
-                "type"
+                "claim_id",
+                "pay_key"
             ],
             "properties": {
-                "greeting": {
+                "claim_id": {
                     "type": "string",
-                    "maxLength": 100
+                    "example": "0"
                 },
                 "pay_key": {
                     "type": "string",
                     "maxLength": 10
-                },
-                "total_amount": {
-                    "type": "number"
-                },
-                "total_count": {
-                    "type": "integer",
-                    "minimum": 1
-                },
-                "type": {
-                    "enum": [
-                        "fixed",
-                        "random"
-                    ],
-                    "allOf": [
-                        {
-                            "$ref": "#/definitions/model.RedEnvelopeType"
-                        }
-                    ]
                 }
             }
         },
-        "redenvelope.ListRequest": {
+        "redenvelope.SearchRequest": {
             "type": "object",
             "required": [
                 "page",
                 "page_size"
             ],
             "properties": {
+                "keyword": {
+                    "type": "string",
+                    "maxLength": 100
+                },
                 "page": {
                     "type": "integer",
                     "minimum": 1
@@ -2264,13 +4711,79 @@ const docTemplate = `{
                     "type": "integer",
                     "maximum": 100,
                     "minimum": 1
+                }
+            }
+        },
+        "redenvelope.UpdateRecurringRequest": {
+            "type": "object",
+            "properties": {
+                "enabled": {
+                    "type": "boolean"
                 },
-                "type": {
+                "ends_at": {
+                    "type": "string"
+                },
+                "no_end_date": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "redenvelope.VerifyReceiptRequest": {
+            "type": "object",
+            "required": [
+                "receipt"
+            ],
+            "properties": {
+                "receipt": {
                     "type": "string",
-                    "enum": [
-                        "sent",
-                        "received"
-                    ]
+                    "maxLength": 2048
+                }
+            }
+        },
+        "redenvelope.WalletTransferRequest": {
+            "type": "object",
+            "required": [
+                "amount",
+                "pay_key",
+                "wallet"
+            ],
+            "properties": {
+                "amount": {
+                    "type": "number"
+                },
+                "pay_key": {
+                    "type": "string",
+                    "maxLength": 10
+                },
+                "wallet": {
+                    "type": "string",
+                    "maxLength": 32
+                }
+            }
+        },
+        "redenvelope.WebhookClaimRequest": {
+            "type": "object",
+            "required": [
+                "nonce",
+                "red_envelope_id",
+                "timestamp",
+                "user_id"
+            ],
+            "properties": {
+                "nonce": {
+                    "type": "string",
+                    "maxLength": 64
+                },
+                "red_envelope_id": {
+                    "type": "string",
+                    "example": "0"
+                },
+                "timestamp": {
+                    "type": "integer"
+                },
+                "user_id": {
+                    "type": "string",
+                    "example": "0"
                 }
             }
         },
@@ -2412,6 +4925,181 @@ const docTemplate = `{
                 }
             }
         },
+        "util.Response-array_model_RedEnvelopeEvent": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.RedEnvelopeEvent"
+                    }
+                },
+                "error_msg": {
+                    "type": "string"
+                }
+            }
+        },
+        "util.Response-array_redenvelope_BulkClaimItem": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/redenvelope.BulkClaimItem"
+                    }
+                },
+                "error_msg": {
+                    "type": "string"
+                }
+            }
+        },
+        "util.Response-array_redenvelope_ChannelStat": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/redenvelope.ChannelStat"
+                    }
+                },
+                "error_msg": {
+                    "type": "string"
+                }
+            }
+        },
+        "util.Response-array_redenvelope_ClaimBatchItem": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/redenvelope.ClaimBatchItem"
+                    }
+                },
+                "error_msg": {
+                    "type": "string"
+                }
+            }
+        },
+        "util.Response-array_redenvelope_ClosingSoonItem": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/redenvelope.ClosingSoonItem"
+                    }
+                },
+                "error_msg": {
+                    "type": "string"
+                }
+            }
+        },
+        "util.Response-array_redenvelope_ErrorCodeItem": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/redenvelope.ErrorCodeItem"
+                    }
+                },
+                "error_msg": {
+                    "type": "string"
+                }
+            }
+        },
+        "util.Response-array_redenvelope_RecurringEnvelopeItem": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/redenvelope.RecurringEnvelopeItem"
+                    }
+                },
+                "error_msg": {
+                    "type": "string"
+                }
+            }
+        },
+        "util.Response-red_envelope_refundPause": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/red_envelope.refundPause"
+                },
+                "error_msg": {
+                    "type": "string"
+                }
+            }
+        },
+        "util.Response-redenvelope_ClaimReceipt": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/redenvelope.ClaimReceipt"
+                },
+                "error_msg": {
+                    "type": "string"
+                }
+            }
+        },
+        "util.Response-redenvelope_ConstraintsResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/redenvelope.ConstraintsResponse"
+                },
+                "error_msg": {
+                    "type": "string"
+                }
+            }
+        },
+        "util.Response-redenvelope_DeepLinkResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/redenvelope.DeepLinkResponse"
+                },
+                "error_msg": {
+                    "type": "string"
+                }
+            }
+        },
+        "util.Response-redenvelope_EligibilityResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/redenvelope.EligibilityResponse"
+                },
+                "error_msg": {
+                    "type": "string"
+                }
+            }
+        },
+        "util.Response-redenvelope_PreviewResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/redenvelope.PreviewResponse"
+                },
+                "error_msg": {
+                    "type": "string"
+                }
+            }
+        },
+        "util.Response-redenvelope_RecurringEnvelopeItem": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/redenvelope.RecurringEnvelopeItem"
+                },
+                "error_msg": {
+                    "type": "string"
+                }
+            }
+        },
         "util.ResponseAny": {
             "type": "object",
             "properties": {
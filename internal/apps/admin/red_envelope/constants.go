@@ -0,0 +1,26 @@
+/*
+Copyright 2025 linux.do
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package red_envelope
+
+const (
+	// exportBatchSize 全量导出时每批查询的记录数
+	exportBatchSize = 500
+	// exportRateLimitKeyFormat Redis key 格式，限制管理员全量导出频率（管理员用户ID）
+	exportRateLimitKeyFormat = "admin:red_envelope_export:rate:%d"
+	// exportRateLimitPerHour 每位管理员每小时可发起的全量导出次数
+	exportRateLimitPerHour = 20
+)
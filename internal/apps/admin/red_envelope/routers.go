@@ -0,0 +1,342 @@
+/*
+Copyright 2025 linux.do
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package red_envelope
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis_rate/v10"
+	"github.com/linux-do/credit/internal/apps/oauth"
+	"github.com/linux-do/credit/internal/db"
+	"github.com/linux-do/credit/internal/logger"
+	"github.com/linux-do/credit/internal/model"
+	"github.com/linux-do/credit/internal/util"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// getExposureRequest 红包资金敞口查询请求
+type getExposureRequest struct {
+	CreatorID uint64 `form:"creator_id"`
+}
+
+// exposure 进行中红包的未领取金额汇总
+type exposure struct {
+	ActiveCount       int64           `json:"active_count"`
+	OutstandingAmount decimal.Decimal `json:"outstanding_amount"`
+}
+
+// getExposureResponse 红包资金敞口响应
+type getExposureResponse struct {
+	System  exposure  `json:"system"`
+	Creator *exposure `json:"creator,omitempty"`
+}
+
+// GetExposure 获取进行中红包锁定的资金敞口（全站及指定创建者）
+// @Tags admin
+// @Produce json
+// @Param request query getExposureRequest false "查询参数"
+// @Success 200 {object} util.ResponseAny
+// @Router /api/v1/admin/red-envelopes/exposure [get]
+func GetExposure(c *gin.Context) {
+	var req getExposureRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+		return
+	}
+
+	var resp getExposureResponse
+
+	system, err := sumActiveRemaining(c.Request.Context(), 0)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		return
+	}
+	resp.System = system
+
+	if req.CreatorID != 0 {
+		creator, err := sumActiveRemaining(c.Request.Context(), req.CreatorID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+			return
+		}
+		resp.Creator = &creator
+	}
+
+	c.JSON(http.StatusOK, util.OK(resp))
+}
+
+// sumActiveRemaining 汇总进行中红包的个数与剩余金额，creatorID 为0时统计全站
+func sumActiveRemaining(ctx context.Context, creatorID uint64) (exposure, error) {
+	var result exposure
+	query := db.DB(ctx).Model(&model.RedEnvelope{}).
+		Select("COUNT(*) AS active_count, COALESCE(SUM(remaining_amount), 0) AS outstanding_amount").
+		Where("status = ? AND test_mode = ?", model.RedEnvelopeStatusActive, false)
+	if creatorID != 0 {
+		query = query.Where("creator_id = ?", creatorID)
+	}
+	err := query.Scan(&result).Error
+	return result, err
+}
+
+// fingerprintColumns 可用于关联分析的领取设备指纹字段
+var fingerprintColumns = map[string]string{
+	"ip":     "ip_hash",
+	"device": "device_hash",
+}
+
+// listSharedDevicesRequest 共用设备指纹的红包查询请求
+type listSharedDevicesRequest struct {
+	By          string `form:"by" binding:"omitempty,oneof=ip device"`
+	MinClaimers int    `form:"min_claimers" binding:"omitempty,min=2,max=100"`
+	Days        int    `form:"days" binding:"omitempty,min=1,max=90"`
+	Limit       int    `form:"limit" binding:"omitempty,min=1,max=100"`
+}
+
+// sharedDevice 同一红包中多个领取者共用的设备指纹
+type sharedDevice struct {
+	RedEnvelopeID uint64          `json:"red_envelope_id,string"`
+	CreatorID     uint64          `json:"creator_id,string"`
+	Fingerprint   string          `json:"fingerprint"`
+	ClaimerCount  int64           `json:"claimer_count"`
+	TotalAmount   decimal.Decimal `json:"total_amount"`
+	UserIDs       []string        `json:"user_ids" gorm:"-"`
+	UserIDList    string          `json:"-"`
+}
+
+// ListSharedDevices 查询领取者共用同一设备指纹的红包，用于识别刷红包团伙（需开启领取设备信息采集）
+// @Tags admin
+// @Produce json
+// @Param request query listSharedDevicesRequest false "查询参数"
+// @Success 200 {object} util.ResponseAny
+// @Router /api/v1/admin/red-envelopes/shared-devices [get]
+func ListSharedDevices(c *gin.Context) {
+	var req listSharedDevicesRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+		return
+	}
+	if req.By == "" {
+		req.By = "device"
+	}
+	if req.MinClaimers == 0 {
+		req.MinClaimers = 3
+	}
+	if req.Days == 0 {
+		req.Days = 7
+	}
+	if req.Limit == 0 {
+		req.Limit = 50
+	}
+
+	column := "red_envelope_claims." + fingerprintColumns[req.By]
+	var results []sharedDevice
+	if err := db.DB(c.Request.Context()).Model(&model.RedEnvelopeClaim{}).
+		Select(column+" AS fingerprint, red_envelope_claims.red_envelope_id, red_envelopes.creator_id, "+
+			"COUNT(DISTINCT red_envelope_claims.user_id) AS claimer_count, "+
+			"COALESCE(SUM(red_envelope_claims.amount), 0) AS total_amount, "+
+			"STRING_AGG(DISTINCT red_envelope_claims.user_id::text, ',') AS user_id_list").
+		Joins("JOIN red_envelopes ON red_envelopes.id = red_envelope_claims.red_envelope_id").
+		Where(column+" <> '' AND red_envelope_claims.claimed_at >= ?", time.Now().AddDate(0, 0, -req.Days)).
+		Group(column+", red_envelope_claims.red_envelope_id, red_envelopes.creator_id").
+		Having("COUNT(DISTINCT red_envelope_claims.user_id) >= ?", req.MinClaimers).
+		Order("claimer_count DESC, total_amount DESC").
+		Limit(req.Limit).
+		Scan(&results).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		return
+	}
+
+	for i := range results {
+		results[i].UserIDs = strings.Split(results[i].UserIDList, ",")
+	}
+
+	c.JSON(http.StatusOK, util.OK(results))
+}
+
+// ListEnvelopeEvents 获取指定红包的状态流转记录
+// @Tags admin
+// @Produce json
+// @Param id path string true "红包ID"
+// @Success 200 {object} util.Response[[]model.RedEnvelopeEvent]
+// @Router /api/v1/admin/red-envelopes/{id}/events [get]
+func ListEnvelopeEvents(c *gin.Context) {
+	redEnvelopeID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+		return
+	}
+
+	events := make([]model.RedEnvelopeEvent, 0)
+	if err := db.DB(c.Request.Context()).Where("red_envelope_id = ?", redEnvelopeID).
+		Order("created_at ASC, id ASC").
+		Find(&events).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, util.OK(events))
+}
+
+// exportAllRequest 全量导出请求，cursor 为上次导出收到的最后一条记录ID，用于断点续传
+type exportAllRequest struct {
+	Table  string `form:"table" binding:"required,oneof=envelopes claims"`
+	Cursor uint64 `form:"cursor"`
+}
+
+// exportLimiter 全量导出频率限制器
+var exportLimiter *redis_rate.Limiter
+
+func init() {
+	exportLimiter = redis_rate.NewLimiter(db.Redis)
+}
+
+// ExportAll 以 NDJSON 流式导出全部红包或领取记录，按ID升序每行一条记录
+// 导出中断时以收到的最后一条记录ID作为 cursor 重新请求即可继续
+// @Tags admin
+// @Produce json
+// @Param request query exportAllRequest true "查询参数"
+// @Success 200 {object} util.ResponseAny
+// @Router /api/v1/admin/red-envelopes/export [get]
+func ExportAll(c *gin.Context) {
+	var req exportAllRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+		return
+	}
+
+	ctx := c.Request.Context()
+	currentUser, _ := util.GetFromContext[*model.User](c, oauth.UserObjKey)
+	if db.Redis != nil {
+		res, err := exportLimiter.Allow(ctx, db.PrefixedKey(fmt.Sprintf(exportRateLimitKeyFormat, currentUser.ID)), redis_rate.PerHour(exportRateLimitPerHour))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+			return
+		}
+		if res.Allowed == 0 {
+			c.JSON(http.StatusTooManyRequests, util.Err(exportRateLimited))
+			return
+		}
+	}
+
+	c.Header("Content-Type", "application/x-ndjson; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="red-envelope-%s-%d.ndjson"`, req.Table, req.Cursor))
+	c.Status(http.StatusOK)
+
+	// 响应头已发送，导出中途失败时只能截断输出并记录日志，客户端据最后一条记录续传
+	query := db.DB(ctx).Where("id > ?", req.Cursor)
+	var err error
+	switch req.Table {
+	case "envelopes":
+		err = writeNDJSON[model.RedEnvelope](c, query)
+	case "claims":
+		err = writeNDJSON[model.RedEnvelopeClaim](c, query)
+	}
+	if err != nil {
+		logger.ErrorF(ctx, "管理员 %d 全量导出 %s 失败，cursor: %d: %v", currentUser.ID, req.Table, req.Cursor, err)
+	}
+}
+
+// writeNDJSON 按主键升序分批查询记录，每条记录写为一行 JSON，每批写入后立即刷新
+func writeNDJSON[T any](c *gin.Context, query *gorm.DB) error {
+	var batch []T
+	return query.FindInBatches(&batch, exportBatchSize, func(_ *gorm.DB, _ int) error {
+		for i := range batch {
+			data, err := json.Marshal(&batch[i])
+			if err != nil {
+				return err
+			}
+			if _, err := c.Writer.Write(append(data, '\n')); err != nil {
+				return err
+			}
+		}
+		c.Writer.Flush()
+		return nil
+	}).Error
+}
+
+// refundPause 过期红包自动退款暂停状态
+type refundPause struct {
+	Paused bool `json:"paused"`
+}
+
+// updateRefundPauseRequest 切换过期红包自动退款暂停状态请求
+type updateRefundPauseRequest struct {
+	Paused *bool `json:"paused" binding:"required"`
+}
+
+// GetRefundPause 获取过期红包自动退款是否暂停
+// @Tags admin
+// @Produce json
+// @Success 200 {object} util.Response[refundPause]
+// @Router /api/v1/admin/red-envelopes/refund-pause [get]
+func GetRefundPause(c *gin.Context) {
+	paused, err := model.GetBoolByKey(c.Request.Context(), model.ConfigKeyRedEnvelopeRefundPaused)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, util.OK(refundPause{Paused: paused}))
+}
+
+// UpdateRefundPause 暂停或恢复过期红包自动退款，暂停期间过期红包不会丢失，恢复后由下一轮退款任务补退
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param request body updateRefundPauseRequest true "请求参数"
+// @Success 200 {object} util.Response[refundPause]
+// @Router /api/v1/admin/red-envelopes/refund-pause [put]
+func UpdateRefundPause(c *gin.Context) {
+	var req updateRefundPauseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+		return
+	}
+
+	ctx := c.Request.Context()
+	value := "0"
+	if *req.Paused {
+		value = "1"
+	}
+
+	var config model.SystemConfig
+	if err := db.DB(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("key = ?", model.ConfigKeyRedEnvelopeRefundPaused).First(&config).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&config).Update("value", value).Error; err != nil {
+			return err
+		}
+		return db.HSetJSON(ctx, model.SystemConfigRedisHashKey, config.Key, &config)
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		return
+	}
+
+	currentUser, _ := util.GetFromContext[*model.User](c, oauth.UserObjKey)
+	logger.InfoF(ctx, "管理员 %d 将过期红包自动退款暂停状态设置为 %t", currentUser.ID, *req.Paused)
+
+	c.JSON(http.StatusOK, util.OK(refundPause{Paused: *req.Paused}))
+}
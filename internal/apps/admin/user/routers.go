@@ -17,12 +17,18 @@ limitations under the License.
 package user
 
 import (
+	"encoding/json"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/hibiken/asynq"
 	"github.com/linux-do/credit/internal/db"
+	"github.com/linux-do/credit/internal/logger"
 	"github.com/linux-do/credit/internal/model"
+	"github.com/linux-do/credit/internal/task"
+	"github.com/linux-do/credit/internal/task/scheduler"
 	"github.com/linux-do/credit/internal/util"
 	"github.com/shopspring/decimal"
 	"gorm.io/gorm"
@@ -159,5 +165,17 @@ func UpdateUserStatus(c *gin.Context) {
 		return
 	}
 
+	// 封禁后立即按配置处理该用户进行中的红包，下发失败时由定时任务兜底
+	if !req.IsActive {
+		payload, _ := json.Marshal(map[string]string{"creator_id": strconv.FormatUint(targetUser.ID, 10)})
+		if _, err := scheduler.AsynqClient.Enqueue(
+			asynq.NewTask(task.HandleBannedCreatorEnvelopesTask, payload),
+			asynq.MaxRetry(3),
+			asynq.Queue(task.QueueDefault),
+		); err != nil {
+			logger.ErrorF(c.Request.Context(), "用户ID:%d 下发封禁创建者红包处理任务失败: %v", targetUser.ID, err)
+		}
+	}
+
 	c.JSON(http.StatusOK, util.OKNil())
 }
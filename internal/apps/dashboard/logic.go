@@ -47,21 +47,26 @@ func queryDailyAmounts(ctx context.Context, userID uint64, isIncome bool, startD
 	if isIncome {
 		// 收入查询：payee_user_id = user
 		// 包括：普通收款、红包领取(red_envelope_receive)、红包退款(red_envelope_refund)
+		// 排除红包托管资金及专用钱包划转(red_envelope_escrow、red_envelope_wallet)，仅为自有资金在余额与托管之间移动
+		// 排除测试订单(test)，测试模式下未实际变动余额
 		err = db.DB(ctx).Model(&model.Order{}).
 			Select("DATE_TRUNC('day', created_at) as date, SUM(amount) as amount").
 			Where("payee_user_id = ?", userID).
 			Where("status = ?", model.OrderStatusSuccess).
+			Where("type NOT IN ?", []model.OrderType{model.OrderTypeRedEnvelopeEscrow, model.OrderTypeRedEnvelopeWallet, model.OrderTypeTest}).
 			Where("created_at >= ? AND created_at < ?", startDate, endDate).
 			Group("DATE_TRUNC('day', created_at)").
 			Scan(&results).Error
 	} else {
-		// 支出查询：payer_user_id = user，但排除 red_envelope_receive
+		// 支出查询：payer_user_id = user，但排除 red_envelope_receive 与 red_envelope_escrow
 		// red_envelope_receive 的 payer_user_id 是红包创建者，但创建者的支出已在 red_envelope_send 时计算
+		// red_envelope_escrow、red_envelope_wallet 为托管资金及专用钱包划转，实际支出同样在 red_envelope_send 时计算
+		// test 为测试订单，未实际扣款
 		err = db.DB(ctx).Model(&model.Order{}).
 			Select("DATE_TRUNC('day', created_at) as date, SUM(amount) as amount").
 			Where("payer_user_id = ?", userID).
 			Where("status = ?", model.OrderStatusSuccess).
-			Where("type != ?", model.OrderTypeRedEnvelopeReceive).
+			Where("type NOT IN ?", []model.OrderType{model.OrderTypeRedEnvelopeReceive, model.OrderTypeRedEnvelopeEscrow, model.OrderTypeRedEnvelopeWallet, model.OrderTypeTest}).
 			Where("created_at >= ? AND created_at < ?", startDate, endDate).
 			Group("DATE_TRUNC('day', created_at)").
 			Scan(&results).Error
@@ -67,7 +67,7 @@ func CreatePaymentLink(c *gin.Context) {
 	}
 
 	// 验证金额
-	if err := util.ValidateAmount(req.Amount); err != nil {
+	if err := util.ValidatePaymentAmount(req.Amount); err != nil {
 		c.JSON(http.StatusBadRequest, util.Err(err.Error()))
 		return
 	}
@@ -200,7 +200,7 @@ func UpdatePaymentLink(c *gin.Context) {
 	}
 
 	// 验证金额
-	if err := util.ValidateAmount(req.Amount); err != nil {
+	if err := util.ValidatePaymentAmount(req.Amount); err != nil {
 		c.JSON(http.StatusBadRequest, util.Err(err.Error()))
 		return
 	}
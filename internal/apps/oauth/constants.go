@@ -30,3 +30,26 @@ const (
 	OAuthStateCacheKeyFormat     = "oauth:state:%s"
 	OAuthStateCacheKeyExpiration = 10 * time.Minute
 )
+
+// LoginMetricName OAuth 登录结果计数指标名
+const LoginMetricName = "oauth.login"
+
+// OAuth 登录结果
+const (
+	LoginOutcomeNewUser   = "new_user"  // 全新用户
+	LoginOutcomeRenamed   = "renamed"   // 用户改名
+	LoginOutcomeRecycled  = "recycled"  // 用户名被注销账户占用，创建新用户
+	LoginOutcomeReturning = "returning" // 已有用户
+	LoginOutcomeFailed    = "failed"    // 登录失败
+)
+
+// OAuth 登录失败的错误类型
+const (
+	LoginErrorTokenExchange = "token_exchange"
+	LoginErrorIDToken       = "id_token_invalid"
+	LoginErrorNonce         = "nonce_mismatch"
+	LoginErrorClaims        = "id_token_claims"
+	LoginErrorUserInfo      = "userinfo_fetch"
+	LoginErrorBanned        = "banned"
+	LoginErrorDatabase      = "database"
+)
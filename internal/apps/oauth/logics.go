@@ -30,7 +30,6 @@ import (
 	"github.com/linux-do/credit/internal/db"
 	"github.com/linux-do/credit/internal/model"
 	"github.com/linux-do/credit/internal/otel_trace"
-	"go.opentelemetry.io/otel/codes"
 	"gorm.io/gorm"
 )
 
@@ -47,7 +46,7 @@ func GetUserIDFromContext(c *gin.Context) uint64 {
 	return GetUserIDFromSession(session)
 }
 
-// doOAuth 执行 OAuth2/OIDC 认证流程
+// doOAuth 执行 OAuth2/OIDC 认证流程，按结果类型记录登录指标
 func doOAuth(ctx context.Context, code string, nonce string) (*model.User, error) {
 	ctx, span := otel_trace.Start(ctx, "OAuth")
 	defer span.End()
@@ -55,8 +54,7 @@ func doOAuth(ctx context.Context, code string, nonce string) (*model.User, error
 	// 使用授权码换取 Token
 	token, err := oauthConf.Exchange(ctx, code)
 	if err != nil {
-		span.SetStatus(codes.Error, err.Error())
-		return nil, err
+		return nil, recordLoginFailure(ctx, span, LoginErrorTokenExchange, err)
 	}
 
 	var userInfo model.OAuthUserInfo
@@ -65,17 +63,13 @@ func doOAuth(ctx context.Context, code string, nonce string) (*model.User, error
 		if rawIDToken, ok := token.Extra("id_token").(string); ok {
 			idToken, verifyErr := oidcVerifier.Verify(ctx, rawIDToken)
 			if verifyErr != nil {
-				err := fmt.Errorf("%s: %w", IDTokenVerifyFailed, verifyErr)
-				span.SetStatus(codes.Error, err.Error())
-				return nil, err
+				return nil, recordLoginFailure(ctx, span, LoginErrorIDToken, fmt.Errorf("%s: %w", IDTokenVerifyFailed, verifyErr))
 			}
 			if nonce != "" && idToken.Nonce != nonce {
-				span.SetStatus(codes.Error, NonceMismatch)
-				return nil, errors.New(NonceMismatch)
+				return nil, recordLoginFailure(ctx, span, LoginErrorNonce, errors.New(NonceMismatch))
 			}
 			if claimsErr := idToken.Claims(&userInfo); claimsErr != nil {
-				span.SetStatus(codes.Error, claimsErr.Error())
-				return nil, claimsErr
+				return nil, recordLoginFailure(ctx, span, LoginErrorClaims, claimsErr)
 			}
 		}
 	}
@@ -84,30 +78,26 @@ func doOAuth(ctx context.Context, code string, nonce string) (*model.User, error
 		client := oauthConf.Client(ctx, token)
 		resp, httpErr := client.Get(config.Config.OAuth2.UserEndpoint)
 		if httpErr != nil {
-			span.SetStatus(codes.Error, httpErr.Error())
-			return nil, httpErr
+			return nil, recordLoginFailure(ctx, span, LoginErrorUserInfo, httpErr)
 		}
 		defer resp.Body.Close()
 
 		responseData, readErr := io.ReadAll(resp.Body)
 		if readErr != nil {
-			span.SetStatus(codes.Error, readErr.Error())
-			return nil, readErr
+			return nil, recordLoginFailure(ctx, span, LoginErrorUserInfo, readErr)
 		}
 		if unmarshalErr := json.Unmarshal(responseData, &userInfo); unmarshalErr != nil {
-			span.SetStatus(codes.Error, unmarshalErr.Error())
-			return nil, unmarshalErr
+			return nil, recordLoginFailure(ctx, span, LoginErrorUserInfo, unmarshalErr)
 		}
 	}
 
 	if !userInfo.Active {
-		err = errors.New(common.BannedAccount)
-		span.SetStatus(codes.Error, err.Error())
-		return nil, err
+		return nil, recordLoginFailure(ctx, span, LoginErrorBanned, errors.New(common.BannedAccount))
 	}
 
 	// 处理用户信息同步逻辑
 	var user model.User
+	var outcome string
 
 	txByUsername := db.DB(ctx).Where("username = ?", userInfo.Username).First(&user)
 	if txByUsername.Error != nil {
@@ -115,44 +105,43 @@ func doOAuth(ctx context.Context, code string, nonce string) (*model.User, error
 		if txByID == nil {
 			// ID 存在但 username 不匹配(用户改名)
 			if err = user.CheckActive(); err != nil {
-				span.SetStatus(codes.Error, err.Error())
-				return nil, err
+				return nil, recordLoginFailure(ctx, span, LoginErrorBanned, err)
 			}
 			user.UpdateFromOAuthInfo(&userInfo)
 			if err = db.DB(ctx).Save(&user).Error; err != nil {
-				span.SetStatus(codes.Error, err.Error())
-				return nil, err
+				return nil, recordLoginFailure(ctx, span, LoginErrorDatabase, err)
 			}
+			outcome = LoginOutcomeRenamed
 		} else if errors.Is(txByUsername.Error, gorm.ErrRecordNotFound) {
 			// ID 和 username 都不存在(全新用户)
 			user = model.User{}
 			if err = user.CreateWithInitialCredit(ctx, &userInfo); err != nil {
-				span.SetStatus(codes.Error, err.Error())
-				return nil, err
+				return nil, recordLoginFailure(ctx, span, LoginErrorDatabase, err)
 			}
+			outcome = LoginOutcomeNewUser
 		} else {
 			// query failed
-			span.SetStatus(codes.Error, txByUsername.Error.Error())
-			return nil, txByUsername.Error
+			return nil, recordLoginFailure(ctx, span, LoginErrorDatabase, txByUsername.Error)
 		}
 	} else {
 		if user.ID != userInfo.GetID() {
 			// username 相同但 ID 不同(账户注销后被新用户占用)
 			if err = user.CreateWithInitialCredit(ctx, &userInfo); err != nil {
-				span.SetStatus(codes.Error, err.Error())
-				return nil, err
+				return nil, recordLoginFailure(ctx, span, LoginErrorDatabase, err)
 			}
+			outcome = LoginOutcomeRecycled
 		} else {
 			if err = user.CheckActive(); err != nil {
-				span.SetStatus(codes.Error, err.Error())
-				return nil, err
+				return nil, recordLoginFailure(ctx, span, LoginErrorBanned, err)
 			}
 			user.UpdateFromOAuthInfo(&userInfo)
 			if err = db.DB(ctx).Save(&user).Error; err != nil {
-				span.SetStatus(codes.Error, err.Error())
-				return nil, err
+				return nil, recordLoginFailure(ctx, span, LoginErrorDatabase, err)
 			}
+			outcome = LoginOutcomeReturning
 		}
 	}
+
+	recordLoginSuccess(ctx, span, outcome, user.ID)
 	return &user, nil
 }
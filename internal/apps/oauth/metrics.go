@@ -0,0 +1,72 @@
+/*
+Copyright 2025 linux.do
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oauth
+
+import (
+	"context"
+	"log"
+
+	"github.com/linux-do/credit/internal/logger"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/noop"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// loginCounter OAuth 登录结果计数，按 outcome 及 error_type 区分
+var loginCounter metric.Int64Counter
+
+func init() {
+	var err error
+	loginCounter, err = otel.Meter("github.com/linux-do/credit/oauth").Int64Counter(
+		LoginMetricName,
+		metric.WithDescription("OAuth 登录结果计数"),
+		metric.WithUnit("{login}"),
+	)
+	if err != nil {
+		// 指标仅用于观测，初始化失败时退化为不记录
+		log.Printf("[OAuth] init login counter failed, login metrics disabled: %v", err)
+		loginCounter = noop.Int64Counter{}
+	}
+}
+
+// recordLoginSuccess 记录登录成功的结果类型
+func recordLoginSuccess(ctx context.Context, span trace.Span, outcome string, userID uint64) {
+	span.SetAttributes(attribute.String("oauth.outcome", outcome))
+	loginCounter.Add(ctx, 1, metric.WithAttributes(
+		attribute.String("outcome", outcome),
+		attribute.String("error_type", ""),
+	))
+	logger.InfoF(ctx, "OAuth 登录成功 outcome=%s user_id=%d", outcome, userID)
+}
+
+// recordLoginFailure 记录登录失败的错误类型并标记 span，返回原错误
+func recordLoginFailure(ctx context.Context, span trace.Span, errorType string, err error) error {
+	span.SetStatus(codes.Error, err.Error())
+	span.SetAttributes(
+		attribute.String("oauth.outcome", LoginOutcomeFailed),
+		attribute.String("oauth.error_type", errorType),
+	)
+	loginCounter.Add(ctx, 1, metric.WithAttributes(
+		attribute.String("outcome", LoginOutcomeFailed),
+		attribute.String("error_type", errorType),
+	))
+	logger.WarnF(ctx, "OAuth 登录失败 error_type=%s error=%v", errorType, err)
+	return err
+}
@@ -17,6 +17,7 @@ limitations under the License.
 package order
 
 import (
+	"fmt"
 	"net/http"
 	"time"
 
@@ -25,12 +26,17 @@ import (
 	"github.com/linux-do/credit/internal/db"
 	"github.com/linux-do/credit/internal/model"
 	"github.com/linux-do/credit/internal/util"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
 )
 
+// transactionGroupKey 按红包分组时的分组键：关联红包的订单按红包ID分组，其余订单各自成组
+const transactionGroupKey = "CASE WHEN orders.red_envelope_id IS NULL THEN 'order:' || orders.id ELSE 'envelope:' || orders.red_envelope_id END"
+
 type TransactionListRequest struct {
 	Page          int        `json:"page" form:"page" binding:"min=1"`
 	PageSize      int        `json:"page_size" form:"page_size" binding:"min=1,max=100"`
-	Type          string     `json:"type" form:"type" binding:"omitempty,oneof=receive payment transfer community online test distribute red_envelope_send red_envelope_receive red_envelope_refund"`
+	Type          string     `json:"type" form:"type" binding:"omitempty,oneof=receive payment transfer community online test distribute red_envelope_send red_envelope_receive red_envelope_refund red_envelope_escrow red_envelope_return red_envelope_wallet red_envelope_jackpot"`
 	Status        string     `json:"status" form:"status" binding:"omitempty,oneof=success pending failed expired disputing refund refused"`
 	ClientID      string     `json:"client_id" form:"client_id" binding:"omitempty"`
 	StartTime     *time.Time `json:"startTime" form:"startTime" binding:"omitempty"`
@@ -39,24 +45,38 @@ type TransactionListRequest struct {
 	OrderName     string     `json:"order_name" form:"order_name" binding:"omitempty"`
 	PayerUsername string     `json:"payer_username" form:"payer_username" binding:"omitempty"`
 	PayeeUsername string     `json:"payee_username" form:"payee_username" binding:"omitempty"`
+	// GroupByEnvelope 为 true 时按红包分组返回，分页及总数按分组计算
+	GroupByEnvelope bool `json:"group_by_envelope" form:"group_by_envelope"`
 }
 
 type TransactionListResponse struct {
-	Total    int64 `json:"total"`
-	Page     int   `json:"page"`
-	PageSize int   `json:"page_size"`
-	Orders   []struct {
-		model.Order
-		AppName        string  `json:"app_name"`
-		AppHomepageURL string  `json:"app_homepage_url"`
-		AppDescription string  `json:"app_description"`
-		RedirectURI    string  `json:"redirect_uri"`
-		DisputeID      *uint64 `json:"dispute_id,string"`
-		PayerUsername  string  `json:"payer_username"`
-		PayeeUsername  string  `json:"payee_username"`
-		PayerAvatarURL string  `json:"payer_avatar_url"`
-		PayeeAvatarURL string  `json:"payee_avatar_url"`
-	} `json:"orders"`
+	Total    int64              `json:"total"`
+	Page     int                `json:"page"`
+	PageSize int                `json:"page_size"`
+	Orders   []TransactionOrder `json:"orders"`
+	Groups   []TransactionGroup `json:"groups,omitempty"`
+}
+
+// TransactionOrder 交易列表中的订单
+type TransactionOrder struct {
+	model.Order
+	AppName        string  `json:"app_name"`
+	AppHomepageURL string  `json:"app_homepage_url"`
+	AppDescription string  `json:"app_description"`
+	RedirectURI    string  `json:"redirect_uri"`
+	DisputeID      *uint64 `json:"dispute_id,string"`
+	PayerUsername  string  `json:"payer_username"`
+	PayeeUsername  string  `json:"payee_username"`
+	PayerAvatarURL string  `json:"payer_avatar_url"`
+	PayeeAvatarURL string  `json:"payee_avatar_url"`
+}
+
+// TransactionGroup 按红包分组的交易，RedEnvelopeID 为空时为一笔与红包无关的订单
+// NetAmount 为当前用户在组内成功订单上的净收支，收入为正、支出为负
+type TransactionGroup struct {
+	RedEnvelopeID *uint64            `json:"red_envelope_id,string,omitempty"`
+	NetAmount     decimal.Decimal    `json:"net_amount"`
+	Orders        []TransactionOrder `json:"orders"`
 }
 
 // ListTransactions 获取交易列表
@@ -93,6 +113,10 @@ func ListTransactions(c *gin.Context) {
 		case model.OrderTypeCommunity, model.OrderTypeRedEnvelopeRefund, model.OrderTypeRedEnvelopeReceive:
 			// community、red_envelope_refund、red_envelope_receive 类型：查询当前用户作为收款方的订单
 			baseQuery = baseQuery.Where("orders.type = ? AND orders.payee_user_id = ?", orderType, user.ID)
+		case model.OrderTypeRedEnvelopeEscrow, model.OrderTypeRedEnvelopeWallet, model.OrderTypeRedEnvelopeJackpot:
+			// red_envelope_escrow、red_envelope_wallet 类型：存入时当前用户为付款方，取回时为收款方
+			// red_envelope_jackpot 类型：创建红包抽成时当前用户为付款方，获得奖池时为收款方
+			baseQuery = baseQuery.Where("orders.type = ? AND (orders.payer_user_id = ? OR orders.payee_user_id = ?)", orderType, user.ID, user.ID)
 		case model.OrderTypeOnline:
 			// online 类型：商家可查看自己 client_id 的所有订单，普通用户只能查看与自己相关的订单
 			if req.ClientID != "" {
@@ -112,8 +136,9 @@ func ListTransactions(c *gin.Context) {
 			} else {
 				baseQuery = baseQuery.Where("orders.type = ? AND (orders.payer_user_id = ? OR orders.payee_user_id = ?)", orderType, user.ID, user.ID)
 			}
-		case model.OrderTypePayment, model.OrderTypeTransfer, model.OrderTypeTest, model.OrderTypeDistribute, model.OrderTypeRedEnvelopeSend:
-			// payment、transfer、test、distribute、red_envelope_send 类型：查询当前用户作为付款方的订单
+		case model.OrderTypePayment, model.OrderTypeTransfer, model.OrderTypeTest, model.OrderTypeDistribute, model.OrderTypeRedEnvelopeSend,
+			model.OrderTypeRedEnvelopeReturn:
+			// payment、transfer、test、distribute、red_envelope_send、red_envelope_return 类型：查询当前用户作为付款方的订单
 			baseQuery = baseQuery.Where("orders.type = ? AND orders.payer_user_id = ?", orderType, user.ID)
 		}
 	} else {
@@ -146,6 +171,11 @@ func ListTransactions(c *gin.Context) {
 		baseQuery = baseQuery.Where("orders.created_at <= ?", req.EndTime)
 	}
 
+	if req.GroupByEnvelope {
+		listGroupedTransactions(c, baseQuery.Session(&gorm.Session{}), &req, user.ID)
+		return
+	}
+
 	var total int64
 	if err := baseQuery.Count(&total).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
@@ -173,3 +203,82 @@ func ListTransactions(c *gin.Context) {
 
 	c.JSON(http.StatusOK, util.OK(response))
 }
+
+// listGroupedTransactions 按红包分组返回交易列表：同一红包的发送、领取、退款等订单归入一组，其余订单各自成组
+// 分组按组内最近一笔订单的时间倒序分页，query 须为可复用的查询（Session）
+func listGroupedTransactions(c *gin.Context, query *gorm.DB, req *TransactionListRequest, userID uint64) {
+	ctx := c.Request.Context()
+
+	var total int64
+	if err := db.DB(ctx).Table("(?) AS feed", query.Select(transactionGroupKey+" AS group_key")).
+		Distinct("group_key").Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		return
+	}
+
+	response := &TransactionListResponse{
+		Total:    total,
+		Page:     req.Page,
+		PageSize: req.PageSize,
+		Orders:   []TransactionOrder{},
+		Groups:   []TransactionGroup{},
+	}
+
+	var groupKeys []string
+	offset := (req.Page - 1) * req.PageSize
+	if err := db.DB(ctx).Table("(?) AS feed", query.Select(transactionGroupKey+" AS group_key, orders.created_at")).
+		Group("group_key").
+		Order("MAX(created_at) DESC, group_key DESC").
+		Offset(offset).Limit(req.PageSize).
+		Pluck("group_key", &groupKeys).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		return
+	}
+	if len(groupKeys) == 0 {
+		c.JSON(http.StatusOK, util.OK(response))
+		return
+	}
+
+	var orders []TransactionOrder
+	if err := query.Where(transactionGroupKey+" IN ?", groupKeys).
+		Order("orders.created_at DESC").
+		Find(&orders).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		return
+	}
+
+	// 按分组键的分页顺序组装，组内订单按时间倒序
+	groupIndex := make(map[string]int, len(groupKeys))
+	for i, key := range groupKeys {
+		groupIndex[key] = i
+	}
+	response.Groups = make([]TransactionGroup, len(groupKeys))
+	for i := range orders {
+		order := orders[i]
+		if order.Type == model.OrderTypePayment && order.PayeeUserID == userID {
+			order.Type = model.OrderTypeReceive
+		}
+
+		key := fmt.Sprintf("order:%d", order.ID)
+		if order.RedEnvelopeID != nil {
+			key = fmt.Sprintf("envelope:%d", *order.RedEnvelopeID)
+		}
+		index, ok := groupIndex[key]
+		if !ok {
+			continue
+		}
+
+		group := &response.Groups[index]
+		group.RedEnvelopeID = order.RedEnvelopeID
+		group.Orders = append(group.Orders, order)
+		if order.Status == model.OrderStatusSuccess && order.PayerUserID != order.PayeeUserID {
+			if order.PayeeUserID == userID {
+				group.NetAmount = group.NetAmount.Add(order.Amount)
+			} else if order.PayerUserID == userID {
+				group.NetAmount = group.NetAmount.Sub(order.Amount)
+			}
+		}
+	}
+
+	c.JSON(http.StatusOK, util.OK(response))
+}
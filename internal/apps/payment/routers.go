@@ -247,7 +247,7 @@ func RefundMerchantOrder(c *gin.Context) {
 		return
 	}
 
-	if err := util.ValidateAmount(req.Amount); err != nil {
+	if err := util.ValidatePaymentAmount(req.Amount); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"code": -1, "msg": err.Error()})
 		return
 	}
@@ -347,7 +347,7 @@ func MerchantDistribute(c *gin.Context) {
 		return
 	}
 
-	if err := util.ValidateAmount(req.Amount); err != nil {
+	if err := util.ValidatePaymentAmount(req.Amount); err != nil {
 		c.JSON(http.StatusBadRequest, util.Err(err.Error()))
 		return
 	}
@@ -636,7 +636,7 @@ func Transfer(c *gin.Context) {
 		return
 	}
 
-	if err := util.ValidateAmount(req.Amount); err != nil {
+	if err := util.ValidatePaymentAmount(req.Amount); err != nil {
 		c.JSON(http.StatusBadRequest, util.Err(err.Error()))
 		return
 	}
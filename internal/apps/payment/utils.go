@@ -193,7 +193,7 @@ func VerifySignature(c *gin.Context, apiKey *model.MerchantAPIKey) (*CreateOrder
 	}
 
 	// 验证金额
-	if err := util.ValidateAmount(req.Amount); err != nil {
+	if err := util.ValidatePaymentAmount(req.Amount); err != nil {
 		return nil, err
 	}
 
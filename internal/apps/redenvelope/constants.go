@@ -0,0 +1,222 @@
+/*
+Copyright 2025 linux.do
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package redenvelope
+
+import "time"
+
+const (
+	// ClaimTokenKeyFormat Redis key 格式，存储用户针对某个红包的一次性领取凭证（红包ID、用户ID）
+	ClaimTokenKeyFormat = "redenvelope:claim_token:%d:%d"
+	// ClaimTokenExpiration 领取凭证有效期
+	ClaimTokenExpiration = 2 * time.Minute
+	// HiddenGreetingMask 隐藏祝福语在领取前的展示内容
+	HiddenGreetingMask = "领取后可见"
+	// MaxGreetingLength 祝福语最大字符数，与 red_envelopes.greeting 列长度一致
+	MaxGreetingLength = 100
+	// MaxClaimMessageLength 领取成功提示语最大字符数，与 red_envelopes.claim_message 列长度一致
+	MaxClaimMessageLength = 100
+	// DefaultShareDescription 未设置祝福语时分享卡片的默认描述
+	DefaultShareDescription = "恭喜发财，大吉大利"
+	// ClaimGateKeyFormat Redis key 格式，记录红包可放行的剩余个数（红包ID）
+	ClaimGateKeyFormat = "redenvelope:claim_gate:%d"
+	// ReservedSlotsKeyFormat Redis key 格式，有序集合记录红包已预约的用户及预约过期时间（红包ID）
+	ReservedSlotsKeyFormat = "redenvelope:reserved:%d"
+	// ReservationKeyFormat Redis key 格式，存储用户针对某个红包的预约凭证（红包ID、用户ID）
+	ReservationKeyFormat = "redenvelope:reservation:%d:%d"
+	// ReservationExpiration 预约有效期，超时未确认则释放名额
+	ReservationExpiration = 30 * time.Second
+	// LiabilityKey Redis key，缓存全平台进行中红包待领取总额的近似值
+	LiabilityKey = "redenvelope:liability"
+	// LiabilityCacheExpiration 待领取总额缓存有效期，过期后从数据库重新统计
+	LiabilityCacheExpiration = time.Minute
+	// MaxListPageSize 红包列表每页数量上限，与 ListRequest 的校验保持一致
+	MaxListPageSize = 100
+	// DefaultDetailMaxClaims 红包详情领取记录数上限未配置或配置无效时的默认值
+	DefaultDetailMaxClaims = 500
+	// ExportBatchSize 导出红包数据时每批查询的记录数
+	ExportBatchSize = 500
+	// ReadinessCheckTimeout 就绪检查中单个依赖的探测超时时间
+	ReadinessCheckTimeout = 2 * time.Second
+	// DefaultCodeLength 红包码随机部分的默认长度
+	DefaultCodeLength = 8
+	// DefaultCodeAlphabet 红包码默认字符集，排除 0/O/1/I/L 等易混淆字符
+	DefaultCodeAlphabet = "23456789ABCDEFGHJKMNPQRSTUVWXYZ"
+	// MaxCodeLength 红包码（含前缀）最大长度，与 red_envelopes.code 列长度一致
+	MaxCodeLength = 32
+	// MaxCodeAttempts 红包码冲突时的最大重试次数
+	MaxCodeAttempts = 5
+	// LinkTokenBytes 不透明领取链接凭证的随机字节数，编码后长度为 LinkTokenLength
+	LinkTokenBytes = 32
+	// LinkTokenLength 不透明领取链接凭证（base64url 无填充）的长度，长于红包码以便区分
+	LinkTokenLength = 43
+	// BannedCreatorActionPause 创建者被封禁后暂停其进行中红包的领取
+	BannedCreatorActionPause = "pause"
+	// BannedCreatorActionRefund 创建者被封禁后立即将其进行中红包的剩余金额退还
+	BannedCreatorActionRefund = "refund"
+	// MaxChannelLength 领取渠道标识最大长度，与 red_envelope_claims.channel 列长度一致
+	MaxChannelLength = 32
+	// TopSendersKeyFormat Redis key 格式，缓存发红包排行榜（统计天数、条数）
+	TopSendersKeyFormat = "redenvelope:top_senders:d:%d:l:%d"
+	// TopSendersCacheExpiration 发红包排行榜缓存有效期
+	TopSendersCacheExpiration = 5 * time.Minute
+	// ConstraintsKey Redis key，缓存创建红包的校验规则
+	ConstraintsKey = "redenvelope:constraints"
+	// ConstraintsCacheExpiration 创建红包校验规则的缓存有效期，系统配置修改后最迟在此时间后生效
+	ConstraintsCacheExpiration = 30 * time.Second
+	// RedEnvelopeLifetime 红包创建后的有效期
+	RedEnvelopeLifetime = 24 * time.Hour
+	// CreateLockKeyFormat Redis key 格式，同一用户创建红包的互斥锁（用户ID）
+	CreateLockKeyFormat = "redenvelope:create_lock:%d"
+	// CreateLockTTL 创建红包互斥锁的最长持有时间，防止进程异常退出后锁无法释放
+	CreateLockTTL = 10 * time.Second
+	// CreateLockWait 等待同一用户其他创建请求完成的最长时间
+	CreateLockWait = 3 * time.Second
+	// CreateLockPollInterval 等待创建红包互斥锁时的轮询间隔
+	CreateLockPollInterval = 50 * time.Millisecond
+	// RecentCreateKeyFormat Redis key 格式，记录用户最近一次创建红包的请求指纹（用户ID）
+	RecentCreateKeyFormat = "redenvelope:recent_create:%d"
+	// DuplicateCreateWindow 相同参数的创建请求在此时间内视为重复提交
+	DuplicateCreateWindow = 3 * time.Second
+	// MaxAmountIntegerDigits 金额整数部分最大位数，与 numeric(22,4) 列保持一致
+	MaxAmountIntegerDigits = 18
+	// BlackoutLookaheadDays 计算下次开放领取时间时向后查找的天数，超出范围视为持续暂停
+	BlackoutLookaheadDays = 8
+)
+
+const (
+	// DetailRateLimitKeyFormat Redis key 格式，按IP限制红包详情查询频率
+	DetailRateLimitKeyFormat = "redenvelope:detail:rate:%s"
+	// DetailMissKeyFormat Redis key 格式，按IP统计查询不存在红包的次数
+	DetailMissKeyFormat = "redenvelope:detail:miss:%s"
+	// DetailBlockedKeyFormat Redis key 格式，标记因频繁查询不存在红包而被封禁的IP
+	DetailBlockedKeyFormat = "redenvelope:detail:blocked:%s"
+	// DetailMissWindow 不存在红包查询次数的统计窗口
+	DetailMissWindow = time.Minute
+	// DetailBlockDuration 频繁查询不存在红包后的封禁时长
+	DetailBlockDuration = 10 * time.Minute
+	// PayKeyFailureKeyFormat Redis key 格式，统计用户支付密钥连续错误次数（用户ID）
+	PayKeyFailureKeyFormat = "redenvelope:pay_key:failure:%d"
+	// PayKeyLockKeyFormat Redis key 格式，标记因支付密钥错误次数过多而被锁定的用户（用户ID）
+	PayKeyLockKeyFormat = "redenvelope:pay_key:lock:%d"
+	// PayKeyFailureWindow 支付密钥错误次数的统计窗口
+	PayKeyFailureWindow = 15 * time.Minute
+	// PayKeyLockDuration 支付密钥错误次数过多后的锁定时长
+	PayKeyLockDuration = 15 * time.Minute
+	// PayKeyVerifiedKeyFormat Redis key 格式，标记用户支付密钥近期已校验通过（用户ID）
+	PayKeyVerifiedKeyFormat = "redenvelope:pay_key:verified:%d"
+	// MaxPayKeyVerifiedCacheTTL 支付密钥校验结果缓存的最长时间
+	MaxPayKeyVerifiedCacheTTL = time.Minute
+)
+
+const (
+	// WebhookSignatureHeader 回调请求签名头，值为请求体的 HMAC-SHA256 十六进制摘要
+	WebhookSignatureHeader = "X-Signature"
+	// WebhookClaimRequestKey 上下文中已验签的回调领取请求
+	WebhookClaimRequestKey = "redenvelope_webhook_claim_request"
+	// WebhookNonceKeyFormat Redis key 格式，记录已使用的回调 nonce
+	WebhookNonceKeyFormat = "redenvelope:webhook:nonce:%s"
+	// DeepLinkTokenUsedKeyFormat Redis key 格式，记录已使用的一键领取凭证 nonce
+	DeepLinkTokenUsedKeyFormat = "redenvelope:deep_link:used:%s"
+	// DeepLinkTokenExpiration 一键领取凭证有效期
+	DeepLinkTokenExpiration = 30 * time.Minute
+	// WebhookTimestampTolerance 回调请求时间戳允许的偏差，nonce 记录保留两倍时长
+	WebhookTimestampTolerance = 5 * time.Minute
+)
+
+const (
+	// KeyNamespace 红包相关 Redis key 的公共前缀
+	KeyNamespace = "redenvelope:"
+	// StaleKeyScanCount 清理残留 key 时每次 SCAN 的数量
+	StaleKeyScanCount = 500
+	// StaleKeyMetricName 残留 Redis key 清理数量指标名
+	StaleKeyMetricName = "redenvelope.stale_keys.removed"
+	// LiabilityMetricName 全平台进行中红包待领取总额指标名
+	LiabilityMetricName = "redenvelope.outstanding_liability"
+)
+
+// 错误码参考表提供的语言
+const (
+	LocaleZhCN = "zh-CN"
+	LocaleEnUS = "en-US"
+)
+
+// 红包子系统就绪状态
+const (
+	ReadinessStatusOK       = "ok"
+	ReadinessStatusDegraded = "degraded"
+	ReadinessStatusDown     = "down"
+)
+
+// 依赖探测结果
+const (
+	DependencyStatusOK          = "ok"
+	DependencyStatusUnavailable = "unavailable"
+	DependencyStatusDisabled    = "disabled"
+)
+
+const (
+	// AudienceOverflowWarn 私密红包个数超过名单可领取总次数时仅返回提示
+	AudienceOverflowWarn = "warn"
+	// AudienceOverflowReject 私密红包个数超过名单可领取总次数时拒绝创建
+	AudienceOverflowReject = "reject"
+	// AudienceOverflowCap 私密红包个数超过名单可领取总次数时自动调整为可领取总次数
+	AudienceOverflowCap = "cap"
+)
+
+const (
+	// ViewerRoleCreator 红包创建者，可查看全部领取金额
+	ViewerRoleCreator = "creator"
+	// ViewerRoleClaimer 已领取的用户，可查看领取名单及自己的领取金额
+	ViewerRoleClaimer = "claimer"
+	// ViewerRolePublic 其他用户，仅可查看汇总信息（总额、个数、剩余个数），不返回领取记录
+	ViewerRolePublic = "public"
+)
+
+const (
+	// ClaimStreamChannelFormat Redis 发布订阅频道格式，推送红包的实时领取动态（红包ID）
+	ClaimStreamChannelFormat = "redenvelope:stream:%d"
+	// ClaimStreamHeartbeat 实时领取动态的心跳间隔，避免连接被代理断开
+	ClaimStreamHeartbeat = 15 * time.Second
+	// ClaimStreamMaxDuration 单个实时领取动态连接的最长持续时间，超时后由客户端自动重连
+	ClaimStreamMaxDuration = 30 * time.Minute
+	// ClaimStreamBufferSize 单个连接待推送消息的缓冲数量
+	ClaimStreamBufferSize = 64
+	// ClaimStreamSendTimeout 客户端消费过慢时，消息在缓冲区满后等待的最长时间，超时丢弃
+	ClaimStreamSendTimeout = 5 * time.Second
+)
+
+const (
+	// StreamEventSnapshot 连接建立时推送的红包当前状态
+	StreamEventSnapshot = "snapshot"
+	// StreamEventClaim 新的领取记录
+	StreamEventClaim = "claim"
+	// StreamEventFinished 红包已领完，推送后关闭连接
+	StreamEventFinished = "finished"
+	// StreamEventExpired 红包已过期退款，推送后关闭连接
+	StreamEventExpired = "expired"
+	// StreamEventPing 心跳
+	StreamEventPing = "ping"
+)
+
+const (
+	// MaxRecurringPerUser 每个用户最多可创建的定期发放计划数量（含已停用）
+	MaxRecurringPerUser = 10
+	// MinRecurringInterval 定期发放计划相邻两次发放的最短间隔
+	MinRecurringInterval = time.Hour
+	// RecurringBatchSize 定期发放任务每批扫描的计划数量
+	RecurringBatchSize = 100
+)
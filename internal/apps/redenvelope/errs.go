@@ -16,6 +16,8 @@ limitations under the License.
 
 package redenvelope
 
+import "github.com/linux-do/credit/internal/common"
+
 const (
 	RedEnvelopeNotFound       = "红包不存在"
 	RedEnvelopeExpired        = "红包已过期"
@@ -25,7 +27,190 @@ const (
 	InvalidRedEnvelopeType    = "无效的红包类型"
 	InvalidRedEnvelopeCount   = "红包个数必须大于0"
 	InvalidRedEnvelopeAmount  = "红包金额必须大于0"
-	AmountTooSmall            = "每个红包金额不能小于0.01"
+	AmountTooSmall            = "每个红包金额不能小于最小金额单位"
 	RedEnvelopeTooPopular     = "太火爆啦，稍后再试试吧~"
 	InvalidRedEnvelopeID      = "红包ID格式错误"
+	ClaimTokenInvalid         = "领取凭证无效或已过期，请刷新后重试"
+	WebhookDisabled           = "回调领取未启用"
+	WebhookSignatureInvalid   = "回调签名验证失败"
+	WebhookTimestampExpired   = "回调请求已过期"
+	WebhookNonceReplayed      = "回调请求重复提交"
+	WebhookUserNotFound       = "领取用户不存在或已被封禁"
+	NotEnvelopeCreator        = "仅红包创建者可执行此操作"
+	InvalidMaxClaimsPerUser   = "每人可领取次数不能超过红包个数"
+	BalanceCapExceeded        = "领取后余额将超过上限"
+	ConfirmRequired           = "该红包需要先预约再确认领取"
+	ConfirmNotRequired        = "该红包无需预约，请直接领取"
+	ReservationFull           = "名额已被预约完，请稍后再试"
+	ReservationInvalid        = "预约已失效，请重新预约"
+	EscrowInsufficient        = "红包托管余额不足"
+	DetailRateLimited         = "请求过于频繁，请稍后再试"
+	InvalidBaseAmount         = "保底金额必须大于0，且保底金额乘以红包个数不能超过红包金额"
+	InvalidAmountFormat       = "金额格式错误，请输入有效的数字"
+	AmountOutOfRange          = "金额超出允许范围"
+	InvalidPerAmount          = "单个金额仅适用于普通红包，且不能与红包总额同时指定"
+	PayKeyLocked              = "支付密钥错误次数过多，请于 %s 后重试"
+	InvalidVisibility         = "无效的红包可见范围"
+	AllowListRequired         = "私密红包必须设置可领取名单"
+	AllowListNotAllowed       = "仅私密红包可设置可领取名单"
+	AllowListUserNotFound     = "可领取名单中存在不存在的用户"
+	NotInAllowList            = "您不在该红包的可领取名单中"
+	SystemLiabilityCapReached = "平台红包待领取总额已达上限，请稍后再试"
+	InvalidOrderID            = "订单ID格式错误"
+	OrderNotLinked            = "订单不存在或未关联红包"
+	InvalidMinClaimAmount     = "最低领取金额仅适用于拼手气红包，必须大于0且乘以红包个数不能超过红包金额"
+	ClaimNotFound             = "领取记录不存在"
+	ClaimReturnDisabled       = "暂不支持退回红包"
+	ClaimReturnWindowPassed   = "已超过可退回时间"
+	DeepLinkDisabled          = "一键领取未启用"
+	InvalidStatusTransition   = "红包状态流转无效"
+	DeepLinkTokenInvalid      = "领取链接无效或已过期"
+	DeepLinkTokenUsed         = "领取链接已被使用"
+	DatabaseUnavailable       = "数据库不可用"
+	CodeGenerationFailed      = "生成红包码失败，请重试"
+	RedEnvelopePaused         = "红包已暂停领取"
+	RedEnvelopeNotPaused      = "红包未暂停领取"
+	InvalidTargetWallet       = "钱包名称无效或未启用，且不能与托管资金同时使用"
+	WalletInsufficient        = "钱包余额不足"
+	CreatorClaimCapExceeded   = "已达到从该用户红包中累计领取金额上限"
+	InsufficientSlots         = "红包剩余个数不足"
+	BulkClaimUserNotFound     = "用户不存在或已被禁用"
+	InvalidReservations       = "预留名额的用户须存在且不重复（私密红包须在可领取名单内），预留金额之和不能超过红包金额，且开放名额每人至少一个最小金额单位"
+	OpenSlotsExhausted        = "红包剩余名额已为指定用户预留"
+	AudienceTooSmall          = "红包个数超过可领取名单最多可领取的次数"
+	StreamUnavailable         = "实时领取动态不可用，请改为轮询红包详情"
+	InvalidDenomination       = "红包金额、保底金额及最低领取金额须为领取面额的整数倍，且红包金额足够每人至少领取一个面额"
+	UnknownGreetingVariable   = "祝福语包含未定义的模板变量"
+	GreetingTooLong           = "祝福语超过长度上限"
+	ClaimMessageTooLong       = "领取成功提示语超过长度上限"
+	ClaimsClosedNow           = "当前时段暂停领取红包"
+	InvalidSplit              = "按信任等级加权拆分仅适用于拼手气红包"
+	InvalidUserID             = "用户ID格式错误"
+	EligibilityUserNotFound   = "用户不存在"
+	CreateInProgress          = "红包创建请求正在处理中，请稍后重试"
+	DuplicateCreate           = "请勿重复提交相同的红包"
+	InvalidRecurringCron      = "定期发放的 Cron 表达式无效或发放间隔过短"
+	InvalidRecurringEndsAt    = "定期发放的结束时间必须晚于当前时间"
+	RecurringNotFound         = "定期发放计划不存在"
+	RecurringLimitReached     = "定期发放计划数量已达上限"
+	RecurringOpaqueLink       = "定期发放的红包无法返回领取链接，不支持不透明领取链接"
+	InvalidClaimChannel       = "领取渠道只能包含字母、数字、下划线和连字符，且不超过32个字符"
+	ReceiptDisabled           = "未配置领取回执签名密钥，无法使用签名回执"
+	ReceiptInvalid            = "领取回执无效或签名不匹配"
+	CreatorBanned             = "红包创建者账户已被封禁，暂不可领取"
+	InvalidClaimCursor        = "领取记录分页游标无效"
+	TestModeNotAllowed        = "当前账户无权创建测试红包"
+	TestModeUnsupported       = "测试红包不能公开，也不能使用托管资金、专用钱包或领取后转发"
 )
+
+// allowListMissingError 可领取名单中不存在的用户名，错误信息与 AllowListUserNotFound 一致，按错误信息分支的调用方无需区分
+type allowListMissingError struct {
+	usernames []string
+}
+
+func (e *allowListMissingError) Error() string {
+	return AllowListUserNotFound
+}
+
+// errorCode 红包接口错误的稳定错误码，Message 为接口返回的默认提示，English 为英文提示
+type errorCode struct {
+	Code    string
+	Message string
+	English string
+}
+
+// errorRegistry 红包接口可能返回的全部错误，新增错误常量时须在此登记错误码
+// 接口仍以 Message 作为错误信息返回，客户端据此表查出错误码后做本地化
+var errorRegistry = []errorCode{
+	{Code: "RED_ENVELOPE_NOT_FOUND", Message: RedEnvelopeNotFound, English: "Red envelope not found"},
+	{Code: "RED_ENVELOPE_EXPIRED", Message: RedEnvelopeExpired, English: "Red envelope has expired"},
+	{Code: "RED_ENVELOPE_FINISHED", Message: RedEnvelopeFinished, English: "Red envelope has been fully claimed"},
+	{Code: "RED_ENVELOPE_ALREADY_CLAIMED", Message: RedEnvelopeAlreadyClaimed, English: "You have already claimed this red envelope"},
+	{Code: "CANNOT_CLAIM_OWN_RED_ENVELOPE", Message: CannotClaimOwnRedEnvelope, English: "You cannot claim your own red envelope"},
+	{Code: "INVALID_RED_ENVELOPE_TYPE", Message: InvalidRedEnvelopeType, English: "Invalid red envelope type"},
+	{Code: "INVALID_RED_ENVELOPE_COUNT", Message: InvalidRedEnvelopeCount, English: "Red envelope count must be greater than 0"},
+	{Code: "INVALID_RED_ENVELOPE_AMOUNT", Message: InvalidRedEnvelopeAmount, English: "Red envelope amount must be greater than 0"},
+	{Code: "AMOUNT_TOO_SMALL", Message: AmountTooSmall, English: "Each red envelope must be at least the minimum amount unit"},
+	{Code: "RED_ENVELOPE_TOO_POPULAR", Message: RedEnvelopeTooPopular, English: "Too busy right now, please try again later"},
+	{Code: "INVALID_RED_ENVELOPE_ID", Message: InvalidRedEnvelopeID, English: "Invalid red envelope ID"},
+	{Code: "CLAIM_TOKEN_INVALID", Message: ClaimTokenInvalid, English: "Claim token is invalid or expired, please refresh and retry"},
+	{Code: "WEBHOOK_DISABLED", Message: WebhookDisabled, English: "Webhook claims are not enabled"},
+	{Code: "WEBHOOK_SIGNATURE_INVALID", Message: WebhookSignatureInvalid, English: "Webhook signature verification failed"},
+	{Code: "WEBHOOK_TIMESTAMP_EXPIRED", Message: WebhookTimestampExpired, English: "Webhook request has expired"},
+	{Code: "WEBHOOK_NONCE_REPLAYED", Message: WebhookNonceReplayed, English: "Webhook request was already submitted"},
+	{Code: "WEBHOOK_USER_NOT_FOUND", Message: WebhookUserNotFound, English: "Claiming user does not exist or is banned"},
+	{Code: "NOT_ENVELOPE_CREATOR", Message: NotEnvelopeCreator, English: "Only the red envelope creator can do this"},
+	{Code: "INVALID_MAX_CLAIMS_PER_USER", Message: InvalidMaxClaimsPerUser, English: "Claims per user cannot exceed the red envelope count"},
+	{Code: "BALANCE_CAP_EXCEEDED", Message: BalanceCapExceeded, English: "Claiming would exceed your balance cap"},
+	{Code: "CONFIRM_REQUIRED", Message: ConfirmRequired, English: "This red envelope must be reserved before it can be claimed"},
+	{Code: "CONFIRM_NOT_REQUIRED", Message: ConfirmNotRequired, English: "This red envelope does not need a reservation, claim it directly"},
+	{Code: "RESERVATION_FULL", Message: ReservationFull, English: "All slots are reserved, please try again later"},
+	{Code: "RESERVATION_INVALID", Message: ReservationInvalid, English: "Reservation is no longer valid, please reserve again"},
+	{Code: "ESCROW_INSUFFICIENT", Message: EscrowInsufficient, English: "Insufficient red envelope escrow balance"},
+	{Code: "DETAIL_RATE_LIMITED", Message: DetailRateLimited, English: "Too many requests, please try again later"},
+	{Code: "INVALID_BASE_AMOUNT", Message: InvalidBaseAmount, English: "Base amount must be greater than 0 and base amount times count cannot exceed the total amount"},
+	{Code: "INVALID_AMOUNT_FORMAT", Message: InvalidAmountFormat, English: "Invalid amount, please enter a valid number"},
+	{Code: "AMOUNT_OUT_OF_RANGE", Message: AmountOutOfRange, English: "Amount is out of the allowed range"},
+	{Code: "INVALID_PER_AMOUNT", Message: InvalidPerAmount, English: "Per-envelope amount only applies to fixed red envelopes and cannot be combined with a total amount"},
+	{Code: "PAY_KEY_LOCKED", Message: PayKeyLocked, English: "Too many incorrect pay key attempts, please retry after %s"},
+	{Code: "INVALID_VISIBILITY", Message: InvalidVisibility, English: "Invalid red envelope visibility"},
+	{Code: "ALLOW_LIST_REQUIRED", Message: AllowListRequired, English: "Private red envelopes require an allow list"},
+	{Code: "ALLOW_LIST_NOT_ALLOWED", Message: AllowListNotAllowed, English: "Only private red envelopes can have an allow list"},
+	{Code: "ALLOW_LIST_USER_NOT_FOUND", Message: AllowListUserNotFound, English: "The allow list contains users that do not exist"},
+	{Code: "NOT_IN_ALLOW_LIST", Message: NotInAllowList, English: "You are not on this red envelope's allow list"},
+	{Code: "SYSTEM_LIABILITY_CAP_REACHED", Message: SystemLiabilityCapReached, English: "The platform's outstanding red envelope total has reached its limit, please try again later"},
+	{Code: "INVALID_ORDER_ID", Message: InvalidOrderID, English: "Invalid order ID"},
+	{Code: "ORDER_NOT_LINKED", Message: OrderNotLinked, English: "Order does not exist or is not linked to a red envelope"},
+	{Code: "INVALID_MIN_CLAIM_AMOUNT", Message: InvalidMinClaimAmount, English: "Minimum claim amount only applies to random red envelopes, must be greater than 0, and times count cannot exceed the total amount"},
+	{Code: "CLAIM_NOT_FOUND", Message: ClaimNotFound, English: "Claim not found"},
+	{Code: "CLAIM_RETURN_DISABLED", Message: ClaimReturnDisabled, English: "Returning red envelopes is not supported"},
+	{Code: "CLAIM_RETURN_WINDOW_PASSED", Message: ClaimReturnWindowPassed, English: "The return window has passed"},
+	{Code: "DEEP_LINK_DISABLED", Message: DeepLinkDisabled, English: "One-tap claim is not enabled"},
+	{Code: "INVALID_STATUS_TRANSITION", Message: InvalidStatusTransition, English: "Invalid red envelope status transition"},
+	{Code: "DEEP_LINK_TOKEN_INVALID", Message: DeepLinkTokenInvalid, English: "Claim link is invalid or expired"},
+	{Code: "DEEP_LINK_TOKEN_USED", Message: DeepLinkTokenUsed, English: "Claim link has already been used"},
+	{Code: "DATABASE_UNAVAILABLE", Message: DatabaseUnavailable, English: "Database is unavailable"},
+	{Code: "CODE_GENERATION_FAILED", Message: CodeGenerationFailed, English: "Failed to generate a red envelope code, please retry"},
+	{Code: "RED_ENVELOPE_PAUSED", Message: RedEnvelopePaused, English: "Claims for this red envelope are paused"},
+	{Code: "RED_ENVELOPE_NOT_PAUSED", Message: RedEnvelopeNotPaused, English: "Claims for this red envelope are not paused"},
+	{Code: "INVALID_TARGET_WALLET", Message: InvalidTargetWallet, English: "Wallet name is invalid or not enabled, and cannot be combined with escrow funding"},
+	{Code: "WALLET_INSUFFICIENT", Message: WalletInsufficient, English: "Insufficient wallet balance"},
+	{Code: "CREATOR_CLAIM_CAP_EXCEEDED", Message: CreatorClaimCapExceeded, English: "You have reached the total claim limit for this user's red envelopes"},
+	{Code: "INSUFFICIENT_SLOTS", Message: InsufficientSlots, English: "Not enough red envelopes remaining"},
+	{Code: "BULK_CLAIM_USER_NOT_FOUND", Message: BulkClaimUserNotFound, English: "User does not exist or is disabled"},
+	{Code: "INVALID_RESERVATIONS", Message: InvalidReservations, English: "Reserved users must exist and be unique (and on the allow list for private envelopes), reserved amounts cannot exceed the total, and each open slot needs at least the minimum amount unit"},
+	{Code: "OPEN_SLOTS_EXHAUSTED", Message: OpenSlotsExhausted, English: "The remaining slots are reserved for specific users"},
+	{Code: "AUDIENCE_TOO_SMALL", Message: AudienceTooSmall, English: "Red envelope count exceeds the number of claims the allow list can make"},
+	{Code: "STREAM_UNAVAILABLE", Message: StreamUnavailable, English: "Live claim updates are unavailable, please poll the red envelope details instead"},
+	{Code: "INVALID_DENOMINATION", Message: InvalidDenomination, English: "Total, base and minimum claim amounts must be multiples of the claim denomination, and the total must cover at least one denomination per claimer"},
+	{Code: "UNKNOWN_GREETING_VARIABLE", Message: UnknownGreetingVariable, English: "Greeting contains an undefined template variable"},
+	{Code: "GREETING_TOO_LONG", Message: GreetingTooLong, English: "Greeting exceeds the length limit"},
+	{Code: "CLAIM_MESSAGE_TOO_LONG", Message: ClaimMessageTooLong, English: "Claim success message exceeds the length limit"},
+	{Code: "CLAIMS_CLOSED_NOW", Message: ClaimsClosedNow, English: "Red envelope claims are closed at this time"},
+	{Code: "INVALID_SPLIT", Message: InvalidSplit, English: "Trust-weighted split only applies to random red envelopes"},
+	{Code: "INVALID_USER_ID", Message: InvalidUserID, English: "Invalid user ID"},
+	{Code: "ELIGIBILITY_USER_NOT_FOUND", Message: EligibilityUserNotFound, English: "User does not exist"},
+	{Code: "CREATE_IN_PROGRESS", Message: CreateInProgress, English: "A red envelope creation request is in progress, please retry shortly"},
+	{Code: "DUPLICATE_CREATE", Message: DuplicateCreate, English: "Please do not submit the same red envelope twice"},
+	{Code: "INVALID_RECURRING_CRON", Message: InvalidRecurringCron, English: "Invalid recurring cron expression or the interval is too short"},
+	{Code: "INVALID_RECURRING_ENDS_AT", Message: InvalidRecurringEndsAt, English: "Recurring end time must be in the future"},
+	{Code: "RECURRING_NOT_FOUND", Message: RecurringNotFound, English: "Recurring red envelope plan not found"},
+	{Code: "RECURRING_LIMIT_REACHED", Message: RecurringLimitReached, English: "You have reached the maximum number of recurring red envelope plans"},
+	{Code: "RECURRING_OPAQUE_LINK", Message: RecurringOpaqueLink, English: "Recurring red envelopes cannot return a claim link, so opaque links are not supported"},
+	{Code: "INVALID_CLAIM_CHANNEL", Message: InvalidClaimChannel, English: "Claim channel may only contain letters, digits, underscores and hyphens, up to 32 characters"},
+	{Code: "RECEIPT_DISABLED", Message: ReceiptDisabled, English: "Signed receipts are unavailable because no receipt signing key is configured"},
+	{Code: "RECEIPT_INVALID", Message: ReceiptInvalid, English: "Claim receipt is invalid or its signature does not match"},
+	{Code: "CREATOR_BANNED", Message: CreatorBanned, English: "The red envelope creator's account is banned, so it cannot be claimed"},
+	{Code: "INVALID_CLAIM_CURSOR", Message: InvalidClaimCursor, English: "Invalid claim pagination cursor"},
+	{Code: "TEST_MODE_NOT_ALLOWED", Message: TestModeNotAllowed, English: "This account is not allowed to create test red envelopes"},
+	{Code: "TEST_MODE_UNSUPPORTED", Message: TestModeUnsupported, English: "Test red envelopes cannot be public or use escrow, wallets or claim forwarding"},
+	{Code: "RED_ENVELOPE_DISABLED", Message: common.RedEnvelopeDisabled, English: "Red envelopes are not enabled"},
+	{Code: "RED_ENVELOPE_AMOUNT_EXCEEDED", Message: common.RedEnvelopeAmountExceeded, English: "Red envelope amount exceeds the per-envelope limit"},
+	{Code: "RED_ENVELOPE_DAILY_LIMIT_EXCEEDED", Message: common.RedEnvelopeDailyLimitExceeded, English: "You have reached today's red envelope limit"},
+	{Code: "RED_ENVELOPE_RECIPIENTS_EXCEEDED", Message: common.RedEnvelopeRecipientsExceeded, English: "Red envelope count exceeds the maximum number of recipients"},
+	{Code: "RED_ENVELOPE_MIN_AMOUNT_REQUIRED", Message: common.RedEnvelopeMinAmountRequired, English: "Red envelope total cannot be less than 1 LDC"},
+	{Code: "INSUFFICIENT_BALANCE", Message: common.InsufficientBalance, English: "Insufficient balance"},
+	{Code: "PAY_KEY_INCORRECT", Message: common.PayKeyIncorrect, English: "Incorrect pay key"},
+	{Code: "AMOUNT_MUST_BE_GREATER_THAN_ZERO", Message: common.AmountMustBeGreaterThanZero, English: "Amount must be greater than 0"},
+	{Code: "AMOUNT_DECIMAL_PLACES_EXCEEDED", Message: common.AmountDecimalPlacesExceeded, English: "Amount has more decimal places than the system allows"},
+}
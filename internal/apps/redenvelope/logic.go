@@ -0,0 +1,2427 @@
+/*
+Copyright 2025 linux.do
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package redenvelope
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/linux-do/credit/internal/common"
+	"github.com/linux-do/credit/internal/config"
+	"github.com/linux-do/credit/internal/db"
+	"github.com/linux-do/credit/internal/db/idgen"
+	"github.com/linux-do/credit/internal/logger"
+	"github.com/linux-do/credit/internal/model"
+	"github.com/linux-do/credit/internal/service"
+	"github.com/linux-do/credit/internal/util"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CreateParams 创建红包参数
+type CreateParams struct {
+	CreatorID        uint64
+	Type             model.RedEnvelopeType
+	Split            model.RedEnvelopeSplit
+	TotalAmount      decimal.Decimal
+	BaseAmount       decimal.Decimal
+	MinClaimAmount   decimal.Decimal
+	TotalCount       int
+	Greeting         string
+	GreetingHidden   bool
+	ClaimMessage     string
+	MaxClaimsPerUser int
+	RequireConfirm   bool
+	FromEscrow       bool
+	TargetWallet     string
+	Visibility       model.RedEnvelopeVisibility
+	AllowedUsernames []string
+	// OpaqueLink 为 true 时不生成红包码，改为生成高熵的不透明领取链接凭证，仅可凭链接或红包ID领取
+	OpaqueLink bool
+	// SignedReceipt 为 true 时领取成功后返回签名的领取回执，供外部系统校验
+	SignedReceipt bool
+	// TestMode 为 true 时创建测试红包，创建、领取及退款均不实际变动余额，订单记为测试订单
+	TestMode bool
+	// ReservedAllocations 为指定用户（用户名）预留的领取金额，每人占用一个名额
+	ReservedAllocations map[string]decimal.Decimal
+	// hold 复合操作（如领取后转发）中已持有的创建者余额占用，为空时直接扣减可用余额
+	hold *balanceHold
+}
+
+// CreateRedEnvelope 校验参数并扣减创建者余额创建红包，供 HTTP 接口及内部调用共用
+// 支付密码等调用方身份校验由调用方负责
+func CreateRedEnvelope(ctx context.Context, params CreateParams) (*model.RedEnvelope, error) {
+	// 参数本身不合法时无需开启事务
+	if err := validateCreateParams(&params); err != nil {
+		return nil, err
+	}
+
+	var redEnvelope *model.RedEnvelope
+	if err := db.DB(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		redEnvelope, err = createRedEnvelope(ctx, tx, params)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	onRedEnvelopeCreated(ctx, redEnvelope)
+	return redEnvelope, nil
+}
+
+// validateCreateParams 校验不依赖配置及数据库的创建参数：红包类型、个数、分配方式及金额存储范围，未指定分配方式时设为均匀分配
+func validateCreateParams(params *CreateParams) error {
+	if params.Type != model.RedEnvelopeTypeFixed && params.Type != model.RedEnvelopeTypeRandom && params.Type != model.RedEnvelopeTypeHybrid {
+		return errors.New(InvalidRedEnvelopeType)
+	}
+	if params.TotalCount <= 0 {
+		return errors.New(InvalidRedEnvelopeCount)
+	}
+	switch params.Split {
+	case "", model.RedEnvelopeSplitUniform:
+		params.Split = model.RedEnvelopeSplitUniform
+	case model.RedEnvelopeSplitTrustWeighted:
+		if params.Type != model.RedEnvelopeTypeRandom {
+			return errors.New(InvalidSplit)
+		}
+	default:
+		return errors.New(InvalidSplit)
+	}
+
+	// 超出存储范围的金额（如科学计数法表示的极大值）直接拒绝，包括保底、最低领取及预留金额
+	amounts := []decimal.Decimal{params.TotalAmount, params.BaseAmount, params.MinClaimAmount}
+	for _, amount := range params.ReservedAllocations {
+		amounts = append(amounts, amount)
+	}
+	return validateAmountRange(amounts...)
+}
+
+// createRedEnvelope 在给定事务中校验参数、扣款并创建红包，事务提交后需调用 onRedEnvelopeCreated
+func createRedEnvelope(ctx context.Context, tx *gorm.DB, params CreateParams) (*model.RedEnvelope, error) {
+	if err := validateCreateParams(&params); err != nil {
+		return nil, err
+	}
+
+	// 祝福语中的模板变量替换为配置值，保存替换后的结果
+	greeting, err := resolveGreeting(ctx, params.Greeting)
+	if err != nil {
+		return nil, err
+	}
+	params.Greeting = greeting
+
+	claimMessage, err := sanitizeClaimMessage(params.ClaimMessage)
+	if err != nil {
+		return nil, err
+	}
+	params.ClaimMessage = claimMessage
+
+	// 签名回执须配置签名密钥
+	if params.SignedReceipt && config.Config.RedEnvelope.ReceiptSecret == "" {
+		return nil, errors.New(ReceiptDisabled)
+	}
+
+	// 测试红包仅限授权账户创建，且不能涉及托管资金、专用钱包及公开展示
+	if params.TestMode {
+		if params.Visibility == model.RedEnvelopeVisibilityPublic || params.FromEscrow || params.TargetWallet != "" || params.hold != nil {
+			return nil, errors.New(TestModeUnsupported)
+		}
+		if err := checkTestModeAllowed(ctx, tx, params.CreatorID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := util.ValidateAmount(params.TotalAmount); err != nil {
+		return nil, err
+	}
+
+	// 检查红包最低金额限制（1 LDC）
+	if params.TotalAmount.LessThan(decimal.NewFromInt(1)) {
+		return nil, errors.New(common.RedEnvelopeMinAmountRequired)
+	}
+
+	// 检查单个红包最大金额限制
+	maxAmount, err := model.GetDecimalByKey(ctx, model.ConfigKeyRedEnvelopeMaxAmount, util.AmountPrecision())
+	if err != nil {
+		return nil, err
+	}
+	if params.TotalAmount.GreaterThan(maxAmount) {
+		return nil, errors.New(common.RedEnvelopeAmountExceeded)
+	}
+
+	// 检查红包最大领取人数限制
+	maxRecipients, err := model.GetIntByKey(ctx, model.ConfigKeyRedEnvelopeMaxRecipients)
+	if err != nil {
+		return nil, err
+	}
+	if params.TotalCount > maxRecipients {
+		return nil, errors.New(common.RedEnvelopeRecipientsExceeded)
+	}
+
+	// 每人可领取次数默认为1，且不能超过红包个数
+	if params.MaxClaimsPerUser == 0 {
+		params.MaxClaimsPerUser = 1
+	}
+	if params.MaxClaimsPerUser < 0 || params.MaxClaimsPerUser > params.TotalCount {
+		return nil, errors.New(InvalidMaxClaimsPerUser)
+	}
+
+	// 可见范围默认为不公开，私密红包必须设置可领取名单
+	allowedUserIDs, err := resolveAllowList(ctx, &params)
+	if err != nil {
+		return nil, err
+	}
+
+	// 私密红包个数超过名单可领取总次数时多出的个数只能过期退款，按配置提示、拒绝或自动调整个数
+	var warnings []string
+	if len(allowedUserIDs) > 0 {
+		if warnings, err = checkAudienceSize(ctx, &params, len(allowedUserIDs)); err != nil {
+			return nil, err
+		}
+	}
+
+	// 检查每个红包平均金额不能小于最小金额单位（避免前面领取者获得0 LDC）
+	perAmount := params.TotalAmount.Div(decimal.NewFromInt(int64(params.TotalCount)))
+	if perAmount.LessThan(util.MinAmountUnit()) {
+		return nil, errors.New(AmountTooSmall)
+	}
+
+	// 保底加随机红包：每人保底金额，剩余部分随机分配
+	if params.Type == model.RedEnvelopeTypeHybrid {
+		if err := util.ValidateAmount(params.BaseAmount); err != nil ||
+			params.BaseAmount.Mul(decimal.NewFromInt(int64(params.TotalCount))).GreaterThan(params.TotalAmount) {
+			return nil, errors.New(InvalidBaseAmount)
+		}
+	} else {
+		params.BaseAmount = decimal.Zero
+	}
+
+	// 拼手气红包可设置每人最低领取金额，抬高随机金额下限
+	if !params.MinClaimAmount.IsZero() {
+		if params.Type != model.RedEnvelopeTypeRandom || util.ValidateAmount(params.MinClaimAmount) != nil ||
+			params.MinClaimAmount.Mul(decimal.NewFromInt(int64(params.TotalCount))).GreaterThan(params.TotalAmount) {
+			return nil, errors.New(InvalidMinClaimAmount)
+		}
+	}
+
+	// 领取面额取整时，各项金额须为面额的整数倍，且每人至少可领取一个面额
+	denomination, err := model.GetDecimalByKey(ctx, model.ConfigKeyRedEnvelopeClaimDenomination, util.AmountPrecision())
+	if err != nil {
+		return nil, err
+	}
+	if err := validateAmountRange(denomination); err != nil {
+		return nil, err
+	}
+	if denomination.GreaterThan(util.MinAmountUnit()) {
+		if !isMultipleOf(params.TotalAmount, denomination) || !isMultipleOf(params.BaseAmount, denomination) ||
+			!isMultipleOf(params.MinClaimAmount, denomination) ||
+			params.TotalAmount.LessThan(denomination.Mul(decimal.NewFromInt(int64(params.TotalCount)))) {
+			return nil, errors.New(InvalidDenomination)
+		}
+	} else {
+		denomination = decimal.Zero
+	}
+
+	// 指定专用钱包时从创建者同名钱包扣款，不能与托管资金同时使用
+	if params.TargetWallet != "" {
+		if params.FromEscrow {
+			return nil, errors.New(InvalidTargetWallet)
+		}
+		if err := validateWalletName(ctx, params.TargetWallet); err != nil {
+			return nil, err
+		}
+	}
+
+	// 为指定用户预留名额及金额，其余名额及金额按红包类型分配
+	reservations, reservedAmount, err := resolveReservations(ctx, &params, allowedUserIDs, denomination)
+	if err != nil {
+		return nil, err
+	}
+
+	// 检查每日红包发送数量限制
+	dailyLimit, err := model.GetIntByKey(ctx, model.ConfigKeyRedEnvelopeDailyLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	// 查询今日已发送的红包数量
+	var todayCount int64
+	today := time.Now().Truncate(24 * time.Hour)
+	if err := tx.Model(&model.RedEnvelope{}).
+		Where("creator_id = ? AND created_at >= ?", params.CreatorID, today).
+		Count(&todayCount).Error; err != nil {
+		return nil, err
+	}
+
+	if todayCount >= int64(dailyLimit) {
+		return nil, errors.New(common.RedEnvelopeDailyLimitExceeded)
+	}
+
+	// 全平台待领取总额达到上限时暂停创建，总额为缓存的近似值，测试红包不计入
+	liabilityCap, err := model.GetDecimalByKey(ctx, model.ConfigKeyRedEnvelopeLiabilityCap, util.AmountPrecision())
+	if err != nil {
+		return nil, err
+	}
+	if liabilityCap.IsPositive() && !params.TestMode {
+		liability, err := getOutstandingLiability(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if liability.Add(params.TotalAmount).GreaterThan(liabilityCap) {
+			return nil, errors.New(SystemLiabilityCapReached)
+		}
+	}
+
+	feeAmount, jackpotAmount, totalDeduction, err := calculateCreateFee(ctx, params.TotalAmount)
+	if err != nil {
+		return nil, err
+	}
+
+	if params.TestMode {
+		// 测试红包不扣款，不收取手续费及奖池抽成
+		feeAmount, jackpotAmount, totalDeduction = decimal.Zero, decimal.Zero, params.TotalAmount
+	} else if params.FromEscrow || params.TargetWallet != "" {
+		// 从托管余额或专用钱包扣款，支出同样计入total_payment
+		if params.FromEscrow {
+			err = deductEscrowBalance(tx, params.CreatorID, totalDeduction)
+		} else {
+			err = deductWalletBalance(tx, params.CreatorID, params.TargetWallet, totalDeduction)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if err := tx.Model(&model.User{}).Where("id = ?", params.CreatorID).
+			UpdateColumn("total_payment", gorm.Expr("total_payment + ?", totalDeduction)).Error; err != nil {
+			return nil, err
+		}
+	} else {
+		// 红包金额连同手续费及奖池抽成经余额占用扣款并更新total_payment，复合操作中沿用已持有的占用
+		hold := params.hold
+		if hold == nil {
+			if hold, err = holdBalance(tx, params.CreatorID); err != nil {
+				return nil, err
+			}
+		}
+		if err := hold.debit(tx, totalDeduction, "total_payment"); err != nil {
+			return nil, err
+		}
+	}
+
+	// 红包码字符空间有限可被枚举，使用不透明链接的红包仅保存凭证哈希，凭证只在创建时返回一次
+	var code, linkTokenHash *string
+	var linkToken string
+	if params.OpaqueLink {
+		if linkToken, err = generateLinkToken(); err != nil {
+			return nil, err
+		}
+		hash := hashLinkToken(linkToken)
+		linkTokenHash = &hash
+	} else {
+		envelopeCode, err := newUniqueEnvelopeCode(tx)
+		if err != nil {
+			return nil, err
+		}
+		code = &envelopeCode
+	}
+
+	// 创建红包
+	redEnvelope := model.RedEnvelope{
+		ID:               idgen.NextUint64ID(),
+		Code:             code,
+		LinkTokenHash:    linkTokenHash,
+		CreatorID:        params.CreatorID,
+		Type:             params.Type,
+		Split:            params.Split,
+		TotalAmount:      params.TotalAmount,
+		RemainingAmount:  params.TotalAmount,
+		BaseAmount:       params.BaseAmount,
+		MinClaimAmount:   params.MinClaimAmount,
+		Denomination:     denomination,
+		ReservedCount:    len(reservations),
+		ReservedAmount:   reservedAmount,
+		TotalCount:       params.TotalCount,
+		RemainingCount:   params.TotalCount,
+		Greeting:         params.Greeting,
+		GreetingHidden:   params.GreetingHidden,
+		SignedReceipt:    params.SignedReceipt,
+		TestMode:         params.TestMode,
+		ClaimMessage:     params.ClaimMessage,
+		MaxClaimsPerUser: params.MaxClaimsPerUser,
+		RequireConfirm:   params.RequireConfirm,
+		FundedByEscrow:   params.FromEscrow,
+		TargetWallet:     params.TargetWallet,
+		Visibility:       params.Visibility,
+		Status:           model.RedEnvelopeStatusActive,
+		ExpiresAt:        time.Now().Add(RedEnvelopeLifetime),
+	}
+
+	if err := tx.Create(&redEnvelope).Error; err != nil {
+		return nil, err
+	}
+	redEnvelope.Warnings = warnings
+	redEnvelope.LinkToken = linkToken
+	if err := recordStatusEvent(tx, redEnvelope.ID, "", redEnvelope.Status,
+		statusEvent{ActorType: model.RedEnvelopeEventActorCreator, ActorID: params.CreatorID, Reason: "创建红包"}); err != nil {
+		return nil, err
+	}
+
+	if len(reservations) > 0 {
+		for i := range reservations {
+			reservations[i].RedEnvelopeID = redEnvelope.ID
+		}
+		if err := tx.Create(&reservations).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	if len(allowedUserIDs) > 0 {
+		allowedUsers := make([]model.RedEnvelopeAllowedUser, 0, len(allowedUserIDs))
+		for _, userID := range allowedUserIDs {
+			allowedUsers = append(allowedUsers, model.RedEnvelopeAllowedUser{RedEnvelopeID: redEnvelope.ID, UserID: userID})
+		}
+		if err := tx.Create(&allowedUsers).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	// 创建订单记录（红包支出），奖池抽成单独记录
+	order := model.Order{
+		OrderName:     "红包支出",
+		PayerUserID:   params.CreatorID,
+		PayeeUserID:   0,
+		Amount:        totalDeduction.Sub(jackpotAmount),
+		Status:        model.OrderStatusSuccess,
+		Type:          model.OrderTypeRedEnvelopeSend,
+		Remark:        sendOrderRemark(&redEnvelope, feeAmount),
+		RedEnvelopeID: &redEnvelope.ID,
+		TradeTime:     time.Now(),
+		ExpiresAt:     time.Now().Add(24 * time.Hour),
+	}
+
+	if err := tx.Create(markTestOrder(&redEnvelope, &order)).Error; err != nil {
+		return nil, err
+	}
+
+	if jackpotAmount.IsPositive() {
+		if err := addJackpotBalance(tx, jackpotAmount); err != nil {
+			return nil, err
+		}
+		if err := tx.Create(&model.Order{
+			OrderName:     "红包奖池",
+			PayerUserID:   params.CreatorID,
+			PayeeUserID:   0,
+			Amount:        jackpotAmount,
+			Status:        model.OrderStatusSuccess,
+			Type:          model.OrderTypeRedEnvelopeJackpot,
+			Remark:        fmt.Sprintf("创建红包计入奖池，红包ID:%d，金额: %s", redEnvelope.ID, util.FormatAmount(jackpotAmount)),
+			RedEnvelopeID: &redEnvelope.ID,
+			TradeTime:     time.Now(),
+			ExpiresAt:     time.Now().Add(24 * time.Hour),
+		}).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	return &redEnvelope, nil
+}
+
+// calculateCreateFee 按系统配置的手续费率及奖池抽成比例计算创建红包的手续费、奖池抽成及总扣款金额
+func calculateCreateFee(ctx context.Context, totalAmount decimal.Decimal) (decimal.Decimal, decimal.Decimal, decimal.Decimal, error) {
+	feeRate, err := model.GetDecimalByKey(ctx, model.ConfigKeyRedEnvelopeFeeRate, 2)
+	if err != nil {
+		return decimal.Zero, decimal.Zero, decimal.Zero, err
+	}
+	jackpotRate, err := model.GetDecimalByKey(ctx, model.ConfigKeyRedEnvelopeJackpotRate, 2)
+	if err != nil {
+		return decimal.Zero, decimal.Zero, decimal.Zero, err
+	}
+
+	// 计算手续费及奖池抽成（红包金额 * 比例）
+	feeAmount := totalAmount.Mul(feeRate).Round(util.AmountPrecision())
+	jackpotAmount := decimal.Zero
+	if jackpotRate.IsPositive() {
+		jackpotAmount = totalAmount.Mul(jackpotRate).Round(util.AmountPrecision())
+	}
+
+	// 总扣款金额 = 红包金额 + 手续费 + 奖池抽成
+	return feeAmount, jackpotAmount, totalAmount.Add(feeAmount).Add(jackpotAmount), nil
+}
+
+// createCost 单个红包的预估费用，Err 为按创建流程校验失败的原因，Warnings 为创建时的提示
+type createCost struct {
+	FeeAmount      decimal.Decimal
+	JackpotAmount  decimal.Decimal
+	TotalDeduction decimal.Decimal
+	Err            error
+	Warnings       []string
+}
+
+// errPreviewRollback 费用预估结束后用于回滚事务
+var errPreviewRollback = errors.New("preview rollback")
+
+// previewCreateCosts 在回滚的事务中依次执行创建流程，得到每个红包的费用及校验结果
+// 前面的红包同样计入余额扣减和每日数量限制，预估结果与依次创建一致
+func previewCreateCosts(ctx context.Context, paramsList []CreateParams) ([]createCost, error) {
+	costs := make([]createCost, len(paramsList))
+	err := db.DB(ctx).Transaction(func(tx *gorm.DB) error {
+		for i, params := range paramsList {
+			feeAmount, jackpotAmount, totalDeduction, err := calculateCreateFee(ctx, params.TotalAmount)
+			if err != nil {
+				return err
+			}
+			costs[i] = createCost{FeeAmount: feeAmount, JackpotAmount: jackpotAmount, TotalDeduction: totalDeduction}
+
+			savepoint := fmt.Sprintf("preview_%d", i)
+			if err := tx.SavePoint(savepoint).Error; err != nil {
+				return err
+			}
+			redEnvelope, err := createRedEnvelope(ctx, tx, params)
+			if err != nil {
+				costs[i].Err = err
+				if err := tx.RollbackTo(savepoint).Error; err != nil {
+					return err
+				}
+				continue
+			}
+			costs[i].Warnings = redEnvelope.Warnings
+		}
+		return errPreviewRollback
+	})
+	if !errors.Is(err, errPreviewRollback) {
+		return nil, err
+	}
+	return costs, nil
+}
+
+// onRedEnvelopeCreated 红包创建事务提交后更新待领取总额缓存并初始化领取闸门
+func onRedEnvelopeCreated(ctx context.Context, redEnvelope *model.RedEnvelope) {
+	if !redEnvelope.TestMode {
+		addOutstandingLiability(ctx, redEnvelope.TotalAmount)
+	}
+
+	// 启用闸门时初始化剩余个数，失败不影响红包创建，领取时回落到数据库校验
+	if gateEnabled, err := model.GetBoolByKey(ctx, model.ConfigKeyRedEnvelopeClaimGateEnabled); err == nil && gateEnabled {
+		if err := initClaimGate(ctx, redEnvelope.ID, redEnvelope.TotalCount, redEnvelope.ExpiresAt); err != nil {
+			logger.WarnF(ctx, "红包ID:%d 初始化领取闸门失败: %v", redEnvelope.ID, err)
+		}
+	}
+}
+
+// allowedStatusTransitions 红包状态允许的流转，已领完及已过期为终态，暂停的红包过期后同样退款
+// 已领完的红包仅可在领取退回时经 reopenFinishedEnvelope 重新变为进行中，不在此处开放
+var allowedStatusTransitions = map[model.RedEnvelopeStatus][]model.RedEnvelopeStatus{
+	model.RedEnvelopeStatusActive: {model.RedEnvelopeStatusFinished, model.RedEnvelopeStatusExpired, model.RedEnvelopeStatusPaused},
+	model.RedEnvelopeStatusPaused: {model.RedEnvelopeStatusActive, model.RedEnvelopeStatusExpired},
+}
+
+// statusEvent 状态流转的操作者及原因，写入红包状态流转记录
+type statusEvent struct {
+	ActorType model.RedEnvelopeEventActor
+	ActorID   uint64
+	Reason    string
+}
+
+// recordStatusEvent 在给定事务中写入红包状态流转记录
+func recordStatusEvent(tx *gorm.DB, redEnvelopeID uint64, from, to model.RedEnvelopeStatus, event statusEvent) error {
+	return tx.Create(&model.RedEnvelopeEvent{
+		ID:            idgen.NextUint64ID(),
+		RedEnvelopeID: redEnvelopeID,
+		FromStatus:    from,
+		ToStatus:      to,
+		ActorType:     event.ActorType,
+		ActorID:       event.ActorID,
+		Reason:        event.Reason,
+	}).Error
+}
+
+// transitionStatus 校验并执行红包状态流转，同时更新 updates 中的其他字段
+// 状态不变时仅更新其他字段；状态变更以当前状态为条件更新，状态已被并发修改时返回 InvalidStatusTransition
+// 状态变更时在同一事务中记录 event
+func transitionStatus(tx *gorm.DB, redEnvelope *model.RedEnvelope, to model.RedEnvelopeStatus, updates map[string]interface{}, event statusEvent) error {
+	from := redEnvelope.Status
+	if from == to {
+		if len(updates) == 0 {
+			return nil
+		}
+		return tx.Model(&model.RedEnvelope{}).Where("id = ?", redEnvelope.ID).Updates(updates).Error
+	}
+
+	if !slices.Contains(allowedStatusTransitions[from], to) {
+		return fmt.Errorf("%s: %s -> %s", InvalidStatusTransition, from, to)
+	}
+	return applyStatusTransition(tx, redEnvelope, to, updates, event)
+}
+
+// reopenFinishedEnvelope 领取退回时将已领完的红包重新变为进行中，这是已领完状态唯一的出口
+// 红包当前不是已领完状态时返回 InvalidStatusTransition
+func reopenFinishedEnvelope(tx *gorm.DB, redEnvelope *model.RedEnvelope, updates map[string]interface{}, event statusEvent) error {
+	if redEnvelope.Status != model.RedEnvelopeStatusFinished {
+		return fmt.Errorf("%s: %s -> %s", InvalidStatusTransition, redEnvelope.Status, model.RedEnvelopeStatusActive)
+	}
+	return applyStatusTransition(tx, redEnvelope, model.RedEnvelopeStatusActive, updates, event)
+}
+
+// applyStatusTransition 以当前状态为条件执行已校验的状态变更并记录 event
+func applyStatusTransition(tx *gorm.DB, redEnvelope *model.RedEnvelope, to model.RedEnvelopeStatus, updates map[string]interface{}, event statusEvent) error {
+	from := redEnvelope.Status
+	if updates == nil {
+		updates = make(map[string]interface{}, 1)
+	}
+	updates["status"] = to
+	result := tx.Model(&model.RedEnvelope{}).
+		Where("id = ? AND status = ?", redEnvelope.ID, from).
+		Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("%s: %s -> %s", InvalidStatusTransition, from, to)
+	}
+	if err := recordStatusEvent(tx, redEnvelope.ID, from, to, event); err != nil {
+		return err
+	}
+
+	redEnvelope.Status = to
+	return nil
+}
+
+// resolveReservations 校验预留分配并将用户名解析为用户ID，返回预留记录（未设置红包ID）及预留总额
+// 预留金额须为有效金额及领取面额的整数倍，剩余的开放名额每人至少一个最小金额单位且满足保底及最低领取金额
+func resolveReservations(ctx context.Context, params *CreateParams, allowedUserIDs []uint64, denomination decimal.Decimal) ([]model.RedEnvelopeReservation, decimal.Decimal, error) {
+	if len(params.ReservedAllocations) == 0 {
+		return nil, decimal.Zero, nil
+	}
+	invalid := errors.New(InvalidReservations)
+	if len(params.ReservedAllocations) > params.TotalCount {
+		return nil, decimal.Zero, invalid
+	}
+
+	usernames := make([]string, 0, len(params.ReservedAllocations))
+	reservedAmount := decimal.Zero
+	for username, amount := range params.ReservedAllocations {
+		if util.ValidateAmount(amount) != nil || (denomination.IsPositive() && !isMultipleOf(amount, denomination)) {
+			return nil, decimal.Zero, invalid
+		}
+		usernames = append(usernames, username)
+		reservedAmount = reservedAmount.Add(amount)
+	}
+
+	openCount := params.TotalCount - len(usernames)
+	openAmount := params.TotalAmount.Sub(reservedAmount)
+	if openCount == 0 {
+		if !openAmount.IsZero() {
+			return nil, decimal.Zero, invalid
+		}
+	} else {
+		openCountDec := decimal.NewFromInt(int64(openCount))
+		if openAmount.LessThan(util.MinAmountUnit().Mul(openCountDec)) ||
+			params.BaseAmount.Mul(openCountDec).GreaterThan(openAmount) ||
+			params.MinClaimAmount.Mul(openCountDec).GreaterThan(openAmount) {
+			return nil, decimal.Zero, invalid
+		}
+	}
+
+	var users []model.User
+	if err := db.DB(ctx).Select("id, username").Where("username IN ?", usernames).Find(&users).Error; err != nil {
+		return nil, decimal.Zero, err
+	}
+	if len(users) != len(usernames) {
+		return nil, decimal.Zero, invalid
+	}
+
+	reservations := make([]model.RedEnvelopeReservation, 0, len(users))
+	for _, user := range users {
+		if user.ID == params.CreatorID {
+			return nil, decimal.Zero, invalid
+		}
+		if params.Visibility == model.RedEnvelopeVisibilityPrivate && !slices.Contains(allowedUserIDs, user.ID) {
+			return nil, decimal.Zero, invalid
+		}
+		reservations = append(reservations, model.RedEnvelopeReservation{
+			UserID: user.ID,
+			Amount: params.ReservedAllocations[user.Username],
+		})
+	}
+	return reservations, reservedAmount, nil
+}
+
+// loadReservation 查询红包尚未领取的预留名额，返回该用户的预留记录（没有时为空）及扣除预留后的开放剩余个数与金额
+func loadReservation(tx *gorm.DB, redEnvelope *model.RedEnvelope, userID uint64) (*model.RedEnvelopeReservation, int, decimal.Decimal, error) {
+	var pending []model.RedEnvelopeReservation
+	if err := tx.Where("red_envelope_id = ? AND claim_id IS NULL", redEnvelope.ID).Find(&pending).Error; err != nil {
+		return nil, 0, decimal.Zero, err
+	}
+
+	var reservation *model.RedEnvelopeReservation
+	openAmount := redEnvelope.RemainingAmount
+	for i := range pending {
+		openAmount = openAmount.Sub(pending[i].Amount)
+		if pending[i].UserID == userID {
+			reservation = &pending[i]
+		}
+	}
+	return reservation, redEnvelope.RemainingCount - len(pending), openAmount, nil
+}
+
+// resolveAllowList 校验可见范围并将可领取名单中的用户名解析为用户ID
+func resolveAllowList(ctx context.Context, params *CreateParams) ([]uint64, error) {
+	switch params.Visibility {
+	case "":
+		params.Visibility = model.RedEnvelopeVisibilityUnlisted
+	case model.RedEnvelopeVisibilityPublic, model.RedEnvelopeVisibilityUnlisted, model.RedEnvelopeVisibilityPrivate:
+	default:
+		return nil, errors.New(InvalidVisibility)
+	}
+
+	if params.Visibility != model.RedEnvelopeVisibilityPrivate {
+		if len(params.AllowedUsernames) > 0 {
+			return nil, errors.New(AllowListNotAllowed)
+		}
+		return nil, nil
+	}
+	if len(params.AllowedUsernames) == 0 {
+		return nil, errors.New(AllowListRequired)
+	}
+
+	usernames := make([]string, 0, len(params.AllowedUsernames))
+	seen := make(map[string]struct{}, len(params.AllowedUsernames))
+	for _, username := range params.AllowedUsernames {
+		username = strings.TrimSpace(username)
+		if username == "" {
+			continue
+		}
+		if _, ok := seen[username]; ok {
+			continue
+		}
+		seen[username] = struct{}{}
+		usernames = append(usernames, username)
+	}
+	if len(usernames) == 0 {
+		return nil, errors.New(AllowListRequired)
+	}
+
+	// 按创建时的用户名解析并保存用户ID，之后用户改名不影响名单
+	var users []model.User
+	if err := db.DB(ctx).Select("id, username").
+		Where("username IN ?", usernames).
+		Find(&users).Error; err != nil {
+		return nil, err
+	}
+	found := make(map[string]uint64, len(users))
+	for _, user := range users {
+		found[user.Username] = user.ID
+	}
+
+	userIDs := make([]uint64, 0, len(usernames))
+	var missing []string
+	for _, username := range usernames {
+		if userID, ok := found[username]; ok {
+			userIDs = append(userIDs, userID)
+		} else {
+			missing = append(missing, username)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, &allowListMissingError{usernames: missing}
+	}
+	return userIDs, nil
+}
+
+// checkAllowList 私密红包仅允许名单内的用户领取
+func checkAllowList(tx *gorm.DB, redEnvelope *model.RedEnvelope, userID uint64) error {
+	if redEnvelope.Visibility != model.RedEnvelopeVisibilityPrivate {
+		return nil
+	}
+	var count int64
+	if err := tx.Model(&model.RedEnvelopeAllowedUser{}).
+		Where("red_envelope_id = ? AND user_id = ?", redEnvelope.ID, userID).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return errors.New(NotInAllowList)
+	}
+	return nil
+}
+
+// addEscrowBalance 增加用户红包托管余额，托管账户不存在时自动创建
+func addEscrowBalance(tx *gorm.DB, userID uint64, amount decimal.Decimal) error {
+	return tx.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"balance":    gorm.Expr("red_envelope_escrows.balance + EXCLUDED.balance"),
+			"updated_at": time.Now(),
+		}),
+	}).Create(&model.RedEnvelopeEscrow{UserID: userID, Balance: amount}).Error
+}
+
+// addJackpotBalance 增加红包奖池余额，奖池记录不存在时创建
+func addJackpotBalance(tx *gorm.DB, amount decimal.Decimal) error {
+	return tx.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"balance":    gorm.Expr("red_envelope_jackpots.balance + EXCLUDED.balance"),
+			"updated_at": time.Now(),
+		}),
+	}).Create(&model.RedEnvelopeJackpot{ID: model.RedEnvelopeJackpotPoolID, Balance: amount}).Error
+}
+
+// balanceHold 复合操作中对用户可用余额的占用：锁定用户记录后在内存中跟踪余额，
+// 每笔扣款前先校验跟踪余额足够，保证先入账后扣款的顺序下任何中间步骤余额都不为负
+type balanceHold struct {
+	userID    uint64
+	available decimal.Decimal
+	update    func(tx *gorm.DB, opts service.BalanceUpdateOptions) error // 执行实际的余额变更
+}
+
+// holdBalance 锁定用户记录并占用其当前可用余额，须在复合操作的首次入账或扣款前调用
+func holdBalance(tx *gorm.DB, userID uint64) (*balanceHold, error) {
+	var user model.User
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Select("id, available_balance").Where("id = ?", userID).First(&user).Error; err != nil {
+		return nil, err
+	}
+	return &balanceHold{userID: userID, available: user.AvailableBalance, update: service.UpdateBalance}, nil
+}
+
+// credited 记录复合操作中已计入可用余额的金额
+func (h *balanceHold) credited(amount decimal.Decimal) {
+	h.available = h.available.Add(amount)
+}
+
+// debit 扣减可用余额，跟踪余额不足时不执行扣款直接返回余额不足
+func (h *balanceHold) debit(tx *gorm.DB, amount decimal.Decimal, totalField string) error {
+	if h.available.LessThan(amount) {
+		return errors.New(common.InsufficientBalance)
+	}
+	if err := h.update(tx, service.BalanceUpdateOptions{
+		UserID:       h.userID,
+		Amount:       amount,
+		Operation:    service.BalanceDeduct,
+		TotalField:   totalField,
+		CheckBalance: true,
+	}); err != nil {
+		return err
+	}
+	h.available = h.available.Sub(amount)
+	return nil
+}
+
+// deductEscrowBalance 扣减用户红包托管余额，余额不足时返回 EscrowInsufficient
+func deductEscrowBalance(tx *gorm.DB, userID uint64, amount decimal.Decimal) error {
+	result := tx.Model(&model.RedEnvelopeEscrow{}).
+		Where("user_id = ? AND balance >= ?", userID, amount).
+		UpdateColumns(map[string]interface{}{
+			"balance":    gorm.Expr("balance - ?", amount),
+			"updated_at": time.Now(),
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New(EscrowInsufficient)
+	}
+	return nil
+}
+
+// addWalletBalance 增加用户专用钱包余额，钱包不存在时自动创建
+func addWalletBalance(tx *gorm.DB, userID uint64, name string, amount decimal.Decimal) error {
+	return tx.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "user_id"}, {Name: "name"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"balance":    gorm.Expr("red_envelope_wallets.balance + EXCLUDED.balance"),
+			"updated_at": time.Now(),
+		}),
+	}).Create(&model.RedEnvelopeWallet{UserID: userID, Name: name, Balance: amount}).Error
+}
+
+// deductWalletBalance 扣减用户专用钱包余额，余额不足时返回 WalletInsufficient
+func deductWalletBalance(tx *gorm.DB, userID uint64, name string, amount decimal.Decimal) error {
+	result := tx.Model(&model.RedEnvelopeWallet{}).
+		Where("user_id = ? AND name = ? AND balance >= ?", userID, name, amount).
+		UpdateColumns(map[string]interface{}{
+			"balance":    gorm.Expr("balance - ?", amount),
+			"updated_at": time.Now(),
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New(WalletInsufficient)
+	}
+	return nil
+}
+
+// refundToCreator 向创建者退还红包金额并冲减total_payment
+// 托管资金创建的红包退回托管余额，指定专用钱包的红包退回同名钱包，否则退回可用余额
+func refundToCreator(tx *gorm.DB, redEnvelope *model.RedEnvelope, amount decimal.Decimal) error {
+	// 测试红包创建时未扣款，退款同样不入账
+	if redEnvelope.TestMode {
+		return nil
+	}
+	if redEnvelope.FundedByEscrow || redEnvelope.TargetWallet != "" {
+		var err error
+		if redEnvelope.FundedByEscrow {
+			err = addEscrowBalance(tx, redEnvelope.CreatorID, amount)
+		} else {
+			err = addWalletBalance(tx, redEnvelope.CreatorID, redEnvelope.TargetWallet, amount)
+		}
+		if err != nil {
+			return err
+		}
+		return tx.Model(&model.User{}).Where("id = ?", redEnvelope.CreatorID).
+			UpdateColumn("total_payment", gorm.Expr("total_payment - ?", amount)).Error
+	}
+
+	// 增加余额并减少total_payment
+	return service.UpdateBalance(tx, service.BalanceUpdateOptions{
+		UserID:     redEnvelope.CreatorID,
+		Amount:     amount.Neg(),
+		Operation:  service.BalanceDeduct,
+		TotalField: "total_payment",
+	})
+}
+
+// depositEscrow 从可用余额存入红包托管资金
+func depositEscrow(ctx context.Context, userID uint64, amount decimal.Decimal) error {
+	return db.DB(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := service.UpdateBalance(tx, service.BalanceUpdateOptions{
+			UserID:       userID,
+			Amount:       amount,
+			Operation:    service.BalanceDeduct,
+			CheckBalance: true,
+		}); err != nil {
+			return err
+		}
+
+		if err := addEscrowBalance(tx, userID, amount); err != nil {
+			return err
+		}
+
+		return tx.Create(&model.Order{
+			OrderName:   "红包托管存入",
+			PayerUserID: userID,
+			PayeeUserID: 0,
+			Amount:      amount,
+			Status:      model.OrderStatusSuccess,
+			Type:        model.OrderTypeRedEnvelopeEscrow,
+			Remark:      fmt.Sprintf("存入红包托管资金，金额: %s", util.FormatAmount(amount)),
+			TradeTime:   time.Now(),
+			ExpiresAt:   time.Now().Add(24 * time.Hour),
+		}).Error
+	})
+}
+
+// withdrawEscrow 将红包托管资金取回可用余额
+func withdrawEscrow(ctx context.Context, userID uint64, amount decimal.Decimal) error {
+	return db.DB(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := deductEscrowBalance(tx, userID, amount); err != nil {
+			return err
+		}
+
+		if err := service.UpdateBalance(tx, service.BalanceUpdateOptions{
+			UserID:    userID,
+			Amount:    amount,
+			Operation: service.BalanceAdd,
+		}); err != nil {
+			return err
+		}
+
+		return tx.Create(&model.Order{
+			OrderName:   "红包托管取回",
+			PayerUserID: 0,
+			PayeeUserID: userID,
+			Amount:      amount,
+			Status:      model.OrderStatusSuccess,
+			Type:        model.OrderTypeRedEnvelopeEscrow,
+			Remark:      fmt.Sprintf("取回红包托管资金，金额: %s", util.FormatAmount(amount)),
+			TradeTime:   time.Now(),
+			ExpiresAt:   time.Now().Add(24 * time.Hour),
+		}).Error
+	})
+}
+
+// depositWallet 从可用余额存入专用钱包
+func depositWallet(ctx context.Context, userID uint64, name string, amount decimal.Decimal) error {
+	return db.DB(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := service.UpdateBalance(tx, service.BalanceUpdateOptions{
+			UserID:       userID,
+			Amount:       amount,
+			Operation:    service.BalanceDeduct,
+			CheckBalance: true,
+		}); err != nil {
+			return err
+		}
+
+		if err := addWalletBalance(tx, userID, name, amount); err != nil {
+			return err
+		}
+
+		return tx.Create(&model.Order{
+			OrderName:   "红包钱包存入",
+			PayerUserID: userID,
+			PayeeUserID: 0,
+			Amount:      amount,
+			Status:      model.OrderStatusSuccess,
+			Type:        model.OrderTypeRedEnvelopeWallet,
+			Remark:      fmt.Sprintf("存入红包钱包 %s，金额: %s", name, util.FormatAmount(amount)),
+			TradeTime:   time.Now(),
+			ExpiresAt:   time.Now().Add(24 * time.Hour),
+		}).Error
+	})
+}
+
+// withdrawWallet 将专用钱包余额取回可用余额
+func withdrawWallet(ctx context.Context, userID uint64, name string, amount decimal.Decimal) error {
+	return db.DB(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := deductWalletBalance(tx, userID, name, amount); err != nil {
+			return err
+		}
+
+		if err := service.UpdateBalance(tx, service.BalanceUpdateOptions{
+			UserID:    userID,
+			Amount:    amount,
+			Operation: service.BalanceAdd,
+		}); err != nil {
+			return err
+		}
+
+		return tx.Create(&model.Order{
+			OrderName:   "红包钱包取回",
+			PayerUserID: 0,
+			PayeeUserID: userID,
+			Amount:      amount,
+			Status:      model.OrderStatusSuccess,
+			Type:        model.OrderTypeRedEnvelopeWallet,
+			Remark:      fmt.Sprintf("取回红包钱包 %s，金额: %s", name, util.FormatAmount(amount)),
+			TradeTime:   time.Now(),
+			ExpiresAt:   time.Now().Add(24 * time.Hour),
+		}).Error
+	})
+}
+
+// findEnvelope 按红包ID或红包码加载红包
+func findEnvelope(ctx context.Context, idOrCode string) (*model.RedEnvelope, error) {
+	redEnvelopeID, err := resolveRedEnvelopeID(ctx, idOrCode)
+	if err != nil {
+		return nil, err
+	}
+
+	var redEnvelope model.RedEnvelope
+	if err := db.DB(ctx).Where("id = ?", redEnvelopeID).First(&redEnvelope).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New(RedEnvelopeNotFound)
+		}
+		return nil, err
+	}
+	return &redEnvelope, nil
+}
+
+// loadCreatorEnvelope 按红包ID或红包码加载红包并校验调用者是否为创建者，供创建者专属接口统一鉴权
+// userID 为0时表示管理员操作，跳过创建者校验
+func loadCreatorEnvelope(ctx context.Context, idOrCode string, userID uint64) (*model.RedEnvelope, error) {
+	redEnvelope, err := findEnvelope(ctx, idOrCode)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkEnvelopeCreator(redEnvelope, userID); err != nil {
+		return nil, err
+	}
+	return redEnvelope, nil
+}
+
+// checkEnvelopeCreator 校验调用者是否为红包创建者，userID 为0时表示管理员操作，不做校验
+func checkEnvelopeCreator(redEnvelope *model.RedEnvelope, userID uint64) error {
+	if userID != 0 && redEnvelope.CreatorID != userID {
+		return errors.New(NotEnvelopeCreator)
+	}
+	return nil
+}
+
+// rotateEnvelopeCode 为进行中的红包重新生成红包码（使用不透明链接的红包重新生成链接凭证），旧红包码或链接随即失效
+// 红包ID、领取记录及订单均保持不变
+func rotateEnvelopeCode(ctx context.Context, idOrCode string, userID uint64) (*model.RedEnvelope, error) {
+	creatorEnvelope, err := loadCreatorEnvelope(ctx, idOrCode, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var redEnvelope model.RedEnvelope
+	if err := db.DB(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ?", creatorEnvelope.ID).First(&redEnvelope).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New(RedEnvelopeNotFound)
+			}
+			return err
+		}
+
+		if redEnvelope.Status == model.RedEnvelopeStatusExpired || redEnvelope.ExpiresAt.Before(time.Now()) {
+			return errors.New(RedEnvelopeExpired)
+		}
+		if redEnvelope.Status == model.RedEnvelopeStatusFinished {
+			return errors.New(RedEnvelopeFinished)
+		}
+
+		// 与创建时相同，红包码冲突时重新生成
+		updates := map[string]interface{}{}
+		if redEnvelope.LinkTokenHash != nil {
+			linkToken, err := generateLinkToken()
+			if err != nil {
+				return err
+			}
+			hash := hashLinkToken(linkToken)
+			redEnvelope.LinkToken = linkToken
+			redEnvelope.LinkTokenHash = &hash
+			updates["link_token_hash"] = hash
+		} else {
+			code, err := newUniqueEnvelopeCode(tx)
+			if err != nil {
+				return err
+			}
+			redEnvelope.Code = &code
+			updates["code"] = code
+		}
+
+		return tx.Model(&model.RedEnvelope{}).Where("id = ?", redEnvelope.ID).Updates(updates).Error
+	}); err != nil {
+		return nil, err
+	}
+
+	return &redEnvelope, nil
+}
+
+// pauseRedEnvelope 暂停进行中红包的领取（仅创建者），不退还剩余金额
+func pauseRedEnvelope(ctx context.Context, idOrCode string, userID uint64) error {
+	creatorEnvelope, err := loadCreatorEnvelope(ctx, idOrCode, userID)
+	if err != nil {
+		return err
+	}
+
+	return db.DB(ctx).Transaction(func(tx *gorm.DB) error {
+		redEnvelope, err := lockEnvelope(tx, creatorEnvelope.ID)
+		if err != nil {
+			return err
+		}
+
+		switch redEnvelope.Status {
+		case model.RedEnvelopeStatusPaused:
+			return errors.New(RedEnvelopePaused)
+		case model.RedEnvelopeStatusFinished:
+			return errors.New(RedEnvelopeFinished)
+		}
+		if redEnvelope.Status == model.RedEnvelopeStatusExpired || redEnvelope.ExpiresAt.Before(time.Now()) {
+			return errors.New(RedEnvelopeExpired)
+		}
+
+		return transitionStatus(tx, redEnvelope, model.RedEnvelopeStatusPaused, map[string]interface{}{
+			"paused_at": time.Now(),
+		}, statusEvent{ActorType: model.RedEnvelopeEventActorCreator, ActorID: userID, Reason: "创建者暂停领取"})
+	})
+}
+
+// resumeRedEnvelope 恢复暂停红包的领取（仅创建者），按配置将过期时间顺延暂停时长
+func resumeRedEnvelope(ctx context.Context, idOrCode string, userID uint64) error {
+	creatorEnvelope, err := loadCreatorEnvelope(ctx, idOrCode, userID)
+	if err != nil {
+		return err
+	}
+	extendExpiry, err := model.GetBoolByKey(ctx, model.ConfigKeyRedEnvelopePauseExtendsExpiry)
+	if err != nil {
+		return err
+	}
+
+	return db.DB(ctx).Transaction(func(tx *gorm.DB) error {
+		redEnvelope, err := lockEnvelope(tx, creatorEnvelope.ID)
+		if err != nil {
+			return err
+		}
+
+		if redEnvelope.Status == model.RedEnvelopeStatusExpired {
+			return errors.New(RedEnvelopeExpired)
+		}
+		if redEnvelope.Status != model.RedEnvelopeStatusPaused {
+			return errors.New(RedEnvelopeNotPaused)
+		}
+
+		// 未顺延时已过期的红包恢复后由过期退款任务处理
+		updates := map[string]interface{}{"paused_at": nil}
+		if extendExpiry && redEnvelope.PausedAt != nil {
+			updates["expires_at"] = redEnvelope.ExpiresAt.Add(time.Since(*redEnvelope.PausedAt))
+		}
+		return transitionStatus(tx, redEnvelope, model.RedEnvelopeStatusActive, updates,
+			statusEvent{ActorType: model.RedEnvelopeEventActorCreator, ActorID: userID, Reason: "创建者恢复领取"})
+	})
+}
+
+// getRedEnvelopeEvents 按时间顺序获取红包的状态流转记录（仅创建者）
+func getRedEnvelopeEvents(ctx context.Context, idOrCode string, userID uint64) ([]model.RedEnvelopeEvent, error) {
+	redEnvelope, err := loadCreatorEnvelope(ctx, idOrCode, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]model.RedEnvelopeEvent, 0)
+	if err := db.DB(ctx).Where("red_envelope_id = ?", redEnvelope.ID).
+		Order("created_at ASC, id ASC").
+		Find(&events).Error; err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// listClosingSoon 查询截止领取时间（过期时间加宽限时间）在窗口内、仍可领取的红包，按截止时间升序
+// 公开范围与红包广场一致，不展示隐藏的祝福语及领取成功提示语
+func listClosingSoon(ctx context.Context, userID uint64, req ClosingSoonRequest) ([]ClosingSoonItem, error) {
+	grace, err := getClaimGracePeriod(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	window := time.Duration(req.WithinMinutes) * time.Minute
+
+	query := db.DB(ctx).Model(&model.RedEnvelope{}).
+		Select("red_envelopes.*, users.username as creator_username, users.avatar_url as creator_avatar_url").
+		Joins("LEFT JOIN users ON red_envelopes.creator_id = users.id").
+		Where("red_envelopes.status = ? AND red_envelopes.expires_at > ? AND red_envelopes.expires_at <= ? AND red_envelopes.remaining_count > 0",
+			model.RedEnvelopeStatusActive, now.Add(-grace), now.Add(window-grace))
+	public := req.Scope == "public"
+	if public {
+		query = query.Where("red_envelopes.visibility = ?", model.RedEnvelopeVisibilityPublic)
+	} else {
+		query = query.Where("red_envelopes.creator_id = ?", userID)
+	}
+
+	var redEnvelopes []model.RedEnvelope
+	if err := query.Order("red_envelopes.expires_at ASC, red_envelopes.id ASC").
+		Limit(req.Limit).
+		Find(&redEnvelopes).Error; err != nil {
+		return nil, err
+	}
+
+	items := make([]ClosingSoonItem, len(redEnvelopes))
+	for i, redEnvelope := range redEnvelopes {
+		if public {
+			if redEnvelope.GreetingHidden && redEnvelope.Greeting != "" {
+				redEnvelope.Greeting = HiddenGreetingMask
+			}
+			redEnvelope.ClaimMessage = ""
+		}
+		deadline := redEnvelope.ExpiresAt.Add(grace)
+		items[i] = ClosingSoonItem{
+			RedEnvelope:   redEnvelope,
+			ClaimDeadline: deadline,
+			SecondsLeft:   max(int64(deadline.Sub(now)/time.Second), 0),
+		}
+	}
+	return items, nil
+}
+
+// getChannelStats 按领取渠道汇总红包的领取记录，仅创建者可查看
+func getChannelStats(ctx context.Context, idOrCode string, userID uint64) ([]ChannelStat, error) {
+	redEnvelope, err := loadCreatorEnvelope(ctx, idOrCode, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := make([]ChannelStat, 0)
+	if err := db.DB(ctx).Model(&model.RedEnvelopeClaim{}).
+		Select("channel, COUNT(*) AS claim_count, COALESCE(SUM(amount), 0) AS total_amount").
+		Where("red_envelope_id = ?", redEnvelope.ID).
+		Group("channel").
+		Order("claim_count DESC, channel ASC").
+		Scan(&stats).Error; err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// lockEnvelope 在事务中锁定红包记录，创建者校验由 loadCreatorEnvelope 在事务外完成（创建者不会变更）
+func lockEnvelope(tx *gorm.DB, redEnvelopeID uint64) (*model.RedEnvelope, error) {
+	var redEnvelope model.RedEnvelope
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ?", redEnvelopeID).First(&redEnvelope).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New(RedEnvelopeNotFound)
+		}
+		return nil, err
+	}
+	return &redEnvelope, nil
+}
+
+// ClaimRedEnvelope 按红包ID或红包码为指定用户领取红包，供服务端回调及内部调用共用，不校验一次性领取凭证
+func ClaimRedEnvelope(ctx context.Context, userID uint64, code string) (*ClaimResponse, error) {
+	redEnvelopeID, err := resolveRedEnvelopeID(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+	return claimRedEnvelope(ctx, userID, redEnvelopeID, false, nil, claimDevice{})
+}
+
+// claimByCode 领取红包接口的服务逻辑：按红包ID或红包码领取，按配置校验一次性领取凭证并记录领取设备信息哈希
+// forward 不为空时在同一事务中以领取金额创建新红包，全部成功或全部回滚；新红包总额为实际入账的领取金额，手续费按正常发红包规则从领取者余额额外扣除
+func claimByCode(ctx context.Context, userID uint64, code string, claimToken string, forward *CreateParams, device claimDevice) (*ClaimResponse, error) {
+	redEnvelopeID, err := resolveRedEnvelopeID(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+
+	claimTokenRequired, err := model.GetBoolByKey(ctx, model.ConfigKeyRedEnvelopeClaimTokenRequired)
+	if err != nil {
+		return nil, err
+	}
+	restoreToken, err := checkClaimToken(ctx, claimTokenRequired, redEnvelopeID, userID, claimToken)
+	if err != nil {
+		return nil, err
+	}
+
+	if forward != nil {
+		forward.CreatorID = userID
+	}
+	resp, err := claimRedEnvelope(ctx, userID, redEnvelopeID, false, forward, device)
+	if err != nil {
+		restoreToken()
+		return nil, err
+	}
+	return resp, nil
+}
+
+// checkClaimToken 需要一次性领取凭证时校验并取走凭证，凭证无效时返回 ClaimTokenInvalid
+// 凭证在领取前取走以防止并发请求重复使用，返回的 restore 须在领取失败时调用以恢复凭证，领取成功后凭证即视为已消费
+func checkClaimToken(ctx context.Context, required bool, redEnvelopeID uint64, userID uint64, token string) (restore func(), err error) {
+	if !required {
+		return func() {}, nil
+	}
+	ttl, valid, err := takeClaimToken(ctx, redEnvelopeID, userID, token)
+	if err != nil {
+		return nil, err
+	}
+	if !valid {
+		return nil, errors.New(ClaimTokenInvalid)
+	}
+	return func() {
+		// 领取失败可能由请求取消导致，恢复凭证不随请求上下文取消
+		restoreClaimToken(context.WithoutCancel(ctx), redEnvelopeID, userID, token, ttl)
+	}, nil
+}
+
+// reserveRedEnvelope 为需确认领取的红包预约名额，已预约人数不超过剩余个数
+func reserveRedEnvelope(ctx context.Context, userID uint64, redEnvelopeID uint64) (*ReserveResponse, error) {
+	var redEnvelope model.RedEnvelope
+	if err := db.DB(ctx).Where("id = ?", redEnvelopeID).First(&redEnvelope).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New(RedEnvelopeNotFound)
+		}
+		return nil, err
+	}
+
+	if !redEnvelope.RequireConfirm {
+		return nil, errors.New(ConfirmNotRequired)
+	}
+	grace, err := getClaimGracePeriod(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkClaimable(&redEnvelope, grace); err != nil {
+		return nil, err
+	}
+	if err := checkAllowList(db.DB(ctx), &redEnvelope, userID); err != nil {
+		return nil, err
+	}
+
+	var claimedCount int64
+	if err := db.DB(ctx).Model(&model.RedEnvelopeClaim{}).
+		Where("red_envelope_id = ? AND user_id = ?", redEnvelope.ID, userID).
+		Count(&claimedCount).Error; err != nil {
+		return nil, err
+	}
+	if claimedCount >= int64(max(redEnvelope.MaxClaimsPerUser, 1)) {
+		return nil, errors.New(RedEnvelopeAlreadyClaimed)
+	}
+
+	token := util.GenerateUniqueIDSimple()
+	now := time.Now()
+	expiresAt := now.Add(ReservationExpiration)
+	reserved, err := reserveSlotScript.Run(ctx, db.Redis,
+		[]string{
+			db.PrefixedKey(fmt.Sprintf(ReservedSlotsKeyFormat, redEnvelope.ID)),
+			db.PrefixedKey(fmt.Sprintf(ReservationKeyFormat, redEnvelope.ID, userID)),
+		},
+		now.UnixMilli(), expiresAt.UnixMilli(), redEnvelope.RemainingCount, userID, token, ReservationExpiration.Milliseconds(),
+	).Int()
+	if err != nil {
+		return nil, err
+	}
+	if reserved == 0 {
+		return nil, errors.New(ReservationFull)
+	}
+
+	return &ReserveResponse{ReservationToken: token, ExpiresAt: expiresAt}, nil
+}
+
+// confirmRedEnvelope 校验并消费预约凭证后完成领取，无论成功与否都释放预约名额
+func confirmRedEnvelope(ctx context.Context, userID uint64, redEnvelopeID uint64, token string, device claimDevice) (*ClaimResponse, error) {
+	reservationKey := db.PrefixedKey(fmt.Sprintf(ReservationKeyFormat, redEnvelopeID, userID))
+	deleted, err := consumeClaimTokenScript.Run(ctx, db.Redis, []string{reservationKey}, token).Int()
+	if err != nil {
+		return nil, err
+	}
+	if deleted != 1 {
+		return nil, errors.New(ReservationInvalid)
+	}
+
+	defer func() {
+		slotsKey := db.PrefixedKey(fmt.Sprintf(ReservedSlotsKeyFormat, redEnvelopeID))
+		if err := db.Redis.ZRem(ctx, slotsKey, userID).Err(); err != nil {
+			logger.WarnF(ctx, "红包ID:%d 释放预约名额失败: %v", redEnvelopeID, err)
+		}
+	}()
+
+	return claimRedEnvelope(ctx, userID, redEnvelopeID, true, nil, device)
+}
+
+// claimRedEnvelope 领取红包事务，confirmed 表示已通过预约确认，forward 不为空时以领取金额转发为新红包
+// device 为领取设备信息哈希，未启用采集时为空
+func claimRedEnvelope(ctx context.Context, userID uint64, redEnvelopeID uint64, confirmed bool, forward *CreateParams, device claimDevice) (*ClaimResponse, error) {
+	// 全局暂停领取时段优先于红包自身的有效期
+	closed, _, err := claimBlackout(ctx, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if closed {
+		return nil, errors.New(ClaimsClosedNow)
+	}
+
+	rules, err := loadClaimRules(ctx)
+	if err != nil {
+		return nil, err
+	}
+	grace, err := getClaimGracePeriod(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// 可选的 Redis 闸门：剩余个数耗尽时直接拒绝，避免争抢请求全部排队等待行锁
+	gateEnabled, err := model.GetBoolByKey(ctx, model.ConfigKeyRedEnvelopeClaimGateEnabled)
+	if err != nil {
+		return nil, err
+	}
+
+	// 闸门名额在所有可能失败的前置检查之后获取，此后的失败均在事务返回时归还
+	gateHeld := false
+	if gateEnabled {
+		admitted, tracked, err := acquireClaimGate(ctx, redEnvelopeID)
+		if err != nil {
+			return nil, err
+		}
+		if !admitted {
+			return nil, errors.New(RedEnvelopeTooPopular)
+		}
+		gateHeld = tracked
+	}
+
+	var claim *model.RedEnvelopeClaim
+	var redEnvelope model.RedEnvelope
+	var forwarded *model.RedEnvelope
+	var claimer model.User
+
+	// 写入领取记录、余额或订单时遇到瞬时数据库错误整体回滚后重试，避免直接向用户返回错误；隔离级别见 claim_isolation 配置
+	if err := db.RetryTransaction(ctx, db.DefaultTxAttempts, func(tx *gorm.DB) error {
+		redEnvelope, forwarded, claimer = model.RedEnvelope{}, nil, model.User{}
+
+		// 使用 FOR UPDATE 锁定红包记录，防止并发领取
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "NOWAIT"}).
+			Where("id = ?", redEnvelopeID).First(&redEnvelope).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New(RedEnvelopeNotFound)
+			}
+			// 序列化冲突等瞬时错误原样返回，由外层整体重试
+			if db.IsTransientError(err) {
+				return err
+			}
+			// 捕获锁等待超时错误，返回友好提示
+			return errors.New(RedEnvelopeTooPopular)
+		}
+
+		// 检查红包状态，过期后的宽限时间内仍可领取
+		if err := checkClaimable(&redEnvelope, grace); err != nil {
+			return err
+		}
+
+		// 需确认领取的红包只能通过预约确认领取
+		if redEnvelope.RequireConfirm && !confirmed {
+			return errors.New(ConfirmRequired)
+		}
+
+		// 转发时先锁定领取者余额，领取入账后再扣除转发金额及手续费
+		var hold *balanceHold
+		if forward != nil {
+			var err error
+			if hold, err = holdBalance(tx, userID); err != nil {
+				return err
+			}
+		}
+
+		var err error
+		claim, err = claimInTx(tx, &redEnvelope, userID, rules, device)
+		if err != nil {
+			return err
+		}
+
+		// 转发失败时整个领取一并回滚
+		if forward != nil {
+			// 计入专用钱包的领取金额不能用于转发，手续费等超出领取金额的部分须由自有余额承担
+			if redEnvelope.TargetWallet == "" && !redEnvelope.TestMode {
+				hold.credited(claim.Amount)
+			}
+			forward.TotalAmount = claim.Amount
+			forward.hold = hold
+			if forwarded, err = createRedEnvelope(ctx, tx, *forward); err != nil {
+				return err
+			}
+		}
+
+		// 在同一事务中读取入账（及转发扣款）后的可用余额，与本次领取结果一致
+		return tx.Select("available_balance").Where("id = ?", userID).First(&claimer).Error
+	}, claimTxOptions(ctx)); err != nil {
+		if gateHeld {
+			releaseClaimGate(ctx, redEnvelopeID)
+		}
+		return nil, err
+	}
+
+	// 数据库为准：红包领完后移除闸门
+	if gateEnabled && redEnvelope.Status == model.RedEnvelopeStatusFinished {
+		removeClaimGate(ctx, redEnvelopeID)
+	}
+	if forwarded != nil {
+		onRedEnvelopeCreated(ctx, forwarded)
+	}
+	publishClaimStream(ctx, &redEnvelope, claim)
+
+	resp := &ClaimResponse{
+		Amount:               claim.Amount,
+		AvailableBalance:     claimer.AvailableBalance,
+		ClaimMessage:         redEnvelope.ClaimMessage,
+		RedEnvelope:          &redEnvelope,
+		ForwardedRedEnvelope: forwarded,
+	}
+	// 领取已提交，回执签名失败不影响领取结果
+	if redEnvelope.SignedReceipt {
+		if resp.Receipt, err = signClaimReceipt(claim); err != nil {
+			logger.ErrorF(ctx, "红包 %d 领取 %d 签发领取回执失败: %v", redEnvelope.ID, claim.ID, err)
+		}
+	}
+	return resp, nil
+}
+
+// claimBatch 为同一用户依次领取多个红包，每个红包使用独立事务，单个红包失败时记录原因并继续领取其余红包
+// 按顺序逐个提交，余额上限、创建者累计领取上限等跨红包的限制均基于前序领取后的最新数据判断
+func claimBatch(ctx context.Context, userID uint64, entries []ClaimBatchEntry, device claimDevice) ([]ClaimBatchItem, error) {
+	claimTokenRequired, err := model.GetBoolByKey(ctx, model.ConfigKeyRedEnvelopeClaimTokenRequired)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]ClaimBatchItem, 0, len(entries))
+	for _, entry := range entries {
+		item := ClaimBatchItem{Code: entry.Code}
+
+		redEnvelopeID, err := resolveRedEnvelopeID(ctx, entry.Code)
+		if err != nil {
+			item.Error = err.Error()
+			items = append(items, item)
+			continue
+		}
+		item.RedEnvelopeID = redEnvelopeID
+
+		restoreToken, err := checkClaimToken(ctx, claimTokenRequired, redEnvelopeID, userID, entry.ClaimToken)
+		if err != nil {
+			item.Error = err.Error()
+			items = append(items, item)
+			continue
+		}
+
+		resp, err := claimRedEnvelope(ctx, userID, redEnvelopeID, false, nil, device)
+		if err != nil {
+			restoreToken()
+			item.Error = err.Error()
+		} else {
+			item.Amount = resp.Amount
+		}
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+// bulkClaimRedEnvelope 在一个事务中为多个用户依次领取红包，按红包的分配规则计算金额并入账
+// operatorID 为0时表示管理员操作，否则仅红包创建者可操作；任一用户无法领取或名额不足时整体回滚
+// 失败时返回导致失败的用户名（与红包本身相关的错误为空）
+func bulkClaimRedEnvelope(ctx context.Context, operatorID uint64, idOrCode string, usernames []string) ([]BulkClaimItem, string, error) {
+	// 代领同样受全局暂停领取时段限制
+	closed, _, err := claimBlackout(ctx, time.Now())
+	if err != nil {
+		return nil, "", err
+	}
+	if closed {
+		return nil, "", errors.New(ClaimsClosedNow)
+	}
+
+	creatorEnvelope, err := loadCreatorEnvelope(ctx, idOrCode, operatorID)
+	if err != nil {
+		return nil, "", err
+	}
+	rules, err := loadClaimRules(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+	grace, err := getClaimGracePeriod(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var redEnvelope model.RedEnvelope
+	var failedUsername string
+	items := make([]BulkClaimItem, 0, len(usernames))
+	claims := make([]*model.RedEnvelopeClaim, 0, len(usernames))
+
+	if err := db.DB(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ?", creatorEnvelope.ID).First(&redEnvelope).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New(RedEnvelopeNotFound)
+			}
+			return err
+		}
+
+		if err := checkClaimable(&redEnvelope, grace); err != nil {
+			return err
+		}
+		if redEnvelope.RemainingCount < len(usernames) {
+			return errors.New(InsufficientSlots)
+		}
+
+		var users []model.User
+		if err := tx.Select("id, username").
+			Where("username IN ? AND is_active = ?", usernames, true).
+			Find(&users).Error; err != nil {
+			return err
+		}
+		userIDs := make(map[string]uint64, len(users))
+		for _, user := range users {
+			userIDs[user.Username] = user.ID
+		}
+
+		for _, username := range usernames {
+			userID, ok := userIDs[username]
+			if !ok {
+				failedUsername = username
+				return errors.New(BulkClaimUserNotFound)
+			}
+			claim, err := claimInTx(tx, &redEnvelope, userID, rules, claimDevice{})
+			if err != nil {
+				failedUsername = username
+				return err
+			}
+			claims = append(claims, claim)
+			items = append(items, BulkClaimItem{UserID: userID, Username: username, Amount: claim.Amount})
+		}
+		return nil
+	}); err != nil {
+		return nil, failedUsername, err
+	}
+
+	// 批量领取未经过闸门，按数据库剩余个数同步闸门
+	if gateEnabled, err := model.GetBoolByKey(ctx, model.ConfigKeyRedEnvelopeClaimGateEnabled); err == nil && gateEnabled {
+		if redEnvelope.Status == model.RedEnvelopeStatusFinished {
+			removeClaimGate(ctx, redEnvelope.ID)
+		} else if err := initClaimGate(ctx, redEnvelope.ID, redEnvelope.RemainingCount, redEnvelope.ExpiresAt); err != nil {
+			logger.WarnF(ctx, "红包ID:%d 同步领取闸门失败: %v", redEnvelope.ID, err)
+		}
+	}
+	publishClaimStream(ctx, &redEnvelope, claims...)
+
+	return items, "", nil
+}
+
+// claimRules 领取时生效的系统限制
+type claimRules struct {
+	balanceCap        decimal.Decimal   // 领取者可用余额上限，0表示不限制
+	balanceCapPartial bool              // 超出余额上限时是否仅入账至上限并将超出部分退还创建者
+	creatorClaimCap   decimal.Decimal   // 从同一创建者处累计领取金额上限，0表示不限制
+	trustWeights      []decimal.Decimal // 按信任等级加权拆分时各信任等级的权重
+	dustThreshold     decimal.Decimal   // 零头阈值，0表示不因零头提前结束红包
+	rejectBanned      bool              // 是否拒绝领取已封禁创建者的红包
+}
+
+// loadClaimRules 读取领取相关的系统配置
+func loadClaimRules(ctx context.Context) (claimRules, error) {
+	var rules claimRules
+	var err error
+	// 领取者余额上限：超出时拒绝领取，或仅入账至上限并将超出部分退还创建者
+	if rules.balanceCap, err = model.GetDecimalByKey(ctx, model.ConfigKeyRedEnvelopeBalanceCap, util.AmountPrecision()); err != nil {
+		return rules, err
+	}
+	if rules.balanceCapPartial, err = model.GetBoolByKey(ctx, model.ConfigKeyRedEnvelopeBalanceCapPartial); err != nil {
+		return rules, err
+	}
+	// 同一用户从同一创建者处累计领取金额上限，防止创建者通过多个红包向单一账户输送资金
+	if rules.creatorClaimCap, err = model.GetDecimalByKey(ctx, model.ConfigKeyRedEnvelopeCreatorClaimCap, util.AmountPrecision()); err != nil {
+		return rules, err
+	}
+	// 拆分权重仅影响按信任等级加权的红包，配置无效时退化为等权重，不影响领取
+	if rules.trustWeights, err = loadTrustWeights(ctx); err != nil {
+		logger.WarnF(ctx, "读取信任等级拆分权重失败，按等权重拆分: %v", err)
+	}
+	if rules.dustThreshold, err = model.GetDecimalByKey(ctx, model.ConfigKeyRedEnvelopeDustThreshold, util.AmountPrecision()); err != nil {
+		return rules, err
+	}
+	if rules.rejectBanned, err = model.GetBoolByKey(ctx, model.ConfigKeyRedEnvelopeRejectBannedCreator); err != nil {
+		return rules, err
+	}
+	return rules, nil
+}
+
+// checkClaimEligibility 判断用户能否领取红包：须在可领取名单内、未达到每人领取次数上限，且有预留名额或开放名额未耗尽
+// 返回用户的预留名额（无则为 nil）及开放名额的剩余个数和金额，红包状态由 checkClaimable 单独判断
+func checkClaimEligibility(tx *gorm.DB, redEnvelope *model.RedEnvelope, userID uint64) (*model.RedEnvelopeReservation, int, decimal.Decimal, error) {
+	if err := checkAllowList(tx, redEnvelope, userID); err != nil {
+		return nil, 0, decimal.Zero, err
+	}
+
+	// 检查是否已达到每人领取次数上限
+	var claimedCount int64
+	if err := tx.Model(&model.RedEnvelopeClaim{}).
+		Where("red_envelope_id = ? AND user_id = ?", redEnvelope.ID, userID).
+		Count(&claimedCount).Error; err != nil {
+		return nil, 0, decimal.Zero, err
+	}
+	maxClaimsPerUser := max(redEnvelope.MaxClaimsPerUser, 1)
+	if claimedCount >= int64(maxClaimsPerUser) {
+		return nil, 0, decimal.Zero, errors.New(RedEnvelopeAlreadyClaimed)
+	}
+
+	// 有预留名额时，被预留的用户领取预留金额，其他用户从扣除未领取预留后的开放名额中领取
+	var reservation *model.RedEnvelopeReservation
+	openCount, openAmount := redEnvelope.RemainingCount, redEnvelope.RemainingAmount
+	if redEnvelope.ReservedCount > 0 {
+		var err error
+		if reservation, openCount, openAmount, err = loadReservation(tx, redEnvelope, userID); err != nil {
+			return nil, 0, decimal.Zero, err
+		}
+	}
+	if reservation == nil && openCount <= 0 {
+		return nil, 0, decimal.Zero, errors.New(OpenSlotsExhausted)
+	}
+
+	return reservation, openCount, openAmount, nil
+}
+
+// getClaimEligibility 查询指定用户能否领取红包，供创建者或管理员排查领取问题，不可领取时返回原因
+func getClaimEligibility(ctx context.Context, operator *model.User, idOrCode string, userID uint64) (*EligibilityResponse, error) {
+	operatorID := operator.ID
+	if operator.IsAdmin {
+		operatorID = 0
+	}
+	redEnvelope, err := loadCreatorEnvelope(ctx, idOrCode, operatorID)
+	if err != nil {
+		return nil, err
+	}
+
+	var user model.User
+	if err := db.DB(ctx).Select("id, username, is_active").Where("id = ?", userID).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New(EligibilityUserNotFound)
+		}
+		return nil, err
+	}
+
+	grace, err := getClaimGracePeriod(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &EligibilityResponse{UserID: user.ID, Username: user.Username}
+	if !user.IsActive {
+		resp.Reason = BulkClaimUserNotFound
+		return resp, nil
+	}
+	if err := checkClaimable(redEnvelope, grace); err != nil {
+		resp.Reason = err.Error()
+		return resp, nil
+	}
+	if _, _, _, err := checkClaimEligibility(db.DB(ctx), redEnvelope, user.ID); err != nil {
+		switch err.Error() {
+		case NotInAllowList, RedEnvelopeAlreadyClaimed, OpenSlotsExhausted:
+			resp.Reason = err.Error()
+			return resp, nil
+		default:
+			return nil, err
+		}
+	}
+
+	resp.Eligible = true
+	return resp, nil
+}
+
+// claimInTx 在已锁定红包的事务中为用户领取一份红包：校验领取资格、计算金额、入账并写入订单
+// 红包的剩余个数、剩余金额及状态同步更新到 redEnvelope，返回领取记录（金额为实际入账金额）
+func claimInTx(tx *gorm.DB, redEnvelope *model.RedEnvelope, userID uint64, rules claimRules, device claimDevice) (*model.RedEnvelopeClaim, error) {
+	reservation, openCount, openAmount, err := checkClaimEligibility(tx, redEnvelope, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 创建者被封禁后按配置拒绝领取，封禁处理任务执行前同样生效
+	if rules.rejectBanned {
+		var creator model.User
+		if err := tx.Select("is_active").Where("id = ?", redEnvelope.CreatorID).First(&creator).Error; err != nil {
+			return nil, err
+		}
+		if err := creator.CheckActive(); err != nil {
+			return nil, errors.New(CreatorBanned)
+		}
+	}
+
+	// 领取记录可被退回删除，领取序号取已有最大序号加一，避免与保留的记录冲突
+	var maxSequence int
+	if err := tx.Model(&model.RedEnvelopeClaim{}).
+		Where("red_envelope_id = ? AND user_id = ?", redEnvelope.ID, userID).
+		Select("COALESCE(MAX(sequence), 0)").
+		Scan(&maxSequence).Error; err != nil {
+		return nil, err
+	}
+
+	// 计算领取金额
+	var claimedAmount decimal.Decimal
+	if reservation != nil {
+		claimedAmount = reservation.Amount
+	} else {
+		if redEnvelope.Type == model.RedEnvelopeTypeFixed {
+			// 固定金额：如果是最后一个，给全部剩余金额（避免舍入误差）
+			if openCount == 1 {
+				claimedAmount = openAmount
+			} else {
+				claimedAmount = redEnvelope.TotalAmount.Sub(redEnvelope.ReservedAmount).
+					Div(decimal.NewFromInt(int64(redEnvelope.TotalCount - redEnvelope.ReservedCount))).Round(util.AmountPrecision())
+			}
+		} else if redEnvelope.Type == model.RedEnvelopeTypeHybrid {
+			// 保底加随机红包：保底金额加上奖池中的随机部分
+			claimedAmount = calculateHybridAmount(openAmount, redEnvelope.BaseAmount, openCount)
+		} else if redEnvelope.Split == model.RedEnvelopeSplitTrustWeighted {
+			// 按信任等级加权：期望金额按领取者权重相对平均权重放大或缩小，下限与总额约束不变
+			var trustLevel model.TrustLevel
+			if err := tx.Model(&model.User{}).Select("trust_level").Where("id = ?", userID).Scan(&trustLevel).Error; err != nil {
+				return nil, err
+			}
+			claimedAmount = calculateWeightedRandomAmount(openAmount, openCount, redEnvelope.MinClaimAmount,
+				trustWeightFactor(rules.trustWeights, trustLevel))
+		} else {
+			// 拼手气红包：使用二倍均值算法，设置了最低领取金额时以其为下限
+			claimedAmount = calculateRandomAmountWithFloor(openAmount, openCount, redEnvelope.MinClaimAmount)
+		}
+		// 设置了领取面额时取整，最后一个领取者吸收差额
+		claimedAmount = snapToDenomination(claimedAmount, openAmount, redEnvelope.Denomination, openCount)
+	}
+
+	// 查询领取者信息用于快照用户名及头像，启用余额上限或创建者累计领取上限时锁定用户记录，
+	// 使同一领取者的并发领取串行执行，避免上限检查基于过期数据
+	// 余额上限仅约束可用余额，计入专用钱包的领取不受限制
+	capped := rules.balanceCap.IsPositive() && redEnvelope.TargetWallet == ""
+	var claimer model.User
+	claimerQuery := tx.Select("id, username, avatar_url, available_balance").Where("id = ?", userID)
+	if capped || rules.creatorClaimCap.IsPositive() {
+		claimerQuery = claimerQuery.Clauses(clause.Locking{Strength: "UPDATE"})
+	}
+	if err := claimerQuery.First(&claimer).Error; err != nil {
+		return nil, err
+	}
+
+	// 检查领取者余额上限，slotAmount 为本次从红包中扣除的金额
+	slotAmount := claimedAmount
+	refundAmount := decimal.Zero
+	if capped {
+		var err error
+		if claimedAmount, refundAmount, err = applyBalanceCap(slotAmount, claimer.AvailableBalance, rules.balanceCap, rules.balanceCapPartial); err != nil {
+			return nil, err
+		}
+	}
+
+	// 检查从该创建者红包中累计领取的金额
+	// 基于红包收入订单统计，领取记录归档后累计金额不会被重置，测试红包不计入
+	if rules.creatorClaimCap.IsPositive() {
+		var received decimal.Decimal
+		if err := tx.Model(&model.Order{}).
+			Where("payee_user_id = ? AND payer_user_id = ? AND status = ? AND type = ?",
+				userID, redEnvelope.CreatorID, model.OrderStatusSuccess, model.OrderTypeRedEnvelopeReceive).
+			Select("COALESCE(SUM(amount), 0)").
+			Scan(&received).Error; err != nil {
+			return nil, err
+		}
+		if received.Add(claimedAmount).GreaterThan(rules.creatorClaimCap) {
+			return nil, errors.New(CreatorClaimCapExceeded)
+		}
+	}
+
+	// 创建领取记录
+	claim := model.RedEnvelopeClaim{
+		ID:            idgen.NextUint64ID(),
+		RedEnvelopeID: redEnvelope.ID,
+		UserID:        userID,
+		Sequence:      maxSequence + 1,
+		Username:      claimer.Username,
+		AvatarURL:     claimer.AvatarUrl,
+		Amount:        claimedAmount,
+		IPHash:        device.IPHash,
+		DeviceHash:    device.DeviceHash,
+		Channel:       device.Channel,
+	}
+	if err := tx.Create(&claim).Error; err != nil {
+		return nil, err
+	}
+	if reservation != nil {
+		if err := tx.Model(&model.RedEnvelopeReservation{}).
+			Where("red_envelope_id = ? AND user_id = ?", redEnvelope.ID, userID).
+			Update("claim_id", claim.ID).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	// 更新红包状态
+	newRemainingCount := redEnvelope.RemainingCount - 1
+	newRemainingAmount := redEnvelope.RemainingAmount.Sub(slotAmount)
+	newStatus := redEnvelope.Status
+	reason := "红包已领完"
+
+	// 剩余金额只是无法再领取的零头时提前结束红包，零头退还创建者
+	dustAmount := decimal.Zero
+	if isDustRemainder(redEnvelope, newRemainingAmount, newRemainingCount, rules.dustThreshold) {
+		dustAmount = newRemainingAmount
+		newRemainingCount, newRemainingAmount = 0, decimal.Zero
+		reason = "剩余金额不足最低领取金额，红包提前结束"
+	}
+	if newRemainingCount <= 0 {
+		newStatus = model.RedEnvelopeStatusFinished
+	}
+
+	if err := transitionStatus(tx, redEnvelope, newStatus, map[string]interface{}{
+		"remaining_count":  newRemainingCount,
+		"remaining_amount": newRemainingAmount,
+	}, statusEvent{ActorType: model.RedEnvelopeEventActorClaimer, ActorID: userID, Reason: reason}); err != nil {
+		return nil, err
+	}
+
+	// 更新红包对象用于返回
+	redEnvelope.RemainingCount = newRemainingCount
+	redEnvelope.RemainingAmount = newRemainingAmount
+
+	// 增加领取者余额（指定专用钱包的红包计入同名钱包）并更新total_receive，测试红包不入账
+	if !redEnvelope.TestMode {
+		if redEnvelope.TargetWallet != "" {
+			if err := addWalletBalance(tx, userID, redEnvelope.TargetWallet, claimedAmount); err != nil {
+				return nil, err
+			}
+			if err := tx.Model(&model.User{}).Where("id = ?", userID).
+				UpdateColumn("total_receive", gorm.Expr("total_receive + ?", claimedAmount)).Error; err != nil {
+				return nil, err
+			}
+		} else if err := service.UpdateBalance(tx, service.BalanceUpdateOptions{
+			UserID:     userID,
+			Amount:     claimedAmount,
+			Operation:  service.BalanceAdd,
+			TotalField: "total_receive",
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	// 创建订单记录（红包收入）
+	order := model.Order{
+		OrderName:     "红包收入",
+		PayerUserID:   redEnvelope.CreatorID,
+		PayeeUserID:   userID,
+		Amount:        claimedAmount,
+		Status:        model.OrderStatusSuccess,
+		Type:          model.OrderTypeRedEnvelopeReceive,
+		Remark:        receiveOrderRemark(redEnvelope, claimedAmount),
+		RedEnvelopeID: &redEnvelope.ID,
+		TradeTime:     time.Now(),
+		ExpiresAt:     time.Now().Add(24 * time.Hour),
+	}
+
+	if err := tx.Create(markTestOrder(redEnvelope, &order)).Error; err != nil {
+		return nil, err
+	}
+
+	// 超出余额上限的部分退还给创建者
+	if refundAmount.IsPositive() {
+		if err := refundToCreator(tx, redEnvelope, refundAmount); err != nil {
+			return nil, err
+		}
+
+		refundOrder := model.Order{
+			OrderName:     "红包退款",
+			PayerUserID:   0,
+			PayeeUserID:   redEnvelope.CreatorID,
+			Amount:        refundAmount,
+			Status:        model.OrderStatusSuccess,
+			Type:          model.OrderTypeRedEnvelopeRefund,
+			Remark:        refundOrderRemark(redEnvelope, refundAmount, "红包领取超出余额上限退款"),
+			RedEnvelopeID: &redEnvelope.ID,
+			TradeTime:     time.Now(),
+			ExpiresAt:     time.Now().Add(24 * time.Hour),
+		}
+		if err := tx.Create(markTestOrder(redEnvelope, &refundOrder)).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	// 提前结束时剩余的零头退还给创建者
+	if dustAmount.IsPositive() {
+		if err := refundToCreator(tx, redEnvelope, dustAmount); err != nil {
+			return nil, err
+		}
+
+		dustOrder := model.Order{
+			OrderName:     "红包退款",
+			PayerUserID:   0,
+			PayeeUserID:   redEnvelope.CreatorID,
+			Amount:        dustAmount,
+			Status:        model.OrderStatusSuccess,
+			Type:          model.OrderTypeRedEnvelopeRefund,
+			Remark:        refundOrderRemark(redEnvelope, dustAmount, "红包剩余零头退款"),
+			RedEnvelopeID: &redEnvelope.ID,
+			TradeTime:     time.Now(),
+			ExpiresAt:     time.Now().Add(24 * time.Hour),
+		}
+		if err := tx.Create(markTestOrder(redEnvelope, &dustOrder)).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	return &claim, nil
+}
+
+// returnClaim 在退回时间窗口内撤销领取：删除领取记录，金额退回红包并扣减领取者余额
+func returnClaim(ctx context.Context, userID uint64, claimID uint64) error {
+	returnSeconds, err := model.GetIntByKey(ctx, model.ConfigKeyRedEnvelopeClaimReturnSeconds)
+	if err != nil {
+		return err
+	}
+	if returnSeconds <= 0 {
+		return errors.New(ClaimReturnDisabled)
+	}
+
+	var redEnvelope model.RedEnvelope
+	if err := db.DB(ctx).Transaction(func(tx *gorm.DB) error {
+		var claim model.RedEnvelopeClaim
+		if err := tx.Where("id = ? AND user_id = ?", claimID, userID).First(&claim).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New(ClaimNotFound)
+			}
+			return err
+		}
+		if time.Since(claim.ClaimedAt) > time.Duration(returnSeconds)*time.Second {
+			return errors.New(ClaimReturnWindowPassed)
+		}
+
+		// 锁定红包记录，与领取及过期退款互斥
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ?", claim.RedEnvelopeID).First(&redEnvelope).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New(RedEnvelopeNotFound)
+			}
+			return err
+		}
+		// 已过期退款的红包无法再接收退回金额
+		if redEnvelope.Status == model.RedEnvelopeStatusExpired {
+			return errors.New(RedEnvelopeExpired)
+		}
+
+		// 删除领取记录，并发的重复退回只有一个能成功
+		result := tx.Where("id = ?", claim.ID).Delete(&model.RedEnvelopeClaim{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errors.New(ClaimNotFound)
+		}
+
+		// 退回的是预留名额时恢复预留，领取者可再次领取预留金额
+		if err := tx.Model(&model.RedEnvelopeReservation{}).
+			Where("claim_id = ?", claim.ID).
+			Update("claim_id", nil).Error; err != nil {
+			return err
+		}
+
+		// 扣减领取者余额（指定专用钱包的红包扣减同名钱包）并冲减total_receive，测试红包领取时未入账无需扣减
+		if !redEnvelope.TestMode {
+			if redEnvelope.TargetWallet != "" {
+				if err := deductWalletBalance(tx, userID, redEnvelope.TargetWallet, claim.Amount); err != nil {
+					return err
+				}
+			} else if err := service.UpdateBalance(tx, service.BalanceUpdateOptions{
+				UserID:       userID,
+				Amount:       claim.Amount,
+				Operation:    service.BalanceDeduct,
+				CheckBalance: true,
+			}); err != nil {
+				return err
+			}
+			if err := tx.Model(&model.User{}).Where("id = ?", userID).
+				UpdateColumn("total_receive", gorm.Expr("total_receive - ?", claim.Amount)).Error; err != nil {
+				return err
+			}
+		}
+
+		// 金额及名额退回红包，已领完的红包重新变为进行中，进行中及暂停中的红包保持原状态
+		updates := map[string]interface{}{
+			"remaining_count":  gorm.Expr("remaining_count + 1"),
+			"remaining_amount": gorm.Expr("remaining_amount + ?", claim.Amount),
+		}
+		event := statusEvent{ActorType: model.RedEnvelopeEventActorClaimer, ActorID: claim.UserID, Reason: "领取退回"}
+		var err error
+		if redEnvelope.Status == model.RedEnvelopeStatusFinished {
+			err = reopenFinishedEnvelope(tx, &redEnvelope, updates, event)
+		} else {
+			err = transitionStatus(tx, &redEnvelope, redEnvelope.Status, updates, event)
+		}
+		if err != nil {
+			return err
+		}
+
+		order := model.Order{
+			OrderName:     "红包退回",
+			PayerUserID:   userID,
+			PayeeUserID:   0,
+			Amount:        claim.Amount,
+			Status:        model.OrderStatusSuccess,
+			Type:          model.OrderTypeRedEnvelopeReturn,
+			Remark:        fmt.Sprintf("退回已领取的红包，红包ID:%d，金额: %s", redEnvelope.ID, util.FormatAmount(claim.Amount)),
+			RedEnvelopeID: &redEnvelope.ID,
+			TradeTime:     time.Now(),
+			ExpiresAt:     time.Now().Add(24 * time.Hour),
+		}
+		return tx.Create(markTestOrder(&redEnvelope, &order)).Error
+	}); err != nil {
+		return err
+	}
+
+	// 闸门存在时归还名额，已领完的红包闸门已移除，领取时回落到数据库校验
+	if gateEnabled, err := model.GetBoolByKey(ctx, model.ConfigKeyRedEnvelopeClaimGateEnabled); err == nil && gateEnabled {
+		releaseClaimGate(ctx, redEnvelope.ID)
+	}
+	return nil
+}
+
+// claimByDeepLink 校验一键领取凭证后以凭证中的用户身份领取红包，凭证仅可使用一次
+// 凭证在领取前占用以拒绝并发使用，领取失败时释放，凭证在有效期内可重新使用
+func claimByDeepLink(ctx context.Context, redEnvelopeID uint64, token string, device claimDevice) (*ClaimResponse, error) {
+	tokenEnvelopeID, userID, nonce, expiresAt, err := parseDeepLinkToken(token)
+	if err != nil {
+		return nil, err
+	}
+	if tokenEnvelopeID != redEnvelopeID {
+		return nil, errors.New(DeepLinkTokenInvalid)
+	}
+
+	var user model.User
+	if err := db.DB(ctx).Where("id = ? AND is_active = ?", userID, true).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New(DeepLinkTokenInvalid)
+		}
+		return nil, err
+	}
+
+	// 记录已使用的凭证，保留至凭证过期
+	usedKey := db.PrefixedKey(fmt.Sprintf(DeepLinkTokenUsedKeyFormat, nonce))
+	ok, err := db.Redis.SetNX(ctx, usedKey, userID, time.Until(expiresAt)+time.Minute).Result()
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errors.New(DeepLinkTokenUsed)
+	}
+
+	resp, err := claimRedEnvelope(ctx, user.ID, redEnvelopeID, false, nil, device)
+	if err != nil {
+		// 请求取消时同样需要释放凭证
+		if delErr := db.Redis.Del(context.WithoutCancel(ctx), usedKey).Err(); delErr != nil {
+			logger.WarnF(ctx, "红包ID:%d 释放一键领取凭证失败: %v", redEnvelopeID, delErr)
+		}
+		return nil, err
+	}
+	return resp, nil
+}
+
+// issueDeepLink 为指定用户签发红包的一键领取凭证，红包须存在且用户须为有效用户
+func issueDeepLink(ctx context.Context, redEnvelopeID uint64, userID uint64) (*DeepLinkResponse, error) {
+	var redEnvelope model.RedEnvelope
+	if err := db.DB(ctx).Select("id").Where("id = ?", redEnvelopeID).First(&redEnvelope).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New(RedEnvelopeNotFound)
+		}
+		return nil, err
+	}
+
+	var user model.User
+	if err := db.DB(ctx).Select("id").Where("id = ? AND is_active = ?", userID, true).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New(WebhookUserNotFound)
+		}
+		return nil, err
+	}
+
+	token, expiresAt, err := GenerateDeepLinkToken(redEnvelope.ID, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &DeepLinkResponse{
+		Token:     token,
+		Link:      fmt.Sprintf("%s/redenvelope/%d?deep_link=%s", config.Config.App.FrontendURL, redEnvelope.ID, url.QueryEscape(token)),
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+// getConstraints 获取创建红包的校验规则，与 createRedEnvelope 的校验保持一致，短暂缓存
+func getConstraints(ctx context.Context) (*ConstraintsResponse, error) {
+	cacheKey := db.PrefixedKey(ConstraintsKey)
+	if db.Redis != nil {
+		if data, err := db.Redis.Get(ctx, cacheKey).Bytes(); err == nil {
+			var cached ConstraintsResponse
+			if err := json.Unmarshal(data, &cached); err == nil {
+				return &cached, nil
+			}
+		}
+	}
+
+	maxAmount, err := model.GetDecimalByKey(ctx, model.ConfigKeyRedEnvelopeMaxAmount, util.AmountPrecision())
+	if err != nil {
+		return nil, err
+	}
+	maxRecipients, err := model.GetIntByKey(ctx, model.ConfigKeyRedEnvelopeMaxRecipients)
+	if err != nil {
+		return nil, err
+	}
+	dailyLimit, err := model.GetIntByKey(ctx, model.ConfigKeyRedEnvelopeDailyLimit)
+	if err != nil {
+		return nil, err
+	}
+	feeRate, err := model.GetDecimalByKey(ctx, model.ConfigKeyRedEnvelopeFeeRate, 2)
+	if err != nil {
+		return nil, err
+	}
+	jackpotRate, err := model.GetDecimalByKey(ctx, model.ConfigKeyRedEnvelopeJackpotRate, 2)
+	if err != nil {
+		return nil, err
+	}
+	denomination, err := model.GetDecimalByKey(ctx, model.ConfigKeyRedEnvelopeClaimDenomination, util.AmountPrecision())
+	if err != nil {
+		return nil, err
+	}
+	maxGraphemes, err := model.GetIntByKey(ctx, model.ConfigKeyRedEnvelopeGreetingGraphemes)
+	if err != nil {
+		return nil, err
+	}
+
+	constraints := &ConstraintsResponse{
+		MinAmount:             decimal.NewFromInt(1),
+		MaxAmount:             maxAmount,
+		AmountDecimalPlaces:   int(util.AmountPrecision()),
+		MinPerEnvelope:        util.MinAmountUnit(),
+		MinCount:              1,
+		MaxCount:              maxRecipients,
+		DailyLimit:            dailyLimit,
+		FeeRate:               feeRate,
+		JackpotRate:           jackpotRate,
+		Denomination:          denomination,
+		MaxGreetingLength:     MaxGreetingLength,
+		MaxGreetingGraphemes:  maxGraphemes,
+		MaxClaimMessageLength: MaxClaimMessageLength,
+		ExpiryHours:           int(RedEnvelopeLifetime / time.Hour),
+	}
+
+	if db.Redis != nil {
+		if data, err := json.Marshal(constraints); err == nil {
+			if err := db.Redis.Set(ctx, cacheKey, data, ConstraintsCacheExpiration).Err(); err != nil {
+				logger.WarnF(ctx, "缓存红包校验规则失败: %v", err)
+			}
+		}
+	}
+	return constraints, nil
+}
+
+// getTopSenders 统计近 days 天内红包被领取总额最高的创建者，测试红包不计入，结果短时缓存
+func getTopSenders(ctx context.Context, days int, limit int) ([]*TopSender, error) {
+	cacheKey := db.PrefixedKey(fmt.Sprintf(TopSendersKeyFormat, days, limit))
+	if db.Redis != nil {
+		if data, err := db.Redis.Get(ctx, cacheKey).Bytes(); err == nil {
+			var cached []*TopSender
+			if err := json.Unmarshal(data, &cached); err == nil {
+				return cached, nil
+			}
+		}
+	}
+
+	senders := make([]*TopSender, 0, limit)
+	if err := db.DB(ctx).Table("red_envelope_claims").
+		Select("red_envelopes.creator_id AS user_id, users.username, users.avatar_url, "+
+			"SUM(red_envelope_claims.amount) AS total_amount, COUNT(*) AS claim_count").
+		Joins("JOIN red_envelopes ON red_envelopes.id = red_envelope_claims.red_envelope_id").
+		Joins("JOIN users ON users.id = red_envelopes.creator_id").
+		Where("red_envelope_claims.claimed_at >= ? AND red_envelopes.test_mode = ?", time.Now().AddDate(0, 0, -days), false).
+		Group("red_envelopes.creator_id, users.username, users.avatar_url").
+		Order("total_amount DESC, user_id ASC").
+		Limit(limit).
+		Scan(&senders).Error; err != nil {
+		return nil, err
+	}
+
+	if db.Redis != nil {
+		if data, err := json.Marshal(senders); err == nil {
+			if err := db.Redis.Set(ctx, cacheKey, data, TopSendersCacheExpiration).Err(); err != nil {
+				logger.WarnF(ctx, "缓存发红包排行榜失败: %v", err)
+			}
+		}
+	}
+	return senders, nil
+}
+
+// recurringDeferredErrors 创建定期发放计划时不拒绝的错误，这些条件在每次发放时重新校验
+var recurringDeferredErrors = []string{
+	common.InsufficientBalance,
+	EscrowInsufficient,
+	WalletInsufficient,
+	common.RedEnvelopeDailyLimitExceeded,
+	SystemLiabilityCapReached,
+}
+
+// createRecurringEnvelope 创建定期发放计划，按创建红包流程校验参数模板但不扣款，余额等条件在每次发放时校验
+func createRecurringEnvelope(ctx context.Context, creatorID uint64, spec EnvelopeSpec, expr string, endsAt *time.Time) (*model.RecurringEnvelope, error) {
+	now := time.Now()
+	if endsAt != nil && !endsAt.After(now) {
+		return nil, errors.New(InvalidRecurringEndsAt)
+	}
+	// 领取链接凭证只在创建时返回一次，定期发放无法将其交给创建者
+	if spec.OpaqueLink {
+		return nil, errors.New(RecurringOpaqueLink)
+	}
+	schedule, err := parseRecurringSchedule(expr, now)
+	if err != nil {
+		return nil, err
+	}
+
+	var count int64
+	if err := db.DB(ctx).Model(&model.RecurringEnvelope{}).Where("creator_id = ?", creatorID).Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count >= MaxRecurringPerUser {
+		return nil, errors.New(RecurringLimitReached)
+	}
+
+	costs, err := previewCreateCosts(ctx, []CreateParams{spec.createParams(creatorID)})
+	if err != nil {
+		return nil, err
+	}
+	if err := costs[0].Err; err != nil && !slices.Contains(recurringDeferredErrors, err.Error()) {
+		return nil, err
+	}
+
+	data, err := json.Marshal(spec)
+	if err != nil {
+		return nil, err
+	}
+	plan := &model.RecurringEnvelope{
+		ID:        idgen.NextUint64ID(),
+		CreatorID: creatorID,
+		Spec:      string(data),
+		Cron:      strings.TrimSpace(expr),
+		Enabled:   true,
+		EndsAt:    endsAt,
+		NextRunAt: schedule.Next(now),
+	}
+	if err := db.DB(ctx).Create(plan).Error; err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// listRecurringEnvelopes 获取用户的全部定期发放计划，按创建时间倒序
+func listRecurringEnvelopes(ctx context.Context, creatorID uint64) ([]model.RecurringEnvelope, error) {
+	plans := make([]model.RecurringEnvelope, 0)
+	if err := db.DB(ctx).Where("creator_id = ?", creatorID).Order("id DESC").Find(&plans).Error; err != nil {
+		return nil, err
+	}
+	return plans, nil
+}
+
+// updateRecurringEnvelope 启用/停用定期发放计划或修改结束时间（仅创建者）
+// 重新启用时从当前时间起计算下次发放时间，停用期间错过的发放不再补发
+func updateRecurringEnvelope(ctx context.Context, planID uint64, creatorID uint64, req *UpdateRecurringRequest) (*model.RecurringEnvelope, error) {
+	var plan model.RecurringEnvelope
+	if err := db.DB(ctx).Where("id = ? AND creator_id = ?", planID, creatorID).First(&plan).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New(RecurringNotFound)
+		}
+		return nil, err
+	}
+
+	now := time.Now()
+	updates := map[string]interface{}{}
+	if req.NoEndDate {
+		plan.EndsAt = nil
+		updates["ends_at"] = nil
+	} else if req.EndsAt != nil {
+		if !req.EndsAt.After(now) {
+			return nil, errors.New(InvalidRecurringEndsAt)
+		}
+		plan.EndsAt = req.EndsAt
+		updates["ends_at"] = *req.EndsAt
+	}
+
+	if req.Enabled != nil {
+		if *req.Enabled && !plan.Enabled {
+			if plan.EndsAt != nil && !plan.EndsAt.After(now) {
+				return nil, errors.New(InvalidRecurringEndsAt)
+			}
+			schedule, err := parseRecurringSchedule(plan.Cron, now)
+			if err != nil {
+				return nil, err
+			}
+			updates["next_run_at"] = schedule.Next(now)
+			updates["last_error"] = ""
+		}
+		updates["enabled"] = *req.Enabled
+	}
+
+	if len(updates) > 0 {
+		if err := db.DB(ctx).Model(&model.RecurringEnvelope{}).Where("id = ?", plan.ID).Updates(updates).Error; err != nil {
+			return nil, err
+		}
+		if err := db.DB(ctx).Where("id = ?", plan.ID).First(&plan).Error; err != nil {
+			return nil, err
+		}
+	}
+	return &plan, nil
+}
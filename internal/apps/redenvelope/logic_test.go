@@ -0,0 +1,254 @@
+/*
+Copyright 2025 linux.do
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package redenvelope
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/linux-do/credit/internal/common"
+	"github.com/linux-do/credit/internal/model"
+	"github.com/linux-do/credit/internal/service"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+func TestCheckEnvelopeCreator(t *testing.T) {
+	envelope := &model.RedEnvelope{ID: 100, CreatorID: 1}
+
+	cases := []struct {
+		name    string
+		userID  uint64
+		wantErr bool
+	}{
+		{"creator", 1, false},
+		{"admin skips creator check", 0, false},
+		{"other user", 2, true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := checkEnvelopeCreator(envelope, tc.userID)
+			if tc.wantErr && (err == nil || err.Error() != NotEnvelopeCreator) {
+				t.Fatalf("err = %v, want %s", err, NotEnvelopeCreator)
+			}
+			if !tc.wantErr && err != nil {
+				t.Fatalf("err = %v", err)
+			}
+		})
+	}
+}
+
+func TestCreateRedEnvelopeRejectsInvalidParams(t *testing.T) {
+	cases := []struct {
+		name    string
+		params  CreateParams
+		wantErr string
+	}{
+		{"unknown type", CreateParams{Type: "lucky", TotalCount: 1}, InvalidRedEnvelopeType},
+		{"zero count", CreateParams{Type: model.RedEnvelopeTypeFixed}, InvalidRedEnvelopeCount},
+		{"negative count", CreateParams{Type: model.RedEnvelopeTypeRandom, TotalCount: -1}, InvalidRedEnvelopeCount},
+		{"trust weighted fixed", CreateParams{Type: model.RedEnvelopeTypeFixed, TotalCount: 2, Split: model.RedEnvelopeSplitTrustWeighted}, InvalidSplit},
+		{"unknown split", CreateParams{Type: model.RedEnvelopeTypeRandom, TotalCount: 2, Split: "skewed"}, InvalidSplit},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			redEnvelope, err := CreateRedEnvelope(context.Background(), tc.params)
+			if err == nil || err.Error() != tc.wantErr {
+				t.Fatalf("err = %v, want %s", err, tc.wantErr)
+			}
+			if redEnvelope != nil {
+				t.Fatalf("red envelope = %+v, want nil", redEnvelope)
+			}
+		})
+	}
+}
+
+func TestValidateCreateParamsDefaultsSplit(t *testing.T) {
+	params := CreateParams{Type: model.RedEnvelopeTypeRandom, TotalCount: 3}
+	if err := validateCreateParams(&params); err != nil {
+		t.Fatalf("err = %v", err)
+	}
+	if params.Split != model.RedEnvelopeSplitUniform {
+		t.Fatalf("split = %q, want %q", params.Split, model.RedEnvelopeSplitUniform)
+	}
+}
+
+func TestValidateCreateParamsAmountRange(t *testing.T) {
+	cases := []struct {
+		name    string
+		body    string
+		wantErr string
+	}{
+		{"plain amounts", `{"total_amount":"100","min_claim_amount":"1"}`, ""},
+		{"scientific notation within range", `{"total_amount":1.5e2,"base_amount":"2e0"}`, ""},
+		{"largest storable total", `{"total_amount":"999999999999999999.9999"}`, ""},
+		{"scientific total out of range", `{"total_amount":1e30}`, AmountOutOfRange},
+		{"total at storage limit", `{"total_amount":"1000000000000000000"}`, AmountOutOfRange},
+		{"negative huge total", `{"total_amount":"-1e18"}`, AmountOutOfRange},
+		{"huge base amount", `{"total_amount":"100","base_amount":"1e20"}`, AmountOutOfRange},
+		{"huge min claim amount", `{"total_amount":"100","min_claim_amount":"5E+40"}`, AmountOutOfRange},
+		{"huge reservation", `{"total_amount":"100","reserved_allocations":{"alice":"1e25"}}`, AmountOutOfRange},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var spec EnvelopeSpec
+			if err := json.Unmarshal([]byte(tc.body), &spec); err != nil {
+				t.Fatalf("unmarshal %s: %v", tc.body, err)
+			}
+			spec.Type, spec.TotalCount = model.RedEnvelopeTypeRandom, 3
+			params := spec.createParams(1)
+			err := validateCreateParams(&params)
+			if tc.wantErr == "" {
+				if err != nil {
+					t.Fatalf("err = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tc.wantErr {
+				t.Fatalf("err = %v, want %s", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestClaimRedEnvelopeRejectsInvalidCode(t *testing.T) {
+	for _, code := range []string{"", strings.Repeat("x", MaxCodeLength+1)} {
+		resp, err := ClaimRedEnvelope(context.Background(), 1, code)
+		if err == nil || err.Error() != InvalidRedEnvelopeID {
+			t.Fatalf("code %q: err = %v, want %s", code, err, InvalidRedEnvelopeID)
+		}
+		if resp != nil {
+			t.Fatalf("code %q: resp = %+v, want nil", code, resp)
+		}
+	}
+}
+
+// fakeLedger 模拟用户可用余额，记录余额曾到达的最低值，扣款不做余额校验以暴露负余额
+type fakeLedger struct {
+	balance decimal.Decimal
+	lowest  decimal.Decimal
+	deducts int
+	failAt  int
+}
+
+// newLedger 构造初始余额为 balance 的 fakeLedger
+func newLedger(balance string) *fakeLedger {
+	ledger := &fakeLedger{balance: decimal.RequireFromString(balance)}
+	ledger.lowest = ledger.balance
+	return ledger
+}
+
+// hold 构造以 fakeLedger 执行余额变更的 balanceHold
+func (l *fakeLedger) hold() *balanceHold {
+	return &balanceHold{userID: 1, available: l.balance, update: l.update}
+}
+
+// update 模拟 service.UpdateBalance
+func (l *fakeLedger) update(_ *gorm.DB, opts service.BalanceUpdateOptions) error {
+	if opts.Operation == service.BalanceAdd {
+		l.credit(opts.Amount)
+		return nil
+	}
+	l.deducts++
+	if l.deducts == l.failAt {
+		return errors.New("connection reset")
+	}
+	l.balance = l.balance.Sub(opts.Amount)
+	l.lowest = decimal.Min(l.lowest, l.balance)
+	return nil
+}
+
+// credit 模拟领取入账
+func (l *fakeLedger) credit(amount decimal.Decimal) {
+	l.balance = l.balance.Add(amount)
+}
+
+func TestBalanceHoldForwardNeverGoesNegative(t *testing.T) {
+	ledger := newLedger("1")
+	hold := ledger.hold()
+
+	// 领取入账后以领取金额转发，手续费由自有余额承担
+	ledger.credit(decimal.NewFromInt(10))
+	hold.credited(decimal.NewFromInt(10))
+	if err := hold.debit(nil, decimal.RequireFromString("10.5"), "total_payment"); err != nil {
+		t.Fatalf("debit: %v", err)
+	}
+	if !ledger.balance.Equal(decimal.RequireFromString("0.5")) || !hold.available.Equal(ledger.balance) {
+		t.Fatalf("balance = %s, hold = %s, want 0.5", ledger.balance, hold.available)
+	}
+	if ledger.lowest.IsNegative() {
+		t.Fatalf("lowest balance = %s, want >= 0", ledger.lowest)
+	}
+}
+
+func TestBalanceHoldRejectsDebitBeyondHeldBalance(t *testing.T) {
+	ledger := newLedger("0")
+	hold := ledger.hold()
+
+	// 入账前扣款同样被拒绝，保证先入账后扣款的顺序
+	if err := hold.debit(nil, decimal.NewFromInt(10), "total_payment"); err == nil || err.Error() != common.InsufficientBalance {
+		t.Fatalf("debit before credit: err = %v, want %s", err, common.InsufficientBalance)
+	}
+
+	ledger.credit(decimal.NewFromInt(10))
+	hold.credited(decimal.NewFromInt(10))
+	if err := hold.debit(nil, decimal.RequireFromString("10.2"), "total_payment"); err == nil || err.Error() != common.InsufficientBalance {
+		t.Fatalf("debit with fee: err = %v, want %s", err, common.InsufficientBalance)
+	}
+	if ledger.deducts != 0 {
+		t.Fatalf("ledger deducts = %d, want 0", ledger.deducts)
+	}
+	if ledger.lowest.IsNegative() {
+		t.Fatalf("lowest balance = %s, want >= 0", ledger.lowest)
+	}
+}
+
+func TestBalanceHoldPartialFailureKeepsTrackedBalance(t *testing.T) {
+	ledger := newLedger("5")
+	ledger.failAt = 2
+	hold := ledger.hold()
+
+	ledger.credit(decimal.NewFromInt(10))
+	hold.credited(decimal.NewFromInt(10))
+	if err := hold.debit(nil, decimal.NewFromInt(10), "total_payment"); err != nil {
+		t.Fatalf("first debit: %v", err)
+	}
+	// 第二笔扣款在数据库层失败，跟踪余额与实际余额保持一致，事务回滚前后均不为负
+	if err := hold.debit(nil, decimal.NewFromInt(3), "total_payment"); err == nil {
+		t.Fatal("second debit: err = nil, want failure")
+	}
+	if !hold.available.Equal(ledger.balance) || !hold.available.Equal(decimal.NewFromInt(5)) {
+		t.Fatalf("hold = %s, balance = %s, want 5", hold.available, ledger.balance)
+	}
+	if ledger.lowest.IsNegative() {
+		t.Fatalf("lowest balance = %s, want >= 0", ledger.lowest)
+	}
+}
+
+func TestCheckClaimTokenNotRequired(t *testing.T) {
+	restore, err := checkClaimToken(context.Background(), false, 1, 2, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	restore()
+	if _, err := checkClaimToken(context.Background(), true, 1, 2, ""); err == nil || err.Error() != ClaimTokenInvalid {
+		t.Fatalf("missing token err = %v, want %s", err, ClaimTokenInvalid)
+	}
+}
@@ -0,0 +1,379 @@
+//go:build memtest
+
+/*
+Copyright 2025 linux.do
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package redenvelope
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/linux-do/credit/internal/apps/oauth"
+	"github.com/linux-do/credit/internal/db"
+	"github.com/linux-do/credit/internal/db/migrator"
+	"github.com/linux-do/credit/internal/model"
+	"github.com/linux-do/credit/internal/util"
+	"github.com/shopspring/decimal"
+)
+
+// setupMemory 切换到内存数据库及 Redis 并初始化表结构与默认配置，测试结束后释放
+func setupMemory(t *testing.T) {
+	t.Helper()
+	release, err := migrator.SetupMemory()
+	if err != nil {
+		t.Fatalf("setup memory store: %v", err)
+	}
+	t.Cleanup(release)
+}
+
+// seedUser 创建指定可用余额的用户
+func seedUser(t *testing.T, id uint64, username string, balance string) {
+	t.Helper()
+	user := model.User{
+		ID:               id,
+		Username:         username,
+		SignKey:          "sign-" + username,
+		AvailableBalance: decimal.RequireFromString(balance),
+		IsActive:         true,
+	}
+	if err := db.DB(context.Background()).Create(&user).Error; err != nil {
+		t.Fatalf("seed user %s: %v", username, err)
+	}
+}
+
+// availableBalance 查询用户当前可用余额
+func availableBalance(t *testing.T, userID uint64) decimal.Decimal {
+	t.Helper()
+	var user model.User
+	if err := db.DB(context.Background()).Select("available_balance").Where("id = ?", userID).First(&user).Error; err != nil {
+		t.Fatalf("load user %d: %v", userID, err)
+	}
+	return user.AvailableBalance
+}
+
+// assertBalance 校验用户可用余额
+func assertBalance(t *testing.T, userID uint64, want string) {
+	t.Helper()
+	if got := availableBalance(t, userID); !got.Equal(decimal.RequireFromString(want)) {
+		t.Fatalf("user %d balance = %s, want %s", userID, got, want)
+	}
+}
+
+func TestMemoryCreateClaimRefund(t *testing.T) {
+	setupMemory(t)
+	ctx := context.Background()
+	const creatorID, claimerID = 1001, 1002
+	seedUser(t, creatorID, "creator", "100")
+	seedUser(t, claimerID, "claimer", "0")
+
+	redEnvelope, err := CreateRedEnvelope(ctx, CreateParams{
+		CreatorID:   creatorID,
+		Type:        model.RedEnvelopeTypeFixed,
+		TotalAmount: decimal.NewFromInt(10),
+		TotalCount:  2,
+	})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	assertBalance(t, creatorID, "90")
+
+	resp, err := ClaimRedEnvelope(ctx, claimerID, strconv.FormatUint(redEnvelope.ID, 10))
+	if err != nil {
+		t.Fatalf("claim: %v", err)
+	}
+	if !resp.Amount.Equal(decimal.NewFromInt(5)) {
+		t.Fatalf("claimed amount = %s, want 5", resp.Amount)
+	}
+	assertBalance(t, claimerID, "5")
+
+	// 同一用户不能重复领取
+	if _, err := ClaimRedEnvelope(ctx, claimerID, strconv.FormatUint(redEnvelope.ID, 10)); err == nil || err.Error() != RedEnvelopeAlreadyClaimed {
+		t.Fatalf("second claim err = %v, want %s", err, RedEnvelopeAlreadyClaimed)
+	}
+
+	// 过期后剩余金额退还创建者，重复执行不会重复退款
+	expiredAt := time.Now().Add(-time.Hour)
+	if err := db.DB(ctx).Model(&model.RedEnvelope{}).Where("id = ?", redEnvelope.ID).Update("expires_at", expiredAt).Error; err != nil {
+		t.Fatalf("expire envelope: %v", err)
+	}
+	var expired model.RedEnvelope
+	if err := db.DB(ctx).Where("id = ?", redEnvelope.ID).First(&expired).Error; err != nil {
+		t.Fatalf("reload envelope: %v", err)
+	}
+	for range 2 {
+		if err := refundExpiredRedEnvelope(ctx, expired, time.Now()); err != nil {
+			t.Fatalf("refund: %v", err)
+		}
+	}
+	assertBalance(t, creatorID, "95")
+
+	if err := db.DB(ctx).Where("id = ?", redEnvelope.ID).First(&expired).Error; err != nil {
+		t.Fatalf("reload envelope: %v", err)
+	}
+	if expired.Status != model.RedEnvelopeStatusExpired || !expired.RemainingAmount.IsZero() {
+		t.Fatalf("after refund status = %s, remaining = %s, want expired with nothing left", expired.Status, expired.RemainingAmount)
+	}
+}
+
+// setSystemConfig 修改系统配置项，须在首次读取该配置（写入 Redis 缓存）前调用
+func setSystemConfig(t *testing.T, key, value string) {
+	t.Helper()
+	ctx := context.Background()
+	result := db.DB(ctx).Model(&model.SystemConfig{}).Where("key = ?", key).Update("value", value)
+	if result.Error != nil || result.RowsAffected != 1 {
+		t.Fatalf("set system config %s: err %v, rows %d", key, result.Error, result.RowsAffected)
+	}
+	if err := db.Redis.HDel(ctx, db.PrefixedKey(model.SystemConfigRedisHashKey), key).Err(); err != nil {
+		t.Fatalf("clear system config cache %s: %v", key, err)
+	}
+}
+
+func TestMemoryClaimTokenSurvivesFailedClaim(t *testing.T) {
+	setupMemory(t)
+	ctx := context.Background()
+	const creatorID, claimerID = 2001, 2002
+	seedUser(t, creatorID, "creator", "100")
+	seedUser(t, claimerID, "claimer", "0")
+	setSystemConfig(t, model.ConfigKeyRedEnvelopeClaimTokenRequired, "true")
+
+	redEnvelope, err := CreateRedEnvelope(ctx, CreateParams{
+		CreatorID:   creatorID,
+		Type:        model.RedEnvelopeTypeFixed,
+		TotalAmount: decimal.NewFromInt(10),
+		TotalCount:  1,
+	})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	id := strconv.FormatUint(redEnvelope.ID, 10)
+	tokenKey := db.PrefixedKey(fmt.Sprintf(ClaimTokenKeyFormat, redEnvelope.ID, claimerID))
+
+	// 暂停期间领取失败，凭证按原剩余有效期恢复
+	token, err := issueClaimToken(ctx, redEnvelope.ID, claimerID)
+	if err != nil {
+		t.Fatalf("issue token: %v", err)
+	}
+	if err := pauseRedEnvelope(ctx, id, creatorID); err != nil {
+		t.Fatalf("pause: %v", err)
+	}
+	if _, err := claimByCode(ctx, claimerID, id, token, nil, claimDevice{}); err == nil || err.Error() != RedEnvelopePaused {
+		t.Fatalf("claim while paused err = %v, want %s", err, RedEnvelopePaused)
+	}
+	if stored, err := db.Redis.Get(ctx, tokenKey).Result(); err != nil || stored != token {
+		t.Fatalf("token after failed claim = %q (err %v), want %q", stored, err, token)
+	}
+	if ttl := db.Redis.PTTL(ctx, tokenKey).Val(); ttl <= 0 || ttl > ClaimTokenExpiration {
+		t.Fatalf("restored token ttl = %v, want within (0, %v]", ttl, ClaimTokenExpiration)
+	}
+
+	// 恢复后凭同一凭证重试成功，凭证随即被消费
+	if err := resumeRedEnvelope(ctx, id, creatorID); err != nil {
+		t.Fatalf("resume: %v", err)
+	}
+	if _, err := claimByCode(ctx, claimerID, id, token, nil, claimDevice{}); err != nil {
+		t.Fatalf("retry claim: %v", err)
+	}
+	if exists := db.Redis.Exists(ctx, tokenKey).Val(); exists != 0 {
+		t.Fatalf("token still present after successful claim")
+	}
+	assertBalance(t, claimerID, "10")
+}
+
+func TestMemoryCreatorClaimCapSurvivesArchive(t *testing.T) {
+	setupMemory(t)
+	ctx := context.Background()
+	const creatorID, claimerID = 1001, 1002
+	seedUser(t, creatorID, "creator", "100")
+	seedUser(t, claimerID, "claimer", "0")
+	setSystemConfig(t, model.ConfigKeyRedEnvelopeCreatorClaimCap, "8")
+
+	var envelopeIDs []string
+	for range 2 {
+		redEnvelope, err := CreateRedEnvelope(ctx, CreateParams{
+			CreatorID:   creatorID,
+			Type:        model.RedEnvelopeTypeFixed,
+			TotalAmount: decimal.NewFromInt(5),
+			TotalCount:  1,
+		})
+		if err != nil {
+			t.Fatalf("create: %v", err)
+		}
+		envelopeIDs = append(envelopeIDs, strconv.FormatUint(redEnvelope.ID, 10))
+	}
+
+	if _, err := ClaimRedEnvelope(ctx, claimerID, envelopeIDs[0]); err != nil {
+		t.Fatalf("first claim: %v", err)
+	}
+
+	// 领取记录归档后累计金额仍以收入订单为准
+	if err := db.DB(ctx).Where("user_id = ?", claimerID).Delete(&model.RedEnvelopeClaim{}).Error; err != nil {
+		t.Fatalf("archive claims: %v", err)
+	}
+
+	if _, err := ClaimRedEnvelope(ctx, claimerID, envelopeIDs[1]); err == nil || err.Error() != CreatorClaimCapExceeded {
+		t.Fatalf("second claim err = %v, want %s", err, CreatorClaimCapExceeded)
+	}
+	assertBalance(t, claimerID, "5")
+}
+
+func TestMemoryRefundTaskHonoursPauseAndGrace(t *testing.T) {
+	setupMemory(t)
+	ctx := context.Background()
+	const creatorID = 1001
+	seedUser(t, creatorID, "creator", "100")
+
+	redEnvelope, err := CreateRedEnvelope(ctx, CreateParams{
+		CreatorID:   creatorID,
+		Type:        model.RedEnvelopeTypeFixed,
+		TotalAmount: decimal.NewFromInt(10),
+		TotalCount:  2,
+	})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if err := db.DB(ctx).Model(&model.RedEnvelope{}).Where("id = ?", redEnvelope.ID).
+		Update("expires_at", time.Now().Add(-30*time.Minute)).Error; err != nil {
+		t.Fatalf("expire envelope: %v", err)
+	}
+
+	// 暂停期间不退款
+	setSystemConfig(t, model.ConfigKeyRedEnvelopeRefundPaused, "1")
+	if err := HandleRefundExpiredRedEnvelopes(ctx, nil); err != nil {
+		t.Fatalf("refund task: %v", err)
+	}
+	assertBalance(t, creatorID, "90")
+
+	// 仍在宽限时间内不退款
+	setSystemConfig(t, model.ConfigKeyRedEnvelopeRefundPaused, "0")
+	setSystemConfig(t, model.ConfigKeyRedEnvelopeClaimGraceSeconds, "3600")
+	if err := HandleRefundExpiredRedEnvelopes(ctx, nil); err != nil {
+		t.Fatalf("refund task: %v", err)
+	}
+	assertBalance(t, creatorID, "90")
+
+	setSystemConfig(t, model.ConfigKeyRedEnvelopeClaimGraceSeconds, "0")
+	if err := HandleRefundExpiredRedEnvelopes(ctx, nil); err != nil {
+		t.Fatalf("refund task: %v", err)
+	}
+	assertBalance(t, creatorID, "100")
+}
+
+func TestMemoryCheckClaimTokenRestoresOnFailure(t *testing.T) {
+	setupMemory(t)
+	ctx := context.Background()
+	const redEnvelopeID, userID = 1, 2
+
+	token, err := issueClaimToken(ctx, redEnvelopeID, userID)
+	if err != nil {
+		t.Fatalf("issue token: %v", err)
+	}
+	restore, err := checkClaimToken(ctx, true, redEnvelopeID, userID, token)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// 领取进行中凭证已被取走，并发请求无法重复使用
+	if _, err := checkClaimToken(ctx, true, redEnvelopeID, userID, token); err == nil || err.Error() != ClaimTokenInvalid {
+		t.Fatalf("concurrent reuse err = %v, want %s", err, ClaimTokenInvalid)
+	}
+
+	// 领取失败后凭证按原剩余有效期恢复，可再次使用
+	restore()
+	key := db.PrefixedKey(fmt.Sprintf(ClaimTokenKeyFormat, redEnvelopeID, userID))
+	if ttl := db.Redis.PTTL(ctx, key).Val(); ttl <= 0 || ttl > ClaimTokenExpiration {
+		t.Fatalf("restored token ttl = %v, want within (0, %v]", ttl, ClaimTokenExpiration)
+	}
+	if _, err := checkClaimToken(ctx, true, redEnvelopeID, userID, token); err != nil {
+		t.Fatalf("retry after restore err = %v", err)
+	}
+
+	// 领取成功时不调用 restore，凭证保持已消费
+	if _, err := checkClaimToken(ctx, true, redEnvelopeID, userID, token); err == nil || err.Error() != ClaimTokenInvalid {
+		t.Fatalf("reuse after success err = %v, want %s", err, ClaimTokenInvalid)
+	}
+}
+
+// serveAs 以指定用户调用 handler，路径参数按 params 设置，返回响应
+func serveAs(t *testing.T, handler gin.HandlerFunc, user *model.User, method string, params gin.Params) *httptest.ResponseRecorder {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+	c.Request = httptest.NewRequest(method, "/", nil)
+	c.Params = params
+	c.Set(oauth.UserObjKey, user)
+	handler(c)
+	return recorder
+}
+
+func TestMemoryCreatorEndpointsRejectOtherUsers(t *testing.T) {
+	setupMemory(t)
+	const creatorID = 1001
+	seedUser(t, creatorID, "creator", "100")
+	redEnvelope, err := CreateRedEnvelope(context.Background(), CreateParams{
+		CreatorID:   creatorID,
+		Type:        model.RedEnvelopeTypeFixed,
+		TotalAmount: decimal.NewFromInt(10),
+		TotalCount:  2,
+	})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	ref := strconv.FormatUint(redEnvelope.ID, 10)
+	other := &model.User{ID: 1002}
+
+	cases := []struct {
+		name    string
+		handler gin.HandlerFunc
+		method  string
+		params  gin.Params
+	}{
+		{"events", ListEvents, http.MethodGet, gin.Params{{Key: "id", Value: ref}}},
+		{"channels", ListChannels, http.MethodGet, gin.Params{{Key: "id", Value: ref}}},
+		{"pause", Pause, http.MethodPost, gin.Params{{Key: "id", Value: ref}}},
+		{"resume", Resume, http.MethodPost, gin.Params{{Key: "id", Value: ref}}},
+		{"rotate", Rotate, http.MethodPost, gin.Params{{Key: "id", Value: ref}}},
+		{"can claim", CanClaim, http.MethodGet, gin.Params{{Key: "id", Value: ref}, {Key: "user_id", Value: "3"}}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := serveAs(t, tc.handler, other, tc.method, tc.params)
+			if recorder.Code != http.StatusForbidden {
+				t.Fatalf("status = %d, want %d; body %s", recorder.Code, http.StatusForbidden, recorder.Body)
+			}
+			var resp util.ResponseAny
+			if err := json.Unmarshal(recorder.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("decode body: %v", err)
+			}
+			if resp.ErrorMsg != NotEnvelopeCreator {
+				t.Fatalf("error_msg = %q, want %q", resp.ErrorMsg, NotEnvelopeCreator)
+			}
+		})
+	}
+
+	// 未知红包码返回 404
+	recorder := serveAs(t, ListEvents, other, http.MethodGet, gin.Params{{Key: "id", Value: "HB-NONE"}})
+	if recorder.Code != http.StatusNotFound {
+		t.Fatalf("unknown code: status = %d, want %d", recorder.Code, http.StatusNotFound)
+	}
+}
@@ -0,0 +1,71 @@
+/*
+Copyright 2025 linux.do
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package redenvelope
+
+import (
+	"context"
+	"log"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/noop"
+)
+
+// staleKeyCounter 残留 Redis key 清理数量，按 key 类型区分
+var staleKeyCounter metric.Int64Counter
+
+func init() {
+	meter := otel.Meter("github.com/linux-do/credit/redenvelope")
+
+	var err error
+	staleKeyCounter, err = meter.Int64Counter(
+		StaleKeyMetricName,
+		metric.WithDescription("红包残留 Redis key 清理数量"),
+		metric.WithUnit("{key}"),
+	)
+	if err != nil {
+		// 指标仅用于观测，初始化失败时退化为不记录
+		log.Printf("[RedEnvelope] init stale key counter failed, cleanup metrics disabled: %v", err)
+		staleKeyCounter = noop.Int64Counter{}
+	}
+
+	// 待领取总额在采集时读取，与创建红包时的总额上限校验共用缓存
+	if _, err := meter.Float64ObservableGauge(
+		LiabilityMetricName,
+		metric.WithDescription("全平台进行中红包待领取总额（不含测试红包）"),
+		metric.WithUnit("{credit}"),
+		metric.WithFloat64Callback(observeLiability),
+	); err != nil {
+		log.Printf("[RedEnvelope] init liability gauge failed, liability metric disabled: %v", err)
+	}
+}
+
+// observeLiability 采集全平台进行中红包待领取总额
+func observeLiability(ctx context.Context, observer metric.Float64Observer) error {
+	liability, err := getOutstandingLiability(ctx)
+	if err != nil {
+		return err
+	}
+	observer.Observe(liability.InexactFloat64())
+	return nil
+}
+
+// recordStaleKeysRemoved 记录某类残留 key 的清理数量
+func recordStaleKeysRemoved(ctx context.Context, family string, count int) {
+	staleKeyCounter.Add(ctx, int64(count), metric.WithAttributes(attribute.String("family", family)))
+}
@@ -17,14 +17,29 @@ limitations under the License.
 package redenvelope
 
 import (
+	"crypto/hmac"
+	"encoding/hex"
+	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-redis/redis_rate/v10"
 	"github.com/linux-do/credit/internal/common"
+	"github.com/linux-do/credit/internal/config"
+	"github.com/linux-do/credit/internal/db"
+	"github.com/linux-do/credit/internal/logger"
 	"github.com/linux-do/credit/internal/model"
 	"github.com/linux-do/credit/internal/util"
 )
 
+var detailRateLimiter *redis_rate.Limiter
+
+func init() {
+	detailRateLimiter = redis_rate.NewLimiter(db.Redis)
+}
+
 // CheckRedEnvelopeEnabled 检查红包功能是否启用的中间件
 func CheckRedEnvelopeEnabled() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -40,3 +55,130 @@ func CheckRedEnvelopeEnabled() gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// RequireWebhookSignature 验证外部系统回调的 HMAC 签名、时间戳与 nonce，防止伪造与重放
+func RequireWebhookSignature() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		secret := config.Config.RedEnvelope.WebhookSecret
+		if secret == "" {
+			c.AbortWithStatusJSON(http.StatusForbidden, util.Err(WebhookDisabled))
+			return
+		}
+
+		body, err := c.GetRawData()
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, util.Err(err.Error()))
+			return
+		}
+
+		// 校验签名：hex(HMAC-SHA256(secret, body))
+		signature, err := hex.DecodeString(c.GetHeader(WebhookSignatureHeader))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, util.Err(WebhookSignatureInvalid))
+			return
+		}
+		expected, _ := hex.DecodeString(signWebhookBody(secret, body))
+		if !hmac.Equal(signature, expected) {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, util.Err(WebhookSignatureInvalid))
+			return
+		}
+
+		var req WebhookClaimRequest
+		if err := binding.JSON.BindBody(body, &req); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, util.Err(err.Error()))
+			return
+		}
+
+		// 校验时间戳，拒绝过旧或超前的请求
+		requestTime := time.Unix(req.Timestamp, 0)
+		if time.Since(requestTime).Abs() > WebhookTimestampTolerance {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, util.Err(WebhookTimestampExpired))
+			return
+		}
+
+		// 记录 nonce，已存在则视为重放
+		nonceKey := db.PrefixedKey(fmt.Sprintf(WebhookNonceKeyFormat, req.Nonce))
+		ok, err := db.Redis.SetNX(c.Request.Context(), nonceKey, req.Timestamp, 2*WebhookTimestampTolerance).Result()
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, util.Err(err.Error()))
+			return
+		}
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusConflict, util.Err(WebhookNonceReplayed))
+			return
+		}
+
+		util.SetToContext(c, WebhookClaimRequestKey, &req)
+
+		c.Next()
+	}
+}
+
+// LimitDetailRate 按IP限制红包详情查询频率，并临时封禁频繁查询不存在红包的IP（Redis 未启用时跳过）
+func LimitDetailRate() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if db.Redis == nil {
+			c.Next()
+			return
+		}
+
+		ctx := c.Request.Context()
+		ip := c.ClientIP()
+
+		rateLimit, err := model.GetIntByKey(ctx, model.ConfigKeyRedEnvelopeDetailRateLimit)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, util.Err(err.Error()))
+			return
+		}
+		missLimit, err := model.GetIntByKey(ctx, model.ConfigKeyRedEnvelopeDetailMissLimit)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, util.Err(err.Error()))
+			return
+		}
+
+		blockedKey := db.PrefixedKey(fmt.Sprintf(DetailBlockedKeyFormat, ip))
+		if missLimit > 0 {
+			blocked, err := db.Redis.Exists(ctx, blockedKey).Result()
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusInternalServerError, util.Err(err.Error()))
+				return
+			}
+			if blocked > 0 {
+				c.AbortWithStatusJSON(http.StatusTooManyRequests, util.Err(DetailRateLimited))
+				return
+			}
+		}
+
+		if rateLimit > 0 {
+			res, err := detailRateLimiter.Allow(ctx, db.PrefixedKey(fmt.Sprintf(DetailRateLimitKeyFormat, ip)), redis_rate.PerMinute(rateLimit))
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusInternalServerError, util.Err(err.Error()))
+				return
+			}
+			if res.Allowed == 0 {
+				c.AbortWithStatusJSON(http.StatusTooManyRequests, util.Err(DetailRateLimited))
+				return
+			}
+		}
+
+		c.Next()
+
+		// 统计不存在红包的查询，超出上限后封禁该IP，防止枚举红包ID
+		if missLimit > 0 && c.Writer.Status() == http.StatusNotFound {
+			missKey := db.PrefixedKey(fmt.Sprintf(DetailMissKeyFormat, ip))
+			misses, err := db.Redis.Incr(ctx, missKey).Result()
+			if err != nil {
+				logger.WarnF(ctx, "统计红包详情未命中次数失败: %v", err)
+				return
+			}
+			if misses == 1 {
+				db.Redis.Expire(ctx, missKey, DetailMissWindow)
+			}
+			if misses > int64(missLimit) {
+				if err := db.Redis.Set(ctx, blockedKey, 1, DetailBlockDuration).Err(); err != nil {
+					logger.WarnF(ctx, "封禁频繁查询不存在红包的IP失败: %v", err)
+				}
+			}
+		}
+	}
+}
@@ -17,61 +17,300 @@ limitations under the License.
 package redenvelope
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
+	"slices"
 	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
 	"github.com/linux-do/credit/internal/apps/oauth"
-	"github.com/linux-do/credit/internal/common"
+	"github.com/linux-do/credit/internal/config"
 	"github.com/linux-do/credit/internal/db"
-	"github.com/linux-do/credit/internal/db/idgen"
+	"github.com/linux-do/credit/internal/logger"
 	"github.com/linux-do/credit/internal/model"
-	"github.com/linux-do/credit/internal/service"
 	"github.com/linux-do/credit/internal/util"
+	"github.com/redis/go-redis/v9"
 	"github.com/shopspring/decimal"
 	"gorm.io/gorm"
-	"gorm.io/gorm/clause"
 )
 
+// EnvelopeSpec 红包参数，创建红包与费用预估共用
+type EnvelopeSpec struct {
+	Type             model.RedEnvelopeType       `json:"type" binding:"required,oneof=fixed random hybrid"`
+	Split            model.RedEnvelopeSplit      `json:"split" binding:"omitempty,oneof=uniform trust_weighted"`
+	TotalAmount      decimal.Decimal             `json:"total_amount"`
+	PerAmount        decimal.Decimal             `json:"per_amount"`
+	BaseAmount       decimal.Decimal             `json:"base_amount"`
+	MinClaimAmount   decimal.Decimal             `json:"min_claim_amount"`
+	TotalCount       int                         `json:"total_count" binding:"required,min=1"`
+	Greeting         string                      `json:"greeting" binding:"max=100"`
+	GreetingHidden   bool                        `json:"greeting_hidden"`
+	ClaimMessage     string                      `json:"claim_message" binding:"max=100"`
+	MaxClaimsPerUser int                         `json:"max_claims_per_user" binding:"omitempty,min=1"`
+	RequireConfirm   bool                        `json:"require_confirm"`
+	FromEscrow       bool                        `json:"from_escrow"`
+	TargetWallet     string                      `json:"target_wallet" binding:"max=32"`
+	Visibility       model.RedEnvelopeVisibility `json:"visibility" binding:"omitempty,oneof=public unlisted private"`
+	AllowedUsernames []string                    `json:"allowed_usernames" binding:"max=200,dive,max=64"`
+	OpaqueLink       bool                        `json:"opaque_link"`
+	SignedReceipt    bool                        `json:"signed_receipt"`
+	TestMode         bool                        `json:"test_mode"`
+	// ReservedAllocations 为指定用户名预留的领取金额，每人占用一个名额
+	ReservedAllocations map[string]decimal.Decimal `json:"reserved_allocations" binding:"max=100"`
+}
+
 // CreateRequest 创建红包请求
 type CreateRequest struct {
-	Type        model.RedEnvelopeType `json:"type" binding:"required,oneof=fixed random"`
-	TotalAmount decimal.Decimal       `json:"total_amount" binding:"required"`
-	TotalCount  int                   `json:"total_count" binding:"required,min=1"`
-	Greeting    string                `json:"greeting" binding:"max=100"`
-	PayKey      string                `json:"pay_key" binding:"required,max=10"`
+	EnvelopeSpec
+	PayKey string `json:"pay_key" binding:"required,max=10"`
+}
+
+// PreviewRequest 红包费用预估请求
+type PreviewRequest struct {
+	Envelopes []EnvelopeSpec `json:"envelopes" binding:"required,min=1,max=20,dive"`
+}
+
+// PreviewItem 单个红包的费用明细
+// WorstCase 为无人领取、过期全额退回红包金额的情形，BestCase 为全部领完、不产生退款的情形，手续费与奖池抽成均不退回
+// 红包不可创建时两者均为零
+type PreviewItem struct {
+	TotalAmount    decimal.Decimal  `json:"total_amount"`
+	PerAmount      *decimal.Decimal `json:"per_amount,omitempty"`
+	FeeAmount      decimal.Decimal  `json:"fee_amount"`
+	JackpotAmount  decimal.Decimal  `json:"jackpot_amount"`
+	TotalDeduction decimal.Decimal  `json:"total_deduction"`
+	WorstCase      CostProjection   `json:"worst_case"`
+	BestCase       CostProjection   `json:"best_case"`
+	Error          string           `json:"error,omitempty"`
+	Warnings       []string         `json:"warnings,omitempty"`
+}
+
+// CostProjection 红包结束后的退款及创建者实际支出预估
+type CostProjection struct {
+	RefundAmount decimal.Decimal `json:"refund_amount"`
+	NetCost      decimal.Decimal `json:"net_cost"`
 }
 
-// CreateResponse 创建红包响应
+// PreviewResponse 红包费用预估响应，合计仅统计可创建的红包
+type PreviewResponse struct {
+	Envelopes   []PreviewItem   `json:"envelopes"`
+	TotalAmount decimal.Decimal `json:"total_amount"`
+	TotalFee    decimal.Decimal `json:"total_fee"`
+	GrandTotal  decimal.Decimal `json:"grand_total"`
+	Valid       bool            `json:"valid"`
+}
+
+// CreateResponse 创建红包响应，Warnings 为创建时的提示（如个数超过可领取名单）
 type CreateResponse struct {
-	ID uint64 `json:"id,string"`
+	ID         uint64   `json:"id,string"`
+	TotalCount int      `json:"total_count"`
+	Warnings   []string `json:"warnings,omitempty"`
+	// LinkToken 不透明领取链接凭证，仅在使用 opaque_link 创建时返回且只返回这一次
+	LinkToken string `json:"link_token,omitempty"`
+	Link      string `json:"link,omitempty"`
 }
 
 // ClaimRequest 领取红包请求
+// ID 与 Code 二选一，Code 可为红包码或不透明领取链接凭证
 type ClaimRequest struct {
+	ID                   uint64                  `json:"id,string" binding:"required_without=Code"`
+	Code                 string                  `json:"code" binding:"max=64"`
+	ClaimToken           string                  `json:"claim_token" binding:"max=64"`
+	Currency             string                  `json:"currency" binding:"max=8"`
+	Channel              string                  `json:"channel" binding:"max=32"`
+	ForwardToNewEnvelope *ForwardEnvelopeRequest `json:"forward_to_new_envelope"`
+}
+
+// ForwardEnvelopeRequest 领取后以领取金额创建新红包的参数，总额即领取金额
+type ForwardEnvelopeRequest struct {
+	Type           model.RedEnvelopeType `json:"type" binding:"required,oneof=fixed random"`
+	TotalCount     int                   `json:"total_count" binding:"required,min=1"`
+	Greeting       string                `json:"greeting" binding:"max=100"`
+	GreetingHidden bool                  `json:"greeting_hidden"`
+	PayKey         string                `json:"pay_key" binding:"required,max=10"`
+}
+
+// WebhookClaimRequest 外部系统回调领取红包请求
+type WebhookClaimRequest struct {
+	RedEnvelopeID uint64 `json:"red_envelope_id,string" binding:"required"`
+	UserID        uint64 `json:"user_id,string" binding:"required"`
+	Nonce         string `json:"nonce" binding:"required,max=64"`
+	Timestamp     int64  `json:"timestamp" binding:"required"`
+}
+
+// DisplayAmount 按展示货币换算后的金额，仅用于展示，以积分金额为准
+type DisplayAmount struct {
+	Currency  string          `json:"currency"`
+	Amount    decimal.Decimal `json:"amount"`
+	Formatted string          `json:"formatted"`
+}
+
+// ReserveRequest 预约红包名额请求
+type ReserveRequest struct {
 	ID uint64 `json:"id,string" binding:"required"`
 }
 
-// ClaimResponse 领取红包响应
+// ReserveResponse 预约红包名额响应
+type ReserveResponse struct {
+	ReservationToken string    `json:"reservation_token"`
+	ExpiresAt        time.Time `json:"expires_at"`
+}
+
+// ConfirmRequest 确认领取红包请求
+type ConfirmRequest struct {
+	ID               uint64 `json:"id,string" binding:"required"`
+	ReservationToken string `json:"reservation_token" binding:"required,max=64"`
+	Currency         string `json:"currency" binding:"max=8"`
+}
+
+// ClaimResponse 领取红包响应，AvailableBalance 为与领取同一事务内读取的领取者可用余额（含转发扣款，计入专用钱包的金额不计入）
 type ClaimResponse struct {
-	Amount      decimal.Decimal    `json:"amount"`
-	RedEnvelope *model.RedEnvelope `json:"red_envelope"`
+	Amount               decimal.Decimal    `json:"amount"`
+	AvailableBalance     decimal.Decimal    `json:"available_balance"`
+	DisplayAmount        *DisplayAmount     `json:"display_amount,omitempty"`
+	ClaimMessage         string             `json:"claim_message,omitempty"`
+	RedEnvelope          *model.RedEnvelope `json:"red_envelope"`
+	ForwardedRedEnvelope *model.RedEnvelope `json:"forwarded_red_envelope,omitempty"`
+	Receipt              string             `json:"receipt,omitempty"`
+}
+
+// ClaimReceipt 领取回执的 JWT 载荷，证明用户在指定时间领取了红包中的指定金额
+type ClaimReceipt struct {
+	ID            string `json:"jti"`
+	Issuer        string `json:"iss"`
+	UserID        string `json:"sub"`
+	Username      string `json:"username"`
+	RedEnvelopeID string `json:"red_envelope_id"`
+	Amount        string `json:"amount"`
+	ClaimedAt     int64  `json:"iat"`
+}
+
+// VerifyReceiptRequest 校验领取回执请求
+type VerifyReceiptRequest struct {
+	Receipt string `json:"receipt" binding:"required,max=2048"`
+}
+
+// ClaimsClosedResponse 暂停领取时段内领取红包的错误数据，NextOpenAt 为空表示暂无开放时间
+type ClaimsClosedResponse struct {
+	NextOpenAt *time.Time `json:"next_open_at,omitempty"`
+}
+
+// EligibilityResponse 指定用户能否领取红包，不可领取时 Reason 为原因
+type EligibilityResponse struct {
+	UserID   uint64 `json:"user_id,string"`
+	Username string `json:"username"`
+	Eligible bool   `json:"eligible"`
+	Reason   string `json:"reason,omitempty"`
+}
+
+// ConstraintsResponse 创建红包的校验规则，供客户端表单校验使用
+type ConstraintsResponse struct {
+	MinAmount             decimal.Decimal `json:"min_amount"`               // 红包最低金额
+	MaxAmount             decimal.Decimal `json:"max_amount"`               // 单个红包的最大金额
+	AmountDecimalPlaces   int             `json:"amount_decimal_places"`    // 金额最大小数位数
+	MinPerEnvelope        decimal.Decimal `json:"min_per_envelope"`         // 每个红包的最低平均金额
+	MinCount              int             `json:"min_count"`                // 红包个数下限
+	MaxCount              int             `json:"max_count"`                // 红包个数上限
+	DailyLimit            int             `json:"daily_limit"`              // 每日发红包的个数限制
+	FeeRate               decimal.Decimal `json:"fee_rate"`                 // 手续费率
+	JackpotRate           decimal.Decimal `json:"jackpot_rate"`             // 奖池抽成比例
+	Denomination          decimal.Decimal `json:"denomination"`             // 领取金额取整面额
+	MaxGreetingLength     int             `json:"max_greeting_length"`      // 祝福语最大字符数
+	MaxGreetingGraphemes  int             `json:"max_greeting_graphemes"`   // 祝福语按字形簇计算的长度上限（0表示不限制）
+	MaxClaimMessageLength int             `json:"max_claim_message_length"` // 领取成功提示语最大字符数
+	ExpiryHours           int             `json:"expiry_hours"`             // 红包有效期（小时）
+}
+
+// ClaimBatchRequest 一次领取多个红包的请求
+type ClaimBatchRequest struct {
+	Items []ClaimBatchEntry `json:"items" binding:"required,min=1,max=20,dive"`
+}
+
+// ClaimBatchEntry 批量领取中的单个红包，Code 可为红包码或红包ID
+type ClaimBatchEntry struct {
+	Code       string `json:"code" binding:"required,max=32"`
+	ClaimToken string `json:"claim_token" binding:"max=64"`
+}
+
+// ClaimBatchItem 批量领取中单个红包的结果，领取失败时 Error 为失败原因
+type ClaimBatchItem struct {
+	Code          string          `json:"code"`
+	RedEnvelopeID uint64          `json:"red_envelope_id,string,omitempty"`
+	Amount        decimal.Decimal `json:"amount"`
+	Error         string          `json:"error,omitempty"`
+}
+
+// BulkClaimRequest 批量代领红包请求，同一用户名重复出现时按每人领取次数上限多次领取
+type BulkClaimRequest struct {
+	Usernames []string `json:"usernames" binding:"required,min=1,max=100,dive,required,max=64"`
+}
+
+// BulkClaimItem 批量代领中单个用户的领取结果
+type BulkClaimItem struct {
+	UserID   uint64          `json:"user_id,string"`
+	Username string          `json:"username"`
+	Amount   decimal.Decimal `json:"amount"`
+}
+
+// ClaimStreamEvent 红包实时领取动态事件，Event 同时作为 SSE 事件名
+type ClaimStreamEvent struct {
+	Event           string                  `json:"event"`
+	UserID          uint64                  `json:"user_id,string,omitempty"`
+	Username        string                  `json:"username,omitempty"`
+	AvatarURL       string                  `json:"avatar_url,omitempty"`
+	Amount          *decimal.Decimal        `json:"amount,omitempty"`
+	RemainingCount  int                     `json:"remaining_count"`
+	RemainingAmount decimal.Decimal         `json:"remaining_amount"`
+	Status          model.RedEnvelopeStatus `json:"status"`
+}
+
+// ShareMeta 红包分享卡片元数据，供前端或链接预览生成分享卡片
+type ShareMeta struct {
+	Title        string `json:"title"`
+	Description  string `json:"description"`
+	ClaimedCount int    `json:"claimed_count"`
+	TotalCount   int    `json:"total_count"`
 }
 
 // DetailResponse 红包详情响应
 type DetailResponse struct {
-	RedEnvelope *model.RedEnvelope       `json:"red_envelope"`
-	Claims      []model.RedEnvelopeClaim `json:"claims"`
-	UserClaimed *model.RedEnvelopeClaim  `json:"user_claimed,omitempty"`
+	RedEnvelope          *model.RedEnvelope      `json:"red_envelope"`
+	ViewerRole           string                  `json:"viewer_role"`
+	Claims               []DetailClaim           `json:"claims"`
+	UserClaimed          *model.RedEnvelopeClaim `json:"user_claimed,omitempty"`
+	ClaimToken           string                  `json:"claim_token,omitempty"`
+	ClaimsArchived       bool                    `json:"claims_archived"`
+	ClaimsTruncated      bool                    `json:"claims_truncated"`
+	NextCursor           string                  `json:"next_cursor,omitempty"`
+	ShareMeta            ShareMeta               `json:"share_meta"`
+	DisplayTotalAmount   *DisplayAmount          `json:"display_total_amount,omitempty"`
+	DisplayClaimedAmount *DisplayAmount          `json:"display_claimed_amount,omitempty"`
+}
+
+// DetailClaimsRequest 红包详情中领取记录的排序及分页参数，默认按领取时间倒序，cursor 为上一页返回的 next_cursor
+// PinMine 为 true 时查看者最近一次领取置顶于首页，其余记录按所选排序返回
+type DetailClaimsRequest struct {
+	Sort    string `form:"sort" binding:"omitempty,oneof=time amount"`
+	Order   string `form:"order" binding:"omitempty,oneof=asc desc"`
+	Cursor  string `form:"cursor" binding:"max=128"`
+	PinMine bool   `form:"pin_mine"`
 }
 
-// ListRequest 红包列表请求
+// DetailClaim 红包详情中的领取记录，无权查看金额时不返回 amount
+type DetailClaim struct {
+	model.RedEnvelopeClaim
+	Amount *decimal.Decimal `json:"amount,omitempty"`
+}
+
+// ListRequest 红包列表请求，未指定页码及每页数量时使用默认值
 type ListRequest struct {
-	Page     int    `json:"page" binding:"required,min=1"`
-	PageSize int    `json:"page_size" binding:"required,min=1,max=100"`
+	Page     int    `json:"page" binding:"omitempty,min=1"`
+	PageSize int    `json:"page_size" binding:"omitempty,min=1,max=100"`
 	Type     string `json:"type" binding:"omitempty,oneof=sent received"`
 }
 
@@ -83,6 +322,199 @@ type ListResponse struct {
 	RedEnvelopes []model.RedEnvelope `json:"red_envelopes"`
 }
 
+// SearchRequest 红包搜索请求
+type SearchRequest struct {
+	Page     int    `json:"page" binding:"required,min=1"`
+	PageSize int    `json:"page_size" binding:"required,min=1,max=100"`
+	Keyword  string `json:"keyword" binding:"max=100"`
+}
+
+// SearchResult 红包搜索结果，Direction 表示红包为当前用户发出（sent）或领取（received）
+type SearchResult struct {
+	model.RedEnvelope
+	Direction string `json:"direction"`
+}
+
+// SearchResponse 红包搜索响应
+type SearchResponse struct {
+	Total        int64          `json:"total"`
+	Page         int            `json:"page"`
+	PageSize     int            `json:"page_size"`
+	RedEnvelopes []SearchResult `json:"red_envelopes"`
+}
+
+// DeepLinkClaimRequest 通过一键领取凭证领取红包请求
+type DeepLinkClaimRequest struct {
+	ID       uint64 `json:"id,string" binding:"required"`
+	Token    string `json:"token" binding:"required,max=256"`
+	Currency string `json:"currency" binding:"max=8"`
+}
+
+// DeepLinkResponse 签发的一键领取凭证，link 为嵌入通知的前端领取地址
+type DeepLinkResponse struct {
+	Token     string    `json:"token"`
+	Link      string    `json:"link"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// ReturnClaimRequest 退回红包请求
+type ReturnClaimRequest struct {
+	ClaimID uint64 `json:"claim_id,string" binding:"required"`
+	PayKey  string `json:"pay_key" binding:"required,max=10"`
+}
+
+// PublicListRequest 公开红包列表请求
+type PublicListRequest struct {
+	Page     int `form:"page" binding:"required,min=1"`
+	PageSize int `form:"page_size" binding:"required,min=1,max=100"`
+}
+
+// RefundsRequest 红包退款记录请求
+type RefundsRequest struct {
+	Page      int        `form:"page" binding:"required,min=1"`
+	PageSize  int        `form:"page_size" binding:"required,min=1,max=100"`
+	StartTime *time.Time `form:"start_time" binding:"omitempty"`
+	EndTime   *time.Time `form:"end_time" binding:"omitempty,gtfield=StartTime"`
+}
+
+// RefundedEnvelope 已退款的红包及退款金额
+type RefundedEnvelope struct {
+	ID             uint64                  `json:"id,string"`
+	Type           model.RedEnvelopeType   `json:"type"`
+	Greeting       string                  `json:"greeting"`
+	TotalAmount    decimal.Decimal         `json:"total_amount"`
+	TotalCount     int                     `json:"total_count"`
+	Status         model.RedEnvelopeStatus `json:"status"`
+	ExpiresAt      time.Time               `json:"expires_at"`
+	CreatedAt      time.Time               `json:"created_at"`
+	RefundedAmount decimal.Decimal         `json:"refunded_amount"`
+	RefundedAt     time.Time               `json:"refunded_at"`
+}
+
+// RefundsResponse 红包退款记录响应
+type RefundsResponse struct {
+	Total     int64              `json:"total"`
+	Page      int                `json:"page"`
+	PageSize  int                `json:"page_size"`
+	Envelopes []RefundedEnvelope `json:"envelopes"`
+}
+
+// MyClaimsRequest 我的领取记录请求
+type MyClaimsRequest struct {
+	Page      int        `form:"page" binding:"required,min=1"`
+	PageSize  int        `form:"page_size" binding:"required,min=1,max=100"`
+	StartTime *time.Time `form:"start_time" binding:"omitempty"`
+	EndTime   *time.Time `form:"end_time" binding:"omitempty,gtfield=StartTime"`
+}
+
+// MyClaim 我的领取记录
+type MyClaim struct {
+	ID              uint64          `json:"id,string"`
+	RedEnvelopeID   uint64          `json:"red_envelope_id,string"`
+	Greeting        string          `json:"greeting"`
+	ClaimMessage    string          `json:"claim_message,omitempty"`
+	Amount          decimal.Decimal `json:"amount"`
+	Sequence        int             `json:"sequence"`
+	ClaimedAt       time.Time       `json:"claimed_at"`
+	CreatorID       uint64          `json:"creator_id,string"`
+	CreatorUsername string          `json:"creator_username"`
+}
+
+// MyClaimsResponse 我的领取记录响应
+type MyClaimsResponse struct {
+	Total    int64     `json:"total"`
+	Page     int       `json:"page"`
+	PageSize int       `json:"page_size"`
+	Claims   []MyClaim `json:"claims"`
+}
+
+// ResultClaim 红包结果中的领取记录
+type ResultClaim struct {
+	model.RedEnvelopeClaim
+	Amount   *decimal.Decimal `json:"amount,omitempty" gorm:"-"`
+	Luckiest bool             `json:"luckiest"`
+}
+
+// ResultsResponse 红包领取结果响应
+type ResultsResponse struct {
+	ViewerRole     string          `json:"viewer_role"`
+	Finished       bool            `json:"finished"`
+	ClaimsArchived bool            `json:"claims_archived"`
+	TotalCount     int             `json:"total_count"`
+	TotalAmount    decimal.Decimal `json:"total_amount"`
+	ClaimedCount   int             `json:"claimed_count"`
+	ClaimedAmount  decimal.Decimal `json:"claimed_amount"`
+	Fairness       *Fairness       `json:"fairness,omitempty"`
+	Claims         []ResultClaim   `json:"claims"`
+}
+
+// Fairness 已结束的非固定金额红包的分配均衡度，Gini 为领取金额的基尼系数（0 表示完全平均，越接近 1 越悬殊），
+// MaxMinRatio 为最大与最小领取金额之比
+type Fairness struct {
+	Gini        decimal.Decimal `json:"gini"`
+	MaxMinRatio decimal.Decimal `json:"max_min_ratio"`
+}
+
+// ChannelStat 按领取渠道汇总的领取情况，Channel 为空表示未标注渠道
+type ChannelStat struct {
+	Channel     string          `json:"channel"`
+	ClaimCount  int64           `json:"claim_count"`
+	TotalAmount decimal.Decimal `json:"total_amount"`
+}
+
+// EscrowRequest 红包托管资金存取请求
+type EscrowRequest struct {
+	Amount decimal.Decimal `json:"amount" binding:"required"`
+	PayKey string          `json:"pay_key" binding:"required,max=10"`
+}
+
+// WalletTransferRequest 专用钱包存取请求
+type WalletTransferRequest struct {
+	Wallet string          `json:"wallet" binding:"required,max=32"`
+	Amount decimal.Decimal `json:"amount" binding:"required"`
+	PayKey string          `json:"pay_key" binding:"required,max=10"`
+}
+
+// CreateRecurringRequest 创建定期发放计划请求，Cron 为标准 5 段表达式（按服务器时区），EndsAt 为空表示不设结束时间
+type CreateRecurringRequest struct {
+	Spec   EnvelopeSpec `json:"spec" binding:"required"`
+	Cron   string       `json:"cron" binding:"required,max=64"`
+	EndsAt *time.Time   `json:"ends_at"`
+	PayKey string       `json:"pay_key" binding:"required,max=10"`
+}
+
+// UpdateRecurringRequest 修改定期发放计划请求，字段为空表示不修改，NoEndDate 为 true 时清除结束时间
+type UpdateRecurringRequest struct {
+	Enabled   *bool      `json:"enabled"`
+	EndsAt    *time.Time `json:"ends_at"`
+	NoEndDate bool       `json:"no_end_date"`
+}
+
+// RecurringEnvelopeItem 定期发放计划及其红包参数模板
+type RecurringEnvelopeItem struct {
+	model.RecurringEnvelope
+	Spec EnvelopeSpec `json:"spec"`
+}
+
+// EscrowResponse 红包托管余额响应
+type EscrowResponse struct {
+	Balance decimal.Decimal `json:"balance"`
+}
+
+// LockedResponse 进行中红包锁定金额响应
+type LockedResponse struct {
+	ActiveCount  int64           `json:"active_count"`
+	LockedAmount decimal.Decimal `json:"locked_amount"`
+}
+
+// RotateResponse 重新生成分享链接响应，使用不透明链接的红包返回新的链接凭证，否则返回新的红包码
+type RotateResponse struct {
+	ID        uint64 `json:"id,string"`
+	Code      string `json:"code,omitempty"`
+	LinkToken string `json:"link_token,omitempty"`
+	Link      string `json:"link"`
+}
+
 // Create 创建红包
 // @Tags redenvelope
 // @Accept json
@@ -92,313 +524,309 @@ type ListResponse struct {
 // @Router /api/v1/redenvelope/create [post]
 func Create(c *gin.Context) {
 	var req CreateRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+	if err := c.ShouldBindBodyWith(&req, binding.JSON); err != nil {
+		// 金额字段无法解析（如 NaN、非数字字符串）时返回明确的字段错误
+		if fields := decimalFieldErrors(c, "", "total_amount", "per_amount", "base_amount", "min_claim_amount"); fields != nil {
+			c.JSON(http.StatusBadRequest, util.ErrFields(InvalidAmountFormat, fields))
+			return
+		}
+		handleBindError(c, err)
 		return
 	}
 
-	if err := util.ValidateAmount(req.TotalAmount); err != nil {
-		c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+	// 按单个金额发红包时由服务端计算红包总额
+	if err := resolvePerAmount(&req.EnvelopeSpec); err != nil {
+		c.JSON(http.StatusBadRequest, util.ErrFields(err.Error(), map[string]string{"per_amount": err.Error()}))
 		return
 	}
 
-	// 检查红包最低金额限制（1 LDC）
-	if req.TotalAmount.LessThan(decimal.NewFromInt(1)) {
-		c.JSON(http.StatusBadRequest, util.Err(common.RedEnvelopeMinAmountRequired))
+	currentUser, _ := util.GetFromContext[*model.User](c, oauth.UserObjKey)
+
+	if !verifyPayKey(c, currentUser, req.PayKey) {
 		return
 	}
 
-	// 检查单个红包最大金额限制
-	maxAmount, err := model.GetDecimalByKey(c.Request.Context(), model.ConfigKeyRedEnvelopeMaxAmount, 2)
+	// 同一用户的创建请求串行处理，拒绝短时间内的重复提交
+	release, err := acquireCreateLock(c.Request.Context(), currentUser.ID, createFingerprint(&req.EnvelopeSpec))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
-		return
-	}
-	if req.TotalAmount.GreaterThan(maxAmount) {
-		c.JSON(http.StatusBadRequest, util.Err(common.RedEnvelopeAmountExceeded))
+		handleCreateError(c, err)
 		return
 	}
 
-	// 检查红包最大领取人数限制
-	maxRecipients, err := model.GetIntByKey(c.Request.Context(), model.ConfigKeyRedEnvelopeMaxRecipients)
+	redEnvelope, err := CreateRedEnvelope(c.Request.Context(), req.createParams(currentUser.ID))
+	release(err == nil)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		handleCreateError(c, err)
 		return
 	}
-	if req.TotalCount > maxRecipients {
-		c.JSON(http.StatusBadRequest, util.Err(common.RedEnvelopeRecipientsExceeded))
-		return
+
+	resp := CreateResponse{
+		ID:         redEnvelope.ID,
+		TotalCount: redEnvelope.TotalCount,
+		Warnings:   redEnvelope.Warnings,
+	}
+	if redEnvelope.LinkToken != "" {
+		resp.LinkToken = redEnvelope.LinkToken
+		resp.Link = fmt.Sprintf("%s/redenvelope/%s", config.Config.App.FrontendURL, redEnvelope.LinkToken)
 	}
+	c.JSON(http.StatusOK, util.OK(resp))
+}
 
-	// 检查每个红包平均金额不能小于0.01（避免前面领取者获得0 LDC）
-	perAmount := req.TotalAmount.Div(decimal.NewFromInt(int64(req.TotalCount)))
-	if perAmount.LessThan(decimal.NewFromFloat(0.01)) {
-		c.JSON(http.StatusBadRequest, util.Err(AmountTooSmall))
+// Preview 预估批量创建红包的费用，按创建流程校验但不扣款、不创建红包
+// @Tags redenvelope
+// @Accept json
+// @Produce json
+// @Param request body PreviewRequest true "红包费用预估请求"
+// @Success 200 {object} util.Response[PreviewResponse]
+// @Router /api/v1/redenvelope/preview [post]
+func Preview(c *gin.Context) {
+	var req PreviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleBindError(c, err)
 		return
 	}
 
 	currentUser, _ := util.GetFromContext[*model.User](c, oauth.UserObjKey)
 
-	// 检查每日红包发送数量限制
-	dailyLimit, err := model.GetIntByKey(c.Request.Context(), model.ConfigKeyRedEnvelopeDailyLimit)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
-		return
+	paramsList := make([]CreateParams, len(req.Envelopes))
+	for i := range req.Envelopes {
+		if err := resolvePerAmount(&req.Envelopes[i]); err != nil {
+			field := fmt.Sprintf("envelopes[%d].per_amount", i)
+			c.JSON(http.StatusBadRequest, util.ErrFields(err.Error(), map[string]string{field: err.Error()}))
+			return
+		}
+		paramsList[i] = req.Envelopes[i].createParams(currentUser.ID)
 	}
 
-	// 查询今日已发送的红包数量
-	var todayCount int64
-	today := time.Now().Truncate(24 * time.Hour)
-	if err := db.DB(c.Request.Context()).Model(&model.RedEnvelope{}).
-		Where("creator_id = ? AND created_at >= ?", currentUser.ID, today).
-		Count(&todayCount).Error; err != nil {
+	costs, err := previewCreateCosts(c.Request.Context(), paramsList)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
 		return
 	}
 
-	if todayCount >= int64(dailyLimit) {
-		c.JSON(http.StatusBadRequest, util.Err(common.RedEnvelopeDailyLimitExceeded))
-		return
+	resp := PreviewResponse{Envelopes: make([]PreviewItem, len(costs)), Valid: true}
+	for i, cost := range costs {
+		spec := req.Envelopes[i]
+		item := PreviewItem{
+			TotalAmount:    spec.TotalAmount,
+			FeeAmount:      cost.FeeAmount,
+			JackpotAmount:  cost.JackpotAmount,
+			TotalDeduction: cost.TotalDeduction,
+			Warnings:       cost.Warnings,
+		}
+		if spec.Type == model.RedEnvelopeTypeFixed && spec.TotalCount > 0 {
+			perAmount := spec.TotalAmount.Div(decimal.NewFromInt(int64(spec.TotalCount))).Round(util.AmountPrecision())
+			item.PerAmount = &perAmount
+		}
+		if cost.Err != nil {
+			item.Error = cost.Err.Error()
+			resp.Valid = false
+		} else {
+			item.WorstCase = CostProjection{RefundAmount: spec.TotalAmount, NetCost: cost.TotalDeduction.Sub(spec.TotalAmount)}
+			item.BestCase = CostProjection{RefundAmount: decimal.Zero, NetCost: cost.TotalDeduction}
+			resp.TotalAmount = resp.TotalAmount.Add(spec.TotalAmount)
+			resp.TotalFee = resp.TotalFee.Add(cost.FeeAmount)
+			resp.GrandTotal = resp.GrandTotal.Add(cost.TotalDeduction)
+		}
+		resp.Envelopes[i] = item
 	}
 
-	if !currentUser.VerifyPayKey(req.PayKey) {
-		c.JSON(http.StatusBadRequest, util.Err(common.PayKeyIncorrect))
+	c.JSON(http.StatusOK, util.OK(resp))
+}
+
+// Claim 领取红包
+// @Tags redenvelope
+// @Accept json
+// @Produce json
+// @Param request body ClaimRequest true "领取红包请求"
+// @Success 200 {object} util.ResponseAny
+// @Router /api/v1/redenvelope/claim [post]
+func Claim(c *gin.Context) {
+	var req ClaimRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleBindError(c, err)
 		return
 	}
 
-	// 获取红包手续费率并计算手续费
-	feeRate, err := model.GetDecimalByKey(c.Request.Context(), model.ConfigKeyRedEnvelopeFeeRate, 2)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
-		return
+	// 未指定红包ID时按红包码领取
+	code := req.Code
+	if req.ID != 0 {
+		code = strconv.FormatUint(req.ID, 10)
 	}
 
-	// 计算手续费（红包金额 * 费率）
-	feeAmount := req.TotalAmount.Mul(feeRate).Round(2)
+	// 请求体未指定渠道时使用领取链接中的 channel 参数
+	device := newClaimDevice(c)
+	if req.Channel != "" {
+		channel, ok := normalizeChannel(req.Channel)
+		if !ok {
+			c.JSON(http.StatusBadRequest, util.ErrFields(InvalidClaimChannel, map[string]string{"channel": InvalidClaimChannel}))
+			return
+		}
+		device.Channel = channel
+	}
 
-	// 总扣款金额 = 红包金额 + 手续费
-	totalDeduction := req.TotalAmount.Add(feeAmount)
+	currentUser, _ := util.GetFromContext[*model.User](c, oauth.UserObjKey)
 
-	// 提前检查余额，避免不必要的事务
-	if currentUser.AvailableBalance.LessThan(totalDeduction) {
-		c.JSON(http.StatusBadRequest, util.Err(common.InsufficientBalance))
+	// 转发会以领取者身份创建红包，需同样校验支付密钥
+	forward := req.ForwardToNewEnvelope
+	if forward != nil && !verifyPayKey(c, currentUser, forward.PayKey) {
 		return
 	}
 
-	var redEnvelope model.RedEnvelope
+	var forwardParams *CreateParams
+	if forward != nil {
+		forwardParams = &CreateParams{
+			Type:           forward.Type,
+			TotalCount:     forward.TotalCount,
+			Greeting:       forward.Greeting,
+			GreetingHidden: forward.GreetingHidden,
+		}
+	}
 
-	if err := db.DB(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
-		// 扣减发送者余额并更新total_payment
-		if err := service.UpdateBalance(tx, service.BalanceUpdateOptions{
-			UserID:       currentUser.ID,
-			Amount:       totalDeduction,
-			Operation:    service.BalanceDeduct,
-			TotalField:   "total_payment",
-			CheckBalance: true,
-		}); err != nil {
-			return err
-		}
-
-		// 创建红包
-		redEnvelope = model.RedEnvelope{
-			ID:              idgen.NextUint64ID(),
-			CreatorID:       currentUser.ID,
-			Type:            req.Type,
-			TotalAmount:     req.TotalAmount,
-			RemainingAmount: req.TotalAmount,
-			TotalCount:      req.TotalCount,
-			RemainingCount:  req.TotalCount,
-			Greeting:        req.Greeting,
-			Status:          model.RedEnvelopeStatusActive,
-			ExpiresAt:       time.Now().Add(24 * time.Hour),
-		}
-
-		if err := tx.Create(&redEnvelope).Error; err != nil {
-			return err
-		}
-
-		// 创建订单记录（红包支出）
-		remarkMsg := fmt.Sprintf("创建红包，共%d个", req.TotalCount)
-		if feeAmount.GreaterThan(decimal.Zero) {
-			remarkMsg = fmt.Sprintf("%s，手续费: %s", remarkMsg, feeAmount.String())
-		}
-		if req.Greeting != "" {
-			remarkMsg = fmt.Sprintf("%s，祝福语: %s", remarkMsg, req.Greeting)
-		}
-
-		order := model.Order{
-			OrderName:   "红包支出",
-			PayerUserID: currentUser.ID,
-			PayeeUserID: 0,
-			Amount:      totalDeduction,
-			Status:      model.OrderStatusSuccess,
-			Type:        model.OrderTypeRedEnvelopeSend,
-			Remark:      remarkMsg,
-			TradeTime:   time.Now(),
-			ExpiresAt:   time.Now().Add(24 * time.Hour),
-		}
-
-		return tx.Create(&order).Error
-	}); err != nil {
-		if err.Error() == common.InsufficientBalance {
-			c.JSON(http.StatusBadRequest, util.Err(common.InsufficientBalance))
-		} else {
-			c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+	// 开启一次性领取凭证时由服务层校验，防止重复提交
+	resp, err := claimByCode(c.Request.Context(), currentUser.ID, code, req.ClaimToken, forwardParams, device)
+	if err != nil {
+		switch err.Error() {
+		case InvalidRedEnvelopeID:
+			c.JSON(http.StatusBadRequest, util.ErrFields(err.Error(), map[string]string{"code": err.Error()}))
+		case ClaimTokenInvalid:
+			c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+		default:
+			handleClaimError(c, err)
 		}
 		return
 	}
 
-	c.JSON(http.StatusOK, util.OK(CreateResponse{
-		ID: redEnvelope.ID,
-	}))
+	// 展示货币换算失败不影响领取结果
+	if resp.DisplayAmount, err = convertDisplayAmount(c.Request.Context(), resp.Amount, req.Currency); err != nil {
+		logger.WarnF(c.Request.Context(), "红包金额展示货币换算失败: %v", err)
+	}
+
+	c.JSON(http.StatusOK, util.OK(resp))
 }
 
-// Claim 领取红包
+// ClaimBatch 一次领取多个红包（如领取帖子内的全部红包），各红包独立领取，部分失败不影响其他红包
 // @Tags redenvelope
 // @Accept json
 // @Produce json
-// @Param request body ClaimRequest true "领取红包请求"
-// @Success 200 {object} util.ResponseAny
-// @Router /api/v1/redenvelope/claim [post]
-func Claim(c *gin.Context) {
-	var req ClaimRequest
+// @Param request body ClaimBatchRequest true "批量领取请求"
+// @Success 200 {object} util.Response[[]ClaimBatchItem]
+// @Router /api/v1/redenvelope/claim-batch [post]
+func ClaimBatch(c *gin.Context) {
+	var req ClaimBatchRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+		handleBindError(c, err)
 		return
 	}
 
 	currentUser, _ := util.GetFromContext[*model.User](c, oauth.UserObjKey)
 
-	var claimedAmount decimal.Decimal
-	var redEnvelope model.RedEnvelope
+	items, err := claimBatch(c.Request.Context(), currentUser.ID, req.Items, newClaimDevice(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		return
+	}
 
-	if err := db.DB(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
-		// 使用 FOR UPDATE 锁定红包记录，防止并发领取
-		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "NOWAIT"}).
-			Where("id = ?", req.ID).First(&redEnvelope).Error; err != nil {
-			if errors.Is(err, gorm.ErrRecordNotFound) {
-				return errors.New(RedEnvelopeNotFound)
-			}
-			// 捕获锁等待超时错误，返回友好提示
-			return errors.New(RedEnvelopeTooPopular)
-		}
+	c.JSON(http.StatusOK, util.OK(items))
+}
 
-		// 检查红包状态
-		if redEnvelope.Status == model.RedEnvelopeStatusExpired || redEnvelope.ExpiresAt.Before(time.Now()) {
-			return errors.New(RedEnvelopeExpired)
-		}
+// WebhookClaim 外部系统回调领取红包（服务端到服务端，HMAC 签名认证）
+// @Tags redenvelope
+// @Accept json
+// @Produce json
+// @Param X-Signature header string true "请求体的 HMAC-SHA256 十六进制签名"
+// @Param request body WebhookClaimRequest true "回调领取请求"
+// @Success 200 {object} util.ResponseAny
+// @Router /api/v1/redenvelope/webhook/claim [post]
+func WebhookClaim(c *gin.Context) {
+	req, _ := util.GetFromContext[*WebhookClaimRequest](c, WebhookClaimRequestKey)
 
-		if redEnvelope.Status == model.RedEnvelopeStatusFinished || redEnvelope.RemainingCount <= 0 {
-			return errors.New(RedEnvelopeFinished)
+	var user model.User
+	if err := db.DB(c.Request.Context()).
+		Where("id = ? AND is_active = ?", req.UserID, true).
+		First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, util.Err(WebhookUserNotFound))
+			return
 		}
+		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		return
+	}
 
-		// 检查是否已领取
-		var existingClaim model.RedEnvelopeClaim
-		if err := tx.Where("red_envelope_id = ? AND user_id = ?", redEnvelope.ID, currentUser.ID).
-			First(&existingClaim).Error; err == nil {
-			return errors.New(RedEnvelopeAlreadyClaimed)
-		}
+	resp, err := ClaimRedEnvelope(c.Request.Context(), user.ID, strconv.FormatUint(req.RedEnvelopeID, 10))
+	if err != nil {
+		handleClaimError(c, err)
+		return
+	}
 
-		// 计算领取金额
-		if redEnvelope.Type == model.RedEnvelopeTypeFixed {
-			// 固定金额：如果是最后一个，给全部剩余金额（避免舍入误差）
-			if redEnvelope.RemainingCount == 1 {
-				claimedAmount = redEnvelope.RemainingAmount
-			} else {
-				claimedAmount = redEnvelope.TotalAmount.Div(decimal.NewFromInt(int64(redEnvelope.TotalCount))).Round(2)
-			}
-		} else {
-			// 拼手气红包：使用二倍均值算法
-			claimedAmount = calculateRandomAmount(redEnvelope.RemainingAmount, redEnvelope.RemainingCount)
-		}
-
-		// 创建领取记录
-		claim := model.RedEnvelopeClaim{
-			ID:            idgen.NextUint64ID(),
-			RedEnvelopeID: redEnvelope.ID,
-			UserID:        currentUser.ID,
-			Amount:        claimedAmount,
-		}
-		if err := tx.Create(&claim).Error; err != nil {
-			return err
-		}
-
-		// 更新红包状态
-		newRemainingCount := redEnvelope.RemainingCount - 1
-		newRemainingAmount := redEnvelope.RemainingAmount.Sub(claimedAmount)
-		newStatus := redEnvelope.Status
-		if newRemainingCount <= 0 {
-			newStatus = model.RedEnvelopeStatusFinished
-		}
-
-		if err := tx.Model(&model.RedEnvelope{}).Where("id = ?", redEnvelope.ID).
-			Updates(map[string]interface{}{
-				"remaining_count":  newRemainingCount,
-				"remaining_amount": newRemainingAmount,
-				"status":           newStatus,
-			}).Error; err != nil {
-			return err
-		}
-
-		// 更新红包对象用于返回
-		redEnvelope.RemainingCount = newRemainingCount
-		redEnvelope.RemainingAmount = newRemainingAmount
-		redEnvelope.Status = newStatus
-
-		// 增加领取者余额并更新total_receive
-		if err := service.UpdateBalance(tx, service.BalanceUpdateOptions{
-			UserID:     currentUser.ID,
-			Amount:     claimedAmount,
-			Operation:  service.BalanceAdd,
-			TotalField: "total_receive",
-		}); err != nil {
-			return err
-		}
-
-		// 创建订单记录（红包收入）
-		order := model.Order{
-			OrderName:   "红包收入",
-			PayerUserID: redEnvelope.CreatorID,
-			PayeeUserID: currentUser.ID,
-			Amount:      claimedAmount,
-			Status:      model.OrderStatusSuccess,
-			Type:        model.OrderTypeRedEnvelopeReceive,
-			Remark:      fmt.Sprintf("祝福语: %s", redEnvelope.Greeting),
-			TradeTime:   time.Now(),
-			ExpiresAt:   time.Now().Add(24 * time.Hour),
-		}
-
-		return tx.Create(&order).Error
-	}); err != nil {
-		errMsg := err.Error()
-		switch errMsg {
-		case RedEnvelopeNotFound:
-			c.JSON(http.StatusNotFound, util.Err(errMsg))
-		case RedEnvelopeExpired, RedEnvelopeFinished, RedEnvelopeAlreadyClaimed, CannotClaimOwnRedEnvelope:
-			c.JSON(http.StatusBadRequest, util.Err(errMsg))
+	c.JSON(http.StatusOK, util.OK(resp))
+}
+
+// IssueDeepLink 外部通知系统为指定用户签发一键领取凭证（服务端到服务端，HMAC 签名认证），凭证嵌入通知后由 DeepLinkClaim 领取
+// @Tags redenvelope
+// @Accept json
+// @Produce json
+// @Param X-Signature header string true "请求体的 HMAC-SHA256 十六进制签名"
+// @Param request body WebhookClaimRequest true "签发请求"
+// @Success 200 {object} util.Response[DeepLinkResponse]
+// @Router /api/v1/redenvelope/webhook/deep-link [post]
+func IssueDeepLink(c *gin.Context) {
+	req, _ := util.GetFromContext[*WebhookClaimRequest](c, WebhookClaimRequestKey)
+
+	resp, err := issueDeepLink(c.Request.Context(), req.RedEnvelopeID, req.UserID)
+	if err != nil {
+		switch err.Error() {
+		case WebhookUserNotFound:
+			c.JSON(http.StatusNotFound, util.Err(err.Error()))
 		default:
-			c.JSON(http.StatusInternalServerError, util.Err(errMsg))
+			handleClaimError(c, err)
 		}
 		return
 	}
 
-	c.JSON(http.StatusOK, util.OK(ClaimResponse{
-		Amount:      claimedAmount,
-		RedEnvelope: &redEnvelope,
-	}))
+	c.JSON(http.StatusOK, util.OK(resp))
 }
 
 // GetDetail 获取红包详情
 // @Tags redenvelope
 // @Produce json
-// @Param id path string true "红包ID"
+// @Param id path string true "红包ID或红包码"
+// @Param currency query string false "展示货币"
+// @Param sort query string false "领取记录排序字段：time（默认）或 amount"
+// @Param order query string false "领取记录排序方向：desc（默认）或 asc"
+// @Param cursor query string false "领取记录分页游标，取上一页的 next_cursor"
+// @Param pin_mine query bool false "是否将当前用户最近一次领取置顶"
 // @Success 200 {object} util.ResponseAny
 // @Router /api/v1/redenvelope/{id} [get]
 func GetDetail(c *gin.Context) {
-	idStr := c.Param("id")
-	redEnvelopeID, err := strconv.ParseUint(idStr, 10, 64)
+	var req DetailClaimsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		handleBindError(c, err)
+		return
+	}
+	if req.Sort == "" {
+		req.Sort = "time"
+	}
+	if req.Order == "" {
+		req.Order = "desc"
+	}
+	claimsQuery, err := claimsPageQuery(db.DB(c.Request.Context()), req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, util.ErrFields(err.Error(), map[string]string{"cursor": err.Error()}))
+		return
+	}
+
+	// 路径参数可为红包ID或红包码
+	redEnvelopeID, err := resolveRedEnvelopeID(c.Request.Context(), c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, util.Err(InvalidRedEnvelopeID))
+		switch err.Error() {
+		case InvalidRedEnvelopeID:
+			c.JSON(http.StatusBadRequest, util.Err(InvalidRedEnvelopeID))
+		case RedEnvelopeNotFound:
+			c.JSON(http.StatusNotFound, util.Err(RedEnvelopeNotFound))
+		default:
+			c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		}
 		return
 	}
 
@@ -417,42 +845,193 @@ func GetDetail(c *gin.Context) {
 		return
 	}
 
-	var claims []model.RedEnvelopeClaim
-	db.DB(c.Request.Context()).
-		Select("red_envelope_claims.*, users.username, users.avatar_url").
-		Joins("LEFT JOIN users ON red_envelope_claims.user_id = users.id").
-		Where("red_envelope_claims.red_envelope_id = ?", redEnvelope.ID).
-		Order("red_envelope_claims.claimed_at DESC").
-		Find(&claims)
+	// 私密红包仅创建者和可领取名单内的用户可查看
+	if currentUser == nil || currentUser.ID != redEnvelope.CreatorID {
+		var viewerID uint64
+		if currentUser != nil {
+			viewerID = currentUser.ID
+		}
+		if err := checkAllowList(db.DB(c.Request.Context()), &redEnvelope, viewerID); err != nil {
+			if err.Error() == NotInAllowList {
+				c.JSON(http.StatusForbidden, util.Err(err.Error()))
+				return
+			}
+			c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+			return
+		}
+	}
+
+	// 单次返回的领取记录数有上限，避免超大红包占用过多内存
+	maxClaims, err := model.GetIntByKey(c.Request.Context(), model.ConfigKeyRedEnvelopeDetailMaxClaims)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		return
+	}
+	if maxClaims <= 0 {
+		maxClaims = DefaultDetailMaxClaims
+	}
+
+	// 领取记录已归档时返回空列表；超出单次上限时截断并返回下一页游标
+	claims := []model.RedEnvelopeClaim{}
+	claimsTruncated := false
+	var nextCursor string
+	if redEnvelope.ClaimsArchivedAt == nil {
+		if err := claimsQuery.
+			Where("red_envelope_id = ?", redEnvelope.ID).
+			Limit(maxClaims + 1).
+			Find(&claims).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+			return
+		}
+		if len(claims) > maxClaims {
+			claims = claims[:maxClaims]
+			claimsTruncated = true
+			nextCursor = encodeClaimCursor(&claims[maxClaims-1], req.Sort)
+		}
+
+		claimRefs := make([]*model.RedEnvelopeClaim, len(claims))
+		for i := range claims {
+			claimRefs[i] = &claims[i]
+		}
+		if err := fillClaimUsers(c.Request.Context(), claimRefs); err != nil {
+			c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+			return
+		}
+	}
 
+	// 记录当前用户最近一次领取及领取次数，仅默认排序的首页包含最近的领取记录
+	paged := claimsTruncated || req.Cursor != ""
+	latestFirst := req.Sort == "time" && req.Order == "desc" && req.Cursor == ""
 	var userClaimed *model.RedEnvelopeClaim
-	if currentUser != nil {
+	userClaimCount := 0
+	if currentUser != nil && latestFirst {
 		for i := range claims {
 			if claims[i].UserID == currentUser.ID {
-				userClaimed = &claims[i]
-				break
+				if userClaimed == nil {
+					userClaimed = &claims[i]
+				}
+				userClaimCount++
 			}
 		}
 	}
 
-	c.JSON(http.StatusOK, util.OK(DetailResponse{
-		RedEnvelope: &redEnvelope,
-		Claims:      claims,
-		UserClaimed: userClaimed,
-	}))
-}
+	// 记录被截断或未按最新在前返回时单独查询当前用户的领取情况
+	if (claimsTruncated || !latestFirst) && currentUser != nil {
+		var userClaims []model.RedEnvelopeClaim
+		if err := db.DB(c.Request.Context()).
+			Where("red_envelope_id = ? AND user_id = ?", redEnvelope.ID, currentUser.ID).
+			Order("claimed_at DESC").
+			Find(&userClaims).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+			return
+		}
+		userClaimCount = len(userClaims)
+		userClaimed = nil
+		if userClaimCount > 0 {
+			userClaimed = &userClaims[0]
+			if userClaimed.Username == "" {
+				userClaimed.Username = currentUser.Username
+				userClaimed.AvatarURL = currentUser.AvatarUrl
+			}
+		}
+	}
 
-// List 获取红包列表
-// @Tags redenvelope
-// @Accept json
-// @Produce json
-// @Param request body ListRequest true "列表请求"
-// @Success 200 {object} util.ResponseAny
+	// 按查看者与红包的关系决定可见范围，隐藏祝福语仅对创建者和已领取用户展示
+	role := viewerRole(&redEnvelope, currentUser, userClaimed != nil)
+	if redEnvelope.GreetingHidden && redEnvelope.Greeting != "" && role == ViewerRolePublic {
+		redEnvelope.Greeting = HiddenGreetingMask
+	}
+	// 领取成功提示语仅对创建者和已领取用户展示
+	if role == ViewerRolePublic {
+		redEnvelope.ClaimMessage = ""
+	}
+
+	// 尚可领取时签发一次性领取凭证
+	grace, err := getClaimGracePeriod(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		return
+	}
+	var claimToken string
+	if currentUser != nil && userClaimCount < redEnvelope.MaxClaimsPerUser &&
+		redEnvelope.Status == model.RedEnvelopeStatusActive && !isClaimExpired(&redEnvelope, grace) {
+		claimTokenRequired, err := model.GetBoolByKey(c.Request.Context(), model.ConfigKeyRedEnvelopeClaimTokenRequired)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+			return
+		}
+		if claimTokenRequired {
+			if claimToken, err = issueClaimToken(c.Request.Context(), redEnvelope.ID, currentUser.ID); err != nil {
+				c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+				return
+			}
+		}
+	}
+
+	// 过期退款会清零剩余个数，领取记录未归档时以实际记录数为准
+	claimedCount := redEnvelope.TotalCount - redEnvelope.RemainingCount
+	if redEnvelope.ClaimsArchivedAt == nil {
+		claimedCount = len(claims)
+		if paged {
+			var total int64
+			if err := db.DB(c.Request.Context()).Model(&model.RedEnvelopeClaim{}).
+				Where("red_envelope_id = ?", redEnvelope.ID).Count(&total).Error; err != nil {
+				c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+				return
+			}
+			claimedCount = int(total)
+		}
+	}
+
+	// 置顶当前用户的领取记录，置顶记录已在首页返回，后续页不再重复
+	if req.PinMine && userClaimed != nil {
+		claims = pinClaim(claims, *userClaimed, req.Cursor == "")
+	}
+
+	// 按请求的展示货币换算金额
+	currency := c.Query("currency")
+	displayTotalAmount, err := convertDisplayAmount(c.Request.Context(), redEnvelope.TotalAmount, currency)
+	if err != nil {
+		logger.WarnF(c.Request.Context(), "红包金额展示货币换算失败: %v", err)
+	}
+	var displayClaimedAmount *DisplayAmount
+	if userClaimed != nil && displayTotalAmount != nil {
+		displayClaimedAmount, _ = convertDisplayAmount(c.Request.Context(), userClaimed.Amount, currency)
+	}
+
+	c.JSON(http.StatusOK, util.OK(DetailResponse{
+		RedEnvelope:          &redEnvelope,
+		ViewerRole:           role,
+		Claims:               projectClaims(claims, role, currentUser),
+		UserClaimed:          userClaimed,
+		ClaimToken:           claimToken,
+		ClaimsArchived:       redEnvelope.ClaimsArchivedAt != nil,
+		ClaimsTruncated:      claimsTruncated,
+		NextCursor:           nextCursor,
+		ShareMeta:            buildShareMeta(&redEnvelope, claimedCount),
+		DisplayTotalAmount:   displayTotalAmount,
+		DisplayClaimedAmount: displayClaimedAmount,
+	}))
+}
+
+// List 获取红包列表
+// @Tags redenvelope
+// @Accept json
+// @Produce json
+// @Param request body ListRequest true "列表请求"
+// @Success 200 {object} util.ResponseAny
 // @Router /api/v1/redenvelope/list [post]
 func List(c *gin.Context) {
 	var req ListRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+		handleBindError(c, err)
+		return
+	}
+
+	if err := resolveListPaging(&req, func() (int, error) {
+		return model.GetIntByKey(c.Request.Context(), model.ConfigKeyRedEnvelopeDefaultPageSize)
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
 		return
 	}
 
@@ -465,8 +1044,8 @@ func List(c *gin.Context) {
 	case "sent":
 		query = query.Where("red_envelopes.creator_id = ?", currentUser.ID)
 	case "received":
-		query = query.Joins("INNER JOIN red_envelope_claims ON red_envelopes.id = red_envelope_claims.red_envelope_id").
-			Where("red_envelope_claims.user_id = ?", currentUser.ID)
+		// 同一红包可能被多次领取，使用 EXISTS 避免重复
+		query = query.Where("EXISTS (SELECT 1 FROM red_envelope_claims WHERE red_envelope_claims.red_envelope_id = red_envelopes.id AND red_envelope_claims.user_id = ?)", currentUser.ID)
 	default:
 		query = query.Where("red_envelopes.creator_id = ?", currentUser.ID)
 	}
@@ -487,3 +1066,1211 @@ func List(c *gin.Context) {
 		RedEnvelopes: redEnvelopes,
 	}))
 }
+
+// GetLocked 获取当前用户进行中红包尚未被领取的金额
+// @Tags redenvelope
+// @Produce json
+// @Success 200 {object} util.ResponseAny
+// @Router /api/v1/redenvelope/locked [get]
+func GetLocked(c *gin.Context) {
+	currentUser, _ := util.GetFromContext[*model.User](c, oauth.UserObjKey)
+
+	var resp LockedResponse
+	if err := db.DB(c.Request.Context()).Model(&model.RedEnvelope{}).
+		Select("COUNT(*) AS active_count, COALESCE(SUM(remaining_amount), 0) AS locked_amount").
+		Where("creator_id = ? AND status IN ? AND expires_at > ?", currentUser.ID, openStatuses, time.Now()).
+		Scan(&resp).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, util.OK(resp))
+}
+
+// Rotate 重新生成红包分享链接（仅创建者），旧红包码或链接随即失效，红包ID及领取记录不变
+// @Tags redenvelope
+// @Produce json
+// @Param id path string true "红包ID或红包码"
+// @Success 200 {object} util.ResponseAny
+// @Router /api/v1/redenvelope/{id}/rotate [post]
+func Rotate(c *gin.Context) {
+	currentUser, _ := util.GetFromContext[*model.User](c, oauth.UserObjKey)
+
+	redEnvelope, err := rotateEnvelopeCode(c.Request.Context(), c.Param("id"), currentUser.ID)
+	if err != nil {
+		switch err.Error() {
+		case RedEnvelopeExpired, RedEnvelopeFinished:
+			c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+		case CodeGenerationFailed:
+			c.JSON(http.StatusServiceUnavailable, util.Err(err.Error()))
+		default:
+			handleCreatorError(c, err)
+		}
+		return
+	}
+
+	resp := RotateResponse{ID: redEnvelope.ID}
+	if redEnvelope.LinkToken != "" {
+		resp.LinkToken = redEnvelope.LinkToken
+		resp.Link = fmt.Sprintf("%s/redenvelope/%s", config.Config.App.FrontendURL, redEnvelope.LinkToken)
+	} else {
+		resp.Code = *redEnvelope.Code
+		resp.Link = fmt.Sprintf("%s/redenvelope/%s", config.Config.App.FrontendURL, *redEnvelope.Code)
+	}
+	c.JSON(http.StatusOK, util.OK(resp))
+}
+
+// GetResults 获取红包领取结果，按金额从高到低排序并标记手气最佳
+// 红包未结束时返回当前的部分结果，finished 为 false 且不标记手气最佳
+// 领取明细的可见范围与红包详情一致，其他用户仅返回汇总信息
+// 已结束的非固定金额红包返回分配均衡度，首次计算后保存在红包上，领取记录归档后仍可返回
+// 私密红包的可见范围与红包详情一致，仅创建者和可领取名单内的用户可查看
+// @Tags redenvelope
+// @Produce json
+// @Param id path string true "红包ID或红包码"
+// @Success 200 {object} util.ResponseAny
+// @Router /api/v1/redenvelope/{id}/results [get]
+func GetResults(c *gin.Context) {
+	// 路径参数可为红包ID或红包码
+	redEnvelopeID, err := resolveRedEnvelopeID(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		switch err.Error() {
+		case InvalidRedEnvelopeID:
+			c.JSON(http.StatusBadRequest, util.Err(InvalidRedEnvelopeID))
+		case RedEnvelopeNotFound:
+			c.JSON(http.StatusNotFound, util.Err(RedEnvelopeNotFound))
+		default:
+			c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		}
+		return
+	}
+
+	currentUser, _ := util.GetFromContext[*model.User](c, oauth.UserObjKey)
+
+	var redEnvelope model.RedEnvelope
+	if err := db.DB(c.Request.Context()).Where("id = ?", redEnvelopeID).First(&redEnvelope).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, util.Err(RedEnvelopeNotFound))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		return
+	}
+
+	// 私密红包仅创建者和可领取名单内的用户可查看
+	if currentUser.ID != redEnvelope.CreatorID {
+		if err := checkAllowList(db.DB(c.Request.Context()), &redEnvelope, currentUser.ID); err != nil {
+			if err.Error() == NotInAllowList {
+				c.JSON(http.StatusForbidden, util.Err(err.Error()))
+				return
+			}
+			c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+			return
+		}
+	}
+
+	resp := ResultsResponse{
+		Finished:       !slices.Contains(openStatuses, redEnvelope.Status),
+		ClaimsArchived: redEnvelope.ClaimsArchivedAt != nil,
+		TotalCount:     redEnvelope.TotalCount,
+		TotalAmount:    redEnvelope.TotalAmount,
+		ClaimedAmount:  decimal.Zero,
+		Claims:         []ResultClaim{},
+	}
+
+	if redEnvelope.ClaimsArchivedAt == nil {
+		if err := db.DB(c.Request.Context()).Model(&model.RedEnvelopeClaim{}).
+			Where("red_envelope_id = ?", redEnvelope.ID).
+			Order("amount DESC, claimed_at ASC").
+			Find(&resp.Claims).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+			return
+		}
+
+		claimRefs := make([]*model.RedEnvelopeClaim, len(resp.Claims))
+		for i := range resp.Claims {
+			claimRefs[i] = &resp.Claims[i].RedEnvelopeClaim
+		}
+		if err := fillClaimUsers(c.Request.Context(), claimRefs); err != nil {
+			c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+			return
+		}
+	}
+
+	claimed := false
+	for _, claim := range resp.Claims {
+		resp.ClaimedAmount = resp.ClaimedAmount.Add(claim.RedEnvelopeClaim.Amount)
+		if claim.UserID == currentUser.ID {
+			claimed = true
+		}
+	}
+	resp.ClaimedCount = len(resp.Claims)
+
+	// 已结束的非固定金额红包标记手气最佳（金额相同时先领取者为准）
+	if resp.Finished && redEnvelope.Type != model.RedEnvelopeTypeFixed && len(resp.Claims) > 0 {
+		resp.Claims[0].Luckiest = true
+	}
+	if resp.Finished && redEnvelope.Type != model.RedEnvelopeTypeFixed {
+		resp.Fairness = envelopeFairness(c.Request.Context(), &redEnvelope, resp.Claims)
+	}
+
+	resp.ViewerRole = viewerRole(&redEnvelope, currentUser, claimed)
+	switch resp.ViewerRole {
+	case ViewerRolePublic:
+		resp.Claims = []ResultClaim{}
+	default:
+		for i := range resp.Claims {
+			if resp.ViewerRole == ViewerRoleCreator || resp.Claims[i].UserID == currentUser.ID {
+				resp.Claims[i].Amount = &resp.Claims[i].RedEnvelopeClaim.Amount
+			}
+		}
+	}
+
+	c.JSON(http.StatusOK, util.OK(resp))
+}
+
+// Reserve 预约红包名额（需确认领取的红包），预约超时未确认自动释放
+// @Tags redenvelope
+// @Accept json
+// @Produce json
+// @Param request body ReserveRequest true "预约请求"
+// @Success 200 {object} util.ResponseAny
+// @Router /api/v1/redenvelope/reserve [post]
+func Reserve(c *gin.Context) {
+	var req ReserveRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleBindError(c, err)
+		return
+	}
+
+	currentUser, _ := util.GetFromContext[*model.User](c, oauth.UserObjKey)
+
+	resp, err := reserveRedEnvelope(c.Request.Context(), currentUser.ID, req.ID)
+	if err != nil {
+		handleClaimError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, util.OK(resp))
+}
+
+// Confirm 使用预约凭证确认领取红包
+// @Tags redenvelope
+// @Accept json
+// @Produce json
+// @Param request body ConfirmRequest true "确认领取请求"
+// @Success 200 {object} util.ResponseAny
+// @Router /api/v1/redenvelope/confirm [post]
+func Confirm(c *gin.Context) {
+	var req ConfirmRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleBindError(c, err)
+		return
+	}
+
+	currentUser, _ := util.GetFromContext[*model.User](c, oauth.UserObjKey)
+
+	resp, err := confirmRedEnvelope(c.Request.Context(), currentUser.ID, req.ID, req.ReservationToken, newClaimDevice(c))
+	if err != nil {
+		handleClaimError(c, err)
+		return
+	}
+
+	if resp.DisplayAmount, err = convertDisplayAmount(c.Request.Context(), resp.Amount, req.Currency); err != nil {
+		logger.WarnF(c.Request.Context(), "红包金额展示货币换算失败: %v", err)
+	}
+
+	c.JSON(http.StatusOK, util.OK(resp))
+}
+
+// GetEscrow 获取当前用户的红包托管余额
+// @Tags redenvelope
+// @Produce json
+// @Success 200 {object} util.ResponseAny
+// @Router /api/v1/redenvelope/escrow [get]
+func GetEscrow(c *gin.Context) {
+	currentUser, _ := util.GetFromContext[*model.User](c, oauth.UserObjKey)
+
+	var escrow model.RedEnvelopeEscrow
+	if err := db.DB(c.Request.Context()).Where("user_id = ?", currentUser.ID).First(&escrow).Error; err != nil &&
+		!errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, util.OK(EscrowResponse{Balance: escrow.Balance}))
+}
+
+// DepositEscrow 从可用余额存入红包托管资金
+// @Tags redenvelope
+// @Accept json
+// @Produce json
+// @Param request body EscrowRequest true "存入请求"
+// @Success 200 {object} util.ResponseAny
+// @Router /api/v1/redenvelope/escrow/deposit [post]
+func DepositEscrow(c *gin.Context) {
+	handleEscrowTransfer(c, depositEscrow)
+}
+
+// WithdrawEscrow 将红包托管资金取回可用余额
+// @Tags redenvelope
+// @Accept json
+// @Produce json
+// @Param request body EscrowRequest true "取回请求"
+// @Success 200 {object} util.ResponseAny
+// @Router /api/v1/redenvelope/escrow/withdraw [post]
+func WithdrawEscrow(c *gin.Context) {
+	handleEscrowTransfer(c, withdrawEscrow)
+}
+
+// ListMyClaims 获取当前用户在所有红包中的领取记录
+// @Tags redenvelope
+// @Produce json
+// @Param page query int true "页码"
+// @Param page_size query int true "每页数量"
+// @Param start_time query string false "开始时间"
+// @Param end_time query string false "结束时间"
+// @Success 200 {object} util.ResponseAny
+// @Router /api/v1/redenvelope/my-claims [get]
+func ListMyClaims(c *gin.Context) {
+	var req MyClaimsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		handleBindError(c, err)
+		return
+	}
+
+	currentUser, _ := util.GetFromContext[*model.User](c, oauth.UserObjKey)
+
+	query := db.DB(c.Request.Context()).Table("red_envelope_claims").
+		Joins("JOIN red_envelopes ON red_envelopes.id = red_envelope_claims.red_envelope_id").
+		Joins("LEFT JOIN users ON users.id = red_envelopes.creator_id").
+		Where("red_envelope_claims.user_id = ?", currentUser.ID)
+	if req.StartTime != nil {
+		query = query.Where("red_envelope_claims.claimed_at >= ?", req.StartTime)
+	}
+	if req.EndTime != nil {
+		query = query.Where("red_envelope_claims.claimed_at <= ?", req.EndTime)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		return
+	}
+
+	claims := make([]MyClaim, 0)
+	if err := query.
+		Select("red_envelope_claims.id, red_envelope_claims.red_envelope_id, red_envelopes.greeting, red_envelopes.claim_message, " +
+			"red_envelope_claims.amount, red_envelope_claims.sequence, red_envelope_claims.claimed_at, " +
+			"red_envelopes.creator_id, users.username as creator_username").
+		Order("red_envelope_claims.claimed_at DESC").
+		Offset((req.Page - 1) * req.PageSize).
+		Limit(req.PageSize).
+		Scan(&claims).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, util.OK(MyClaimsResponse{
+		Total:    total,
+		Page:     req.Page,
+		PageSize: req.PageSize,
+		Claims:   claims,
+	}))
+}
+
+// ListPublic 获取可领取的公开红包列表
+// @Tags redenvelope
+// @Produce json
+// @Param page query int true "页码"
+// @Param page_size query int true "每页数量"
+// @Success 200 {object} util.ResponseAny
+// @Router /api/v1/redenvelope/public [get]
+func ListPublic(c *gin.Context) {
+	var req PublicListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		handleBindError(c, err)
+		return
+	}
+
+	query := db.DB(c.Request.Context()).Model(&model.RedEnvelope{}).
+		Select("red_envelopes.*, users.username as creator_username, users.avatar_url as creator_avatar_url").
+		Joins("LEFT JOIN users ON red_envelopes.creator_id = users.id").
+		Where("red_envelopes.visibility = ? AND red_envelopes.status = ? AND red_envelopes.remaining_count > 0 AND red_envelopes.expires_at > ?",
+			model.RedEnvelopeVisibilityPublic, model.RedEnvelopeStatusActive, time.Now())
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		return
+	}
+
+	redEnvelopes := []model.RedEnvelope{}
+	if err := query.Order("red_envelopes.created_at DESC").
+		Offset((req.Page - 1) * req.PageSize).
+		Limit(req.PageSize).
+		Find(&redEnvelopes).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		return
+	}
+
+	// 列表中不展示隐藏的祝福语及领取成功提示语
+	for i := range redEnvelopes {
+		if redEnvelopes[i].GreetingHidden && redEnvelopes[i].Greeting != "" {
+			redEnvelopes[i].Greeting = HiddenGreetingMask
+		}
+		redEnvelopes[i].ClaimMessage = ""
+	}
+
+	c.JSON(http.StatusOK, util.OK(ListResponse{
+		Total:        total,
+		Page:         req.Page,
+		PageSize:     req.PageSize,
+		RedEnvelopes: redEnvelopes,
+	}))
+}
+
+// GetByOrder 根据订单ID查询关联的红包，用于对账
+// @Tags redenvelope
+// @Produce json
+// @Param order_id path string true "订单ID"
+// @Success 200 {object} util.ResponseAny
+// @Router /api/v1/redenvelope/by-order/{order_id} [get]
+func GetByOrder(c *gin.Context) {
+	orderID, err := strconv.ParseUint(c.Param("order_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, util.Err(InvalidOrderID))
+		return
+	}
+
+	currentUser, _ := util.GetFromContext[*model.User](c, oauth.UserObjKey)
+
+	// 仅订单的付款方或收款方可查询
+	var order model.Order
+	if err := db.DB(c.Request.Context()).
+		Where("id = ? AND red_envelope_id IS NOT NULL AND (payer_user_id = ? OR payee_user_id = ?)", orderID, currentUser.ID, currentUser.ID).
+		Where("(type IN ? OR type = ?)", redEnvelopeOrderTypes, model.OrderTypeTest).
+		First(&order).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, util.Err(OrderNotLinked))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		return
+	}
+
+	var redEnvelope model.RedEnvelope
+	if err := db.DB(c.Request.Context()).
+		Select("red_envelopes.*, users.username as creator_username, users.avatar_url as creator_avatar_url").
+		Joins("LEFT JOIN users ON red_envelopes.creator_id = users.id").
+		Where("red_envelopes.id = ?", *order.RedEnvelopeID).First(&redEnvelope).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, util.Err(RedEnvelopeNotFound))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, util.OK(redEnvelope))
+}
+
+// ListRefunds 获取当前用户已退款的红包及退款金额
+// @Tags redenvelope
+// @Produce json
+// @Param page query int true "页码"
+// @Param page_size query int true "每页数量"
+// @Param start_time query string false "退款开始时间"
+// @Param end_time query string false "退款结束时间"
+// @Success 200 {object} util.ResponseAny
+// @Router /api/v1/redenvelope/refunds [get]
+func ListRefunds(c *gin.Context) {
+	var req RefundsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		handleBindError(c, err)
+		return
+	}
+
+	currentUser, _ := util.GetFromContext[*model.User](c, oauth.UserObjKey)
+
+	baseQuery := func() *gorm.DB {
+		query := db.DB(c.Request.Context()).Table("red_envelopes").
+			Joins("JOIN orders ON orders.red_envelope_id = red_envelopes.id AND orders.type = ?", model.OrderTypeRedEnvelopeRefund).
+			Where("red_envelopes.creator_id = ?", currentUser.ID)
+		if req.StartTime != nil {
+			query = query.Where("orders.trade_time >= ?", req.StartTime)
+		}
+		if req.EndTime != nil {
+			query = query.Where("orders.trade_time <= ?", req.EndTime)
+		}
+		return query
+	}
+
+	var total int64
+	if err := baseQuery().Select("COUNT(DISTINCT red_envelopes.id)").Scan(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		return
+	}
+
+	// 同一红包可能有多笔退款（余额上限退款及过期退款），按红包汇总
+	envelopes := make([]RefundedEnvelope, 0)
+	if err := baseQuery().
+		Select("red_envelopes.id, red_envelopes.type, red_envelopes.greeting, red_envelopes.total_amount, " +
+			"red_envelopes.total_count, red_envelopes.status, red_envelopes.expires_at, red_envelopes.created_at, " +
+			"SUM(orders.amount) AS refunded_amount, MAX(orders.trade_time) AS refunded_at").
+		Group("red_envelopes.id").
+		Order("refunded_at DESC").
+		Offset((req.Page - 1) * req.PageSize).
+		Limit(req.PageSize).
+		Scan(&envelopes).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, util.OK(RefundsResponse{
+		Total:     total,
+		Page:      req.Page,
+		PageSize:  req.PageSize,
+		Envelopes: envelopes,
+	}))
+}
+
+// Search 按祝福语或创建者搜索当前用户发出及领取的红包
+// @Tags redenvelope
+// @Accept json
+// @Produce json
+// @Param request body SearchRequest true "搜索请求"
+// @Success 200 {object} util.ResponseAny
+// @Router /api/v1/redenvelope/search [post]
+func Search(c *gin.Context) {
+	var req SearchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleBindError(c, err)
+		return
+	}
+
+	currentUser, _ := util.GetFromContext[*model.User](c, oauth.UserObjKey)
+
+	// 发出与领取的红包ID通过 UNION 合并，分别走 creator_id 索引与领取记录的 user_id 索引
+	envelopeIDs := db.DB(c.Request.Context()).Raw(
+		"SELECT id FROM red_envelopes WHERE creator_id = ? UNION SELECT red_envelope_id FROM red_envelope_claims WHERE user_id = ?",
+		currentUser.ID, currentUser.ID,
+	)
+
+	query := db.DB(c.Request.Context()).Model(&model.RedEnvelope{}).
+		Joins("LEFT JOIN users ON red_envelopes.creator_id = users.id").
+		Where("red_envelopes.id IN (?)", envelopeIDs)
+	if req.Keyword != "" {
+		keyword := "%" + req.Keyword + "%"
+		query = query.Where("red_envelopes.greeting ILIKE ? OR users.username ILIKE ?", keyword, keyword)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		return
+	}
+
+	results := make([]SearchResult, 0)
+	if err := query.
+		Select("red_envelopes.*, users.username as creator_username, users.avatar_url as creator_avatar_url, "+
+			"CASE WHEN red_envelopes.creator_id = ? THEN 'sent' ELSE 'received' END AS direction", currentUser.ID).
+		Order("red_envelopes.created_at DESC").
+		Offset((req.Page - 1) * req.PageSize).
+		Limit(req.PageSize).
+		Find(&results).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, util.OK(SearchResponse{
+		Total:        total,
+		Page:         req.Page,
+		PageSize:     req.PageSize,
+		RedEnvelopes: results,
+	}))
+}
+
+// ReturnClaim 在退回时间窗口内退回已领取的红包
+// @Tags redenvelope
+// @Accept json
+// @Produce json
+// @Param request body ReturnClaimRequest true "退回请求"
+// @Success 200 {object} util.ResponseAny
+// @Router /api/v1/redenvelope/claim/return [post]
+func ReturnClaim(c *gin.Context) {
+	var req ReturnClaimRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleBindError(c, err)
+		return
+	}
+
+	currentUser, _ := util.GetFromContext[*model.User](c, oauth.UserObjKey)
+	if !verifyPayKey(c, currentUser, req.PayKey) {
+		return
+	}
+
+	if err := returnClaim(c.Request.Context(), currentUser.ID, req.ClaimID); err != nil {
+		handleClaimError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, util.OKNil())
+}
+
+// DeepLinkClaim 通过通知中的一键领取凭证领取红包，以凭证中的用户身份领取，无需登录
+// @Tags redenvelope
+// @Accept json
+// @Produce json
+// @Param request body DeepLinkClaimRequest true "一键领取请求"
+// @Success 200 {object} util.ResponseAny
+// @Router /api/v1/redenvelope/deep-link/claim [post]
+func DeepLinkClaim(c *gin.Context) {
+	var req DeepLinkClaimRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleBindError(c, err)
+		return
+	}
+
+	resp, err := claimByDeepLink(c.Request.Context(), req.ID, req.Token, newClaimDevice(c))
+	if err != nil {
+		handleClaimError(c, err)
+		return
+	}
+
+	if resp.DisplayAmount, err = convertDisplayAmount(c.Request.Context(), resp.Amount, req.Currency); err != nil {
+		logger.WarnF(c.Request.Context(), "红包金额展示货币换算失败: %v", err)
+	}
+
+	c.JSON(http.StatusOK, util.OK(resp))
+}
+
+// VerifyReceipt 校验领取回执的签名并返回回执内容，无需登录，供合作方在无法自行校验时使用
+// @Tags redenvelope
+// @Accept json
+// @Produce json
+// @Param request body VerifyReceiptRequest true "校验请求"
+// @Success 200 {object} util.Response[ClaimReceipt]
+// @Router /api/v1/redenvelope/receipts/verify [post]
+func VerifyReceipt(c *gin.Context) {
+	var req VerifyReceiptRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleBindError(c, err)
+		return
+	}
+
+	receipt, err := VerifyClaimReceipt(req.Receipt)
+	if err != nil {
+		switch err.Error() {
+		case ReceiptDisabled:
+			c.JSON(http.StatusNotFound, util.Err(err.Error()))
+		default:
+			c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, util.OK(receipt))
+}
+
+// Export 以 JSON 文件流式导出当前用户的全部红包数据，包括发出的红包、领取记录及相关订单
+// 测试红包及其领取记录同样属于用户数据，一并导出；其测试订单按关联红包筛选，不包含支付、收款链接产生的测试订单
+// 路由有意不挂载 CheckRedEnvelopeEnabled：导出属于用户取回自身数据的权利，红包功能关闭后仍须可以导出历史数据
+// @Tags redenvelope
+// @Produce json
+// @Success 200 {object} util.ResponseAny
+// @Router /api/v1/redenvelope/export [get]
+func Export(c *gin.Context) {
+	currentUser, _ := util.GetFromContext[*model.User](c, oauth.UserObjKey)
+	ctx := c.Request.Context()
+	tx := db.DB(ctx)
+
+	c.Header("Content-Type", "application/json; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="redenvelope-export-%d.json"`, currentUser.ID))
+	c.Status(http.StatusOK)
+
+	// 响应头已发送，导出中途失败时只能截断输出并记录日志
+	if _, err := fmt.Fprintf(c.Writer, `{"user_id":"%d","exported_at":%q,`, currentUser.ID, time.Now().Format(time.RFC3339)); err != nil {
+		return
+	}
+	if err := writeExportSection[model.RedEnvelope](c, "sent_envelopes",
+		tx.Where("creator_id = ?", currentUser.ID)); err != nil {
+		logger.ErrorF(ctx, "导出发出的红包失败: %v", err)
+		return
+	}
+	c.Writer.WriteString(",")
+	if err := writeExportSection[model.RedEnvelopeClaim](c, "claims",
+		tx.Where("user_id = ?", currentUser.ID)); err != nil {
+		logger.ErrorF(ctx, "导出红包领取记录失败: %v", err)
+		return
+	}
+	c.Writer.WriteString(",")
+	if err := writeExportSection[model.Order](c, "orders",
+		tx.Where("(type IN ? OR (type = ? AND red_envelope_id IS NOT NULL)) AND (payer_user_id = ? OR payee_user_id = ?)",
+			redEnvelopeOrderTypes, model.OrderTypeTest, currentUser.ID, currentUser.ID)); err != nil {
+		logger.ErrorF(ctx, "导出红包相关订单失败: %v", err)
+		return
+	}
+	c.Writer.WriteString("}")
+}
+
+// ReadinessResponse 红包子系统就绪检查响应
+type ReadinessResponse struct {
+	Status   string `json:"status"`
+	Database string `json:"database"`
+	Redis    string `json:"redis"`
+}
+
+// Ready 红包子系统就绪检查：数据库不可用时返回 503，Redis 不可用时核心流程仍可用，返回 degraded
+// @Tags redenvelope
+// @Produce json
+// @Success 200 {object} util.ResponseAny
+// @Failure 503 {object} util.ResponseAny
+// @Router /api/v1/redenvelope/ready [get]
+func Ready(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), ReadinessCheckTimeout)
+	defer cancel()
+
+	resp := ReadinessResponse{
+		Status:   ReadinessStatusOK,
+		Database: DependencyStatusOK,
+		Redis:    DependencyStatusDisabled,
+	}
+
+	// Redis 承载限流、闸门、缓存等可选功能，不可用时降级
+	if db.Redis != nil {
+		resp.Redis = DependencyStatusOK
+		if err := db.Redis.Ping(ctx).Err(); err != nil {
+			logger.WarnF(ctx, "红包就绪检查 Redis 不可用: %v", err)
+			resp.Redis = DependencyStatusUnavailable
+			resp.Status = ReadinessStatusDegraded
+		}
+	}
+
+	sqlDB, err := db.DB(ctx).DB()
+	if err == nil {
+		err = sqlDB.PingContext(ctx)
+	}
+	if err != nil {
+		logger.ErrorF(ctx, "红包就绪检查数据库不可用: %v", err)
+		resp.Database = DependencyStatusUnavailable
+		resp.Status = ReadinessStatusDown
+		c.JSON(http.StatusServiceUnavailable, util.Response[ReadinessResponse]{ErrorMsg: DatabaseUnavailable, Data: resp})
+		return
+	}
+
+	c.JSON(http.StatusOK, util.OK(resp))
+}
+
+// TopSendersRequest 发红包排行榜请求
+type TopSendersRequest struct {
+	Days  int `form:"days" binding:"omitempty,min=1,max=90"`
+	Limit int `form:"limit" binding:"omitempty,min=1,max=50"`
+}
+
+// ClosingSoonRequest 即将截止领取的红包查询请求，scope 为 mine 时查询自己发出的红包，为 public 时查询公开红包
+type ClosingSoonRequest struct {
+	Scope         string `form:"scope" binding:"omitempty,oneof=mine public"`
+	WithinMinutes int    `form:"within_minutes" binding:"omitempty,min=1,max=1440"`
+	Limit         int    `form:"limit" binding:"omitempty,min=1,max=50"`
+}
+
+// ClosingSoonItem 即将截止领取的红包，ClaimDeadline 为过期时间加领取宽限时间，SecondsLeft 为距截止的剩余秒数
+type ClosingSoonItem struct {
+	model.RedEnvelope
+	ClaimDeadline time.Time `json:"claim_deadline"`
+	SecondsLeft   int64     `json:"seconds_left"`
+}
+
+// TopSender 发红包排行榜条目，金额为统计期内从其红包中被领取的总额
+type TopSender struct {
+	UserID      uint64          `json:"user_id,string"`
+	Username    string          `json:"username"`
+	AvatarURL   string          `json:"avatar_url"`
+	TotalAmount decimal.Decimal `json:"total_amount"`
+	ClaimCount  int64           `json:"claim_count"`
+}
+
+// TopSendersResponse 发红包排行榜响应
+type TopSendersResponse struct {
+	Days    int          `json:"days"`
+	Senders []*TopSender `json:"senders"`
+}
+
+// ErrorCodeItem 错误码及各语言的默认提示，键为语言标签
+type ErrorCodeItem struct {
+	Code     string            `json:"code"`
+	Messages map[string]string `json:"messages"`
+}
+
+// ListErrorCodes 获取红包接口全部错误码及各语言的默认提示，接口返回的 error_msg 与 zh-CN 提示一致
+// 部分提示包含 %s 等占位符，由服务端填入具体值
+// @Tags redenvelope
+// @Produce json
+// @Success 200 {object} util.Response[[]ErrorCodeItem]
+// @Router /api/v1/redenvelope/error-codes [get]
+func ListErrorCodes(c *gin.Context) {
+	items := make([]ErrorCodeItem, len(errorRegistry))
+	for i, entry := range errorRegistry {
+		items[i] = ErrorCodeItem{
+			Code: entry.Code,
+			Messages: map[string]string{
+				LocaleZhCN: entry.Message,
+				LocaleEnUS: entry.English,
+			},
+		}
+	}
+	c.JSON(http.StatusOK, util.OK(items))
+}
+
+// GetConstraints 获取创建红包的校验规则，客户端据此校验表单，与服务端规则保持一致
+// @Tags redenvelope
+// @Produce json
+// @Success 200 {object} util.Response[ConstraintsResponse]
+// @Router /api/v1/redenvelope/config [get]
+func GetConstraints(c *gin.Context) {
+	constraints, err := getConstraints(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, util.OK(constraints))
+}
+
+// ListTopSenders 获取发红包排行榜，按统计期内红包被领取的总额排序
+// @Tags redenvelope
+// @Produce json
+// @Param days query int false "统计天数，默认7天"
+// @Param limit query int false "返回条数，默认10条"
+// @Success 200 {object} util.ResponseAny
+// @Router /api/v1/redenvelope/top-senders [get]
+func ListTopSenders(c *gin.Context) {
+	var req TopSendersRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		handleBindError(c, err)
+		return
+	}
+	if req.Days == 0 {
+		req.Days = 7
+	}
+	if req.Limit == 0 {
+		req.Limit = 10
+	}
+
+	senders, err := getTopSenders(c.Request.Context(), req.Days, req.Limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, util.OK(TopSendersResponse{Days: req.Days, Senders: senders}))
+}
+
+// ListClosingSoon 获取即将截止领取且仍有剩余名额的红包，按截止时间由近到远排序，用于倒计时展示
+// @Tags redenvelope
+// @Produce json
+// @Param scope query string false "查询范围：mine 自己发出的红包（默认），public 公开红包"
+// @Param within_minutes query int false "截止时间窗口（分钟），默认60分钟"
+// @Param limit query int false "返回条数，默认20条"
+// @Success 200 {object} util.Response[[]ClosingSoonItem]
+// @Router /api/v1/redenvelope/closing-soon [get]
+func ListClosingSoon(c *gin.Context) {
+	var req ClosingSoonRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		handleBindError(c, err)
+		return
+	}
+	if req.Scope == "" {
+		req.Scope = "mine"
+	}
+	if req.WithinMinutes == 0 {
+		req.WithinMinutes = 60
+	}
+	if req.Limit == 0 {
+		req.Limit = 20
+	}
+
+	currentUser, _ := util.GetFromContext[*model.User](c, oauth.UserObjKey)
+
+	items, err := listClosingSoon(c.Request.Context(), currentUser.ID, req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, util.OK(items))
+}
+
+// Pause 暂停红包领取（仅创建者），剩余金额不退还
+// @Tags redenvelope
+// @Produce json
+// @Param id path string true "红包ID或红包码"
+// @Success 200 {object} util.ResponseAny
+// @Router /api/v1/redenvelope/{id}/pause [post]
+func Pause(c *gin.Context) {
+	handlePauseToggle(c, pauseRedEnvelope)
+}
+
+// Resume 恢复已暂停红包的领取（仅创建者）
+// @Tags redenvelope
+// @Produce json
+// @Param id path string true "红包ID或红包码"
+// @Success 200 {object} util.ResponseAny
+// @Router /api/v1/redenvelope/{id}/resume [post]
+func Resume(c *gin.Context) {
+	handlePauseToggle(c, resumeRedEnvelope)
+}
+
+// ListEvents 获取红包的状态流转记录（仅创建者）
+// @Tags redenvelope
+// @Produce json
+// @Param id path string true "红包ID或红包码"
+// @Success 200 {object} util.Response[[]model.RedEnvelopeEvent]
+// @Router /api/v1/redenvelope/{id}/events [get]
+func ListEvents(c *gin.Context) {
+	currentUser, _ := util.GetFromContext[*model.User](c, oauth.UserObjKey)
+
+	events, err := getRedEnvelopeEvents(c.Request.Context(), c.Param("id"), currentUser.ID)
+	if err != nil {
+		handleCreatorError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, util.OK(events))
+}
+
+// ListChannels 按领取渠道汇总红包的领取次数及金额（仅创建者）
+// @Tags redenvelope
+// @Produce json
+// @Param id path string true "红包ID或红包码"
+// @Success 200 {object} util.Response[[]ChannelStat]
+// @Router /api/v1/redenvelope/{id}/channels [get]
+func ListChannels(c *gin.Context) {
+	currentUser, _ := util.GetFromContext[*model.User](c, oauth.UserObjKey)
+
+	stats, err := getChannelStats(c.Request.Context(), c.Param("id"), currentUser.ID)
+	if err != nil {
+		handleCreatorError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, util.OK(stats))
+}
+
+// CanClaim 查询指定用户能否领取红包（仅创建者或管理员），用于排查用户无法领取的原因
+// @Tags redenvelope
+// @Produce json
+// @Param id path string true "红包ID或红包码"
+// @Param user_id path string true "用户ID"
+// @Success 200 {object} util.Response[EligibilityResponse]
+// @Router /api/v1/redenvelope/{id}/can-claim/{user_id} [get]
+func CanClaim(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("user_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, util.Err(InvalidUserID))
+		return
+	}
+
+	currentUser, _ := util.GetFromContext[*model.User](c, oauth.UserObjKey)
+
+	resp, err := getClaimEligibility(c.Request.Context(), currentUser, c.Param("id"), userID)
+	if err != nil {
+		if err.Error() == EligibilityUserNotFound {
+			c.JSON(http.StatusNotFound, util.Err(EligibilityUserNotFound))
+			return
+		}
+		handleCreatorError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, util.OK(resp))
+}
+
+// Stream 通过 Server-Sent Events 推送红包的实时领取动态，红包领完或过期后关闭连接
+// 连接建立时先推送 snapshot 事件，之后每次领取推送 claim 事件；Redis 未启用时返回 503，客户端应改为轮询
+// 领取者信息仅推送给创建者及已领取用户，领取金额仅推送给创建者及领取者本人，其他用户仅可见剩余个数、金额及状态
+// @Tags redenvelope
+// @Produce text/event-stream
+// @Param id path string true "红包ID或红包码"
+// @Success 200 {object} ClaimStreamEvent
+// @Router /api/v1/redenvelope/{id}/stream [get]
+func Stream(c *gin.Context) {
+	if db.Redis == nil {
+		c.JSON(http.StatusServiceUnavailable, util.Err(StreamUnavailable))
+		return
+	}
+
+	ctx := c.Request.Context()
+	redEnvelopeID, err := resolveRedEnvelopeID(ctx, c.Param("id"))
+	if err != nil {
+		switch err.Error() {
+		case InvalidRedEnvelopeID:
+			c.JSON(http.StatusBadRequest, util.Err(InvalidRedEnvelopeID))
+		case RedEnvelopeNotFound:
+			c.JSON(http.StatusNotFound, util.Err(RedEnvelopeNotFound))
+		default:
+			c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		}
+		return
+	}
+
+	// 先订阅再读取红包状态，避免遗漏两者之间的领取
+	pubsub := db.Redis.Subscribe(ctx, db.PrefixedKey(fmt.Sprintf(ClaimStreamChannelFormat, redEnvelopeID)))
+	defer pubsub.Close()
+	if _, err := pubsub.Receive(ctx); err != nil {
+		c.JSON(http.StatusServiceUnavailable, util.Err(StreamUnavailable))
+		return
+	}
+
+	var redEnvelope model.RedEnvelope
+	if err := db.DB(ctx).Where("id = ?", redEnvelopeID).First(&redEnvelope).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, util.Err(RedEnvelopeNotFound))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		return
+	}
+
+	// 私密红包仅创建者和可领取名单内的用户可查看
+	currentUser, _ := util.GetFromContext[*model.User](c, oauth.UserObjKey)
+	if currentUser.ID != redEnvelope.CreatorID {
+		if err := checkAllowList(db.DB(ctx), &redEnvelope, currentUser.ID); err != nil {
+			if err.Error() == NotInAllowList {
+				c.JSON(http.StatusForbidden, util.Err(err.Error()))
+				return
+			}
+			c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+			return
+		}
+	}
+
+	// 按查看者与红包的关系决定推送内容的可见范围，与红包详情一致
+	var claimed int64
+	if err := db.DB(ctx).Model(&model.RedEnvelopeClaim{}).
+		Where("red_envelope_id = ? AND user_id = ?", redEnvelope.ID, currentUser.ID).
+		Count(&claimed).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		return
+	}
+	role := viewerRole(&redEnvelope, currentUser, claimed > 0)
+
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+
+	writeEvent := func(event string, data any) {
+		c.SSEvent(event, data)
+		c.Writer.Flush()
+	}
+
+	writeEvent(StreamEventSnapshot, ClaimStreamEvent{
+		Event:           StreamEventSnapshot,
+		RemainingCount:  redEnvelope.RemainingCount,
+		RemainingAmount: redEnvelope.RemainingAmount,
+		Status:          redEnvelope.Status,
+	})
+	switch redEnvelope.Status {
+	case model.RedEnvelopeStatusFinished:
+		writeEvent(StreamEventFinished, ClaimStreamEvent{Event: StreamEventFinished, Status: redEnvelope.Status})
+		return
+	case model.RedEnvelopeStatusExpired:
+		writeEvent(StreamEventExpired, ClaimStreamEvent{Event: StreamEventExpired, Status: redEnvelope.Status})
+		return
+	}
+
+	// 客户端消费过慢时缓冲区满后丢弃消息，不阻塞其他订阅者
+	messages := pubsub.Channel(redis.WithChannelSize(ClaimStreamBufferSize), redis.WithChannelSendTimeout(ClaimStreamSendTimeout))
+	heartbeat := time.NewTicker(ClaimStreamHeartbeat)
+	defer heartbeat.Stop()
+	deadline := time.NewTimer(ClaimStreamMaxDuration)
+	defer deadline.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			// 客户端断开连接
+			return
+		case <-deadline.C:
+			return
+		case <-heartbeat.C:
+			writeEvent(StreamEventPing, "")
+		case msg, ok := <-messages:
+			if !ok {
+				return
+			}
+			var event ClaimStreamEvent
+			if err := json.Unmarshal([]byte(msg.Payload), &event); err != nil {
+				logger.WarnF(ctx, "红包ID:%d 解析实时领取动态失败: %v", redEnvelopeID, err)
+				continue
+			}
+			// 查看者在连接期间领取后按已领取用户推送
+			if role == ViewerRolePublic && event.Event == StreamEventClaim && event.UserID == currentUser.ID {
+				role = ViewerRoleClaimer
+			}
+			writeEvent(event.Event, projectStreamEvent(event, role, currentUser))
+			if event.Event == StreamEventFinished || event.Event == StreamEventExpired {
+				return
+			}
+		}
+	}
+}
+
+// BulkClaim 创建者为指定用户批量领取红包（如活动获奖名单），任一用户无法领取时整体失败
+// @Tags redenvelope
+// @Accept json
+// @Produce json
+// @Param id path string true "红包ID或红包码"
+// @Param request body BulkClaimRequest true "批量代领请求"
+// @Success 200 {object} util.Response[[]BulkClaimItem]
+// @Router /api/v1/redenvelope/{id}/bulk-claim [post]
+func BulkClaim(c *gin.Context) {
+	currentUser, _ := util.GetFromContext[*model.User](c, oauth.UserObjKey)
+	handleBulkClaim(c, currentUser.ID)
+}
+
+// AdminBulkClaim 管理员为指定用户批量领取红包，任一用户无法领取时整体失败
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path string true "红包ID或红包码"
+// @Param request body BulkClaimRequest true "批量代领请求"
+// @Success 200 {object} util.Response[[]BulkClaimItem]
+// @Router /api/v1/admin/red-envelopes/{id}/bulk-claim [post]
+func AdminBulkClaim(c *gin.Context) {
+	handleBulkClaim(c, 0)
+}
+
+// ListWallets 获取当前用户的专用钱包余额
+// @Tags redenvelope
+// @Produce json
+// @Success 200 {object} util.ResponseAny
+// @Router /api/v1/redenvelope/wallets [get]
+func ListWallets(c *gin.Context) {
+	currentUser, _ := util.GetFromContext[*model.User](c, oauth.UserObjKey)
+
+	wallets := make([]model.RedEnvelopeWallet, 0)
+	if err := db.DB(c.Request.Context()).
+		Where("user_id = ?", currentUser.ID).
+		Order("name ASC").
+		Find(&wallets).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, util.OK(wallets))
+}
+
+// DepositWallet 从可用余额存入专用钱包
+// @Tags redenvelope
+// @Accept json
+// @Produce json
+// @Param request body WalletTransferRequest true "存入请求"
+// @Success 200 {object} util.ResponseAny
+// @Router /api/v1/redenvelope/wallets/deposit [post]
+func DepositWallet(c *gin.Context) {
+	handleWalletTransfer(c, depositWallet)
+}
+
+// WithdrawWallet 将专用钱包余额取回可用余额
+// @Tags redenvelope
+// @Accept json
+// @Produce json
+// @Param request body WalletTransferRequest true "取回请求"
+// @Success 200 {object} util.ResponseAny
+// @Router /api/v1/redenvelope/wallets/withdraw [post]
+func WithdrawWallet(c *gin.Context) {
+	handleWalletTransfer(c, withdrawWallet)
+}
+
+// CreateRecurring 创建定期发放红包计划，到期时按参数模板从当前用户余额扣款创建红包，余额不足时跳过本次发放
+// @Tags redenvelope
+// @Accept json
+// @Produce json
+// @Param request body CreateRecurringRequest true "创建定期发放计划请求"
+// @Success 200 {object} util.Response[RecurringEnvelopeItem]
+// @Router /api/v1/redenvelope/recurring [post]
+func CreateRecurring(c *gin.Context) {
+	var req CreateRecurringRequest
+	if err := c.ShouldBindBodyWith(&req, binding.JSON); err != nil {
+		if fields := decimalFieldErrors(c, "spec", "total_amount", "per_amount", "base_amount", "min_claim_amount"); fields != nil {
+			c.JSON(http.StatusBadRequest, util.ErrFields(InvalidAmountFormat, fields))
+			return
+		}
+		handleBindError(c, err)
+		return
+	}
+
+	if err := resolvePerAmount(&req.Spec); err != nil {
+		c.JSON(http.StatusBadRequest, util.ErrFields(err.Error(), map[string]string{"spec.per_amount": err.Error()}))
+		return
+	}
+
+	currentUser, _ := util.GetFromContext[*model.User](c, oauth.UserObjKey)
+
+	if !verifyPayKey(c, currentUser, req.PayKey) {
+		return
+	}
+
+	plan, err := createRecurringEnvelope(c.Request.Context(), currentUser.ID, req.Spec, req.Cron, req.EndsAt)
+	if err != nil {
+		handleRecurringError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, util.OK(recurringEnvelopeItem(c.Request.Context(), *plan)))
+}
+
+// ListRecurring 获取当前用户的定期发放红包计划
+// @Tags redenvelope
+// @Produce json
+// @Success 200 {object} util.Response[[]RecurringEnvelopeItem]
+// @Router /api/v1/redenvelope/recurring [get]
+func ListRecurring(c *gin.Context) {
+	currentUser, _ := util.GetFromContext[*model.User](c, oauth.UserObjKey)
+
+	plans, err := listRecurringEnvelopes(c.Request.Context(), currentUser.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		return
+	}
+
+	items := make([]RecurringEnvelopeItem, len(plans))
+	for i, plan := range plans {
+		items[i] = recurringEnvelopeItem(c.Request.Context(), plan)
+	}
+	c.JSON(http.StatusOK, util.OK(items))
+}
+
+// UpdateRecurring 启用/停用定期发放红包计划或修改结束时间（仅创建者）
+// @Tags redenvelope
+// @Accept json
+// @Produce json
+// @Param id path string true "定期发放计划ID"
+// @Param request body UpdateRecurringRequest true "修改定期发放计划请求"
+// @Success 200 {object} util.Response[RecurringEnvelopeItem]
+// @Router /api/v1/redenvelope/recurring/{id} [put]
+func UpdateRecurring(c *gin.Context) {
+	planID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusNotFound, util.Err(RecurringNotFound))
+		return
+	}
+
+	var req UpdateRecurringRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleBindError(c, err)
+		return
+	}
+
+	currentUser, _ := util.GetFromContext[*model.User](c, oauth.UserObjKey)
+
+	plan, err := updateRecurringEnvelope(c.Request.Context(), planID, currentUser.ID, &req)
+	if err != nil {
+		handleRecurringError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, util.OK(recurringEnvelopeItem(c.Request.Context(), *plan)))
+}
@@ -0,0 +1,62 @@
+/*
+Copyright 2025 linux.do
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package redenvelope
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/linux-do/credit/internal/apps/oauth"
+	"github.com/linux-do/credit/internal/model"
+	"github.com/linux-do/credit/internal/util"
+)
+
+func TestCreateRecurringReportsNestedFieldPaths(t *testing.T) {
+	cases := []struct {
+		name      string
+		body      string
+		wantField string
+	}{
+		{"invalid amount", `{"spec":{"type":"fixed","total_amount":"abc","total_count":1},"cron":"0 9 * * *","pay_key":"123456"}`, "spec.total_amount"},
+		{"validation", `{"spec":{"type":"fixed","total_amount":"10","total_count":0},"cron":"0 9 * * *","pay_key":"123456"}`, "spec.total_count"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			recorder := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(recorder)
+			c.Request = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tc.body))
+			c.Set(oauth.UserObjKey, &model.User{ID: 1})
+			CreateRecurring(c)
+
+			if recorder.Code != http.StatusBadRequest {
+				t.Fatalf("status = %d, want %d; body %s", recorder.Code, http.StatusBadRequest, recorder.Body)
+			}
+			var resp util.Response[map[string]string]
+			if err := json.Unmarshal(recorder.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("decode body: %v", err)
+			}
+			if _, ok := resp.Data[tc.wantField]; !ok {
+				t.Fatalf("field errors = %v, want key %q", resp.Data, tc.wantField)
+			}
+		})
+	}
+}
@@ -17,37 +17,73 @@ limitations under the License.
 package redenvelope
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/hibiken/asynq"
+	"github.com/linux-do/credit/internal/config"
 	"github.com/linux-do/credit/internal/db"
 	"github.com/linux-do/credit/internal/logger"
 	"github.com/linux-do/credit/internal/model"
 	"github.com/linux-do/credit/internal/service"
+	"github.com/linux-do/credit/internal/task"
+	"github.com/linux-do/credit/internal/task/scheduler"
+	"github.com/linux-do/credit/internal/util"
+	"github.com/redis/go-redis/v9"
+	"github.com/shopspring/decimal"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // HandleRefundExpiredRedEnvelopes 处理过期红包退款的定时任务
 func HandleRefundExpiredRedEnvelopes(ctx context.Context, t *asynq.Task) error {
 	logger.InfoF(ctx, "开始处理过期红包退款任务")
-	refundExpiredRedEnvelopes(ctx)
+
+	// 运维暂停期间跳过本轮，过期红包保持原状，恢复后的下一轮补退
+	paused, err := model.GetBoolByKey(ctx, model.ConfigKeyRedEnvelopeRefundPaused)
+	if err != nil {
+		logger.ErrorF(ctx, "获取过期红包退款暂停开关失败: %v", err)
+		return nil
+	}
+	if paused {
+		logger.InfoF(ctx, "过期红包自动退款已暂停，跳过本轮")
+		return nil
+	}
+
+	// 宽限时间内的红包仍可领取，截止时间之后才退款
+	grace, err := getClaimGracePeriod(ctx)
+	if err != nil {
+		logger.ErrorF(ctx, "获取红包领取宽限时间失败: %v", err)
+		return nil
+	}
+
+	refundExpiredRedEnvelopes(ctx, time.Now().Add(-grace))
 	logger.InfoF(ctx, "过期红包退款任务完成")
 	return nil
 }
 
-// refundExpiredRedEnvelopes 退款过期红包
-func refundExpiredRedEnvelopes(ctx context.Context) {
+// refundExpiredRedEnvelopes 退款过期时间早于 cutoff 的红包，每批红包由有限数量的协程并发处理
+func refundExpiredRedEnvelopes(ctx context.Context, cutoff time.Time) {
 	const batchSize = 100 // 每批处理100个红包
 	var lastID uint64 = 0
-	var totalProcessed int = 0
+	var totalProcessed atomic.Int64
+	concurrency := refundConcurrency()
 
 	for {
 		// 使用游标分页查询过期红包
 		var expiredEnvelopes []model.RedEnvelope
 		if err := db.DB(ctx).
-			Where("id > ? AND status = ? AND expires_at < ? AND remaining_amount > 0", lastID, model.RedEnvelopeStatusActive, time.Now()).
+			Where("id > ? AND status IN ? AND expires_at < ? AND remaining_amount > 0 AND refunded_at IS NULL", lastID, openStatuses, cutoff).
 			Order("id ASC").
 			Limit(batchSize).
 			Find(&expiredEnvelopes).Error; err != nil {
@@ -62,72 +98,797 @@ func refundExpiredRedEnvelopes(ctx context.Context) {
 
 		logger.InfoF(ctx, "本批次找到 %d 个需要退款的过期红包", len(expiredEnvelopes))
 
-		// 处理每个过期红包
-		for _, envelope := range expiredEnvelopes {
-			if err := db.DB(ctx).Transaction(func(tx *gorm.DB) error {
-				// 更新红包状态为已过期
-				if err := tx.Model(&model.RedEnvelope{}).
-					Where("id = ? AND status = ?", envelope.ID, model.RedEnvelopeStatusActive).
-					Updates(map[string]interface{}{
-						"status":           model.RedEnvelopeStatusExpired,
-						"remaining_amount": 0,
-						"remaining_count":  0,
-					}).Error; err != nil {
-					return err
-				}
-
-				// 退还剩余金额给创建者
-				if envelope.RemainingAmount.IsPositive() {
-					// 增加余额并减少total_payment
-					if err := service.UpdateBalance(tx, service.BalanceUpdateOptions{
-						UserID:     envelope.CreatorID,
-						Amount:     envelope.RemainingAmount.Neg(),
-						Operation:  service.BalanceDeduct,
-						TotalField: "total_payment",
-					}); err != nil {
-						return err
-					}
-
-					// 创建退款订单记录
-					remarkMsg := fmt.Sprintf("红包过期退款，红包ID:%d", envelope.ID)
-					if envelope.Greeting != "" {
-						remarkMsg = fmt.Sprintf("%s，祝福语: %s", remarkMsg, envelope.Greeting)
-					}
-
-					order := model.Order{
-						OrderName:   "红包退款",
-						PayerUserID: 0,
-						PayeeUserID: envelope.CreatorID,
-						Amount:      envelope.RemainingAmount,
-						Status:      model.OrderStatusSuccess,
-						Type:        model.OrderTypeRedEnvelopeRefund,
-						Remark:      remarkMsg,
-						TradeTime:   time.Now(),
-						ExpiresAt:   time.Now().Add(24 * time.Hour),
-					}
-
-					if err := tx.Create(&order).Error; err != nil {
-						return err
-					}
-
-					logger.InfoF(ctx, "红包ID:%d 退款成功，金额:%s", envelope.ID, envelope.RemainingAmount.String())
-				}
+		// 同一批次内红包ID互不相同，且每个红包在事务内加行锁后重新校验状态，并发处理不会重复退款
+		processBounded(expiredEnvelopes, concurrency, func(envelope model.RedEnvelope) {
+			if err := refundExpiredRedEnvelope(ctx, envelope, cutoff); err != nil {
+				logger.ErrorF(ctx, "红包ID:%d 退款失败: %v", envelope.ID, err)
+				return
+			}
+			totalProcessed.Add(1)
+			removeClaimGate(ctx, envelope.ID)
+			publishStreamEvent(ctx, envelope.ID, ClaimStreamEvent{Event: StreamEventExpired, Status: model.RedEnvelopeStatusExpired})
+		})
 
+		// 更新游标
+		lastID = expiredEnvelopes[len(expiredEnvelopes)-1].ID
+	}
+
+	if processed := totalProcessed.Load(); processed > 0 {
+		logger.InfoF(ctx, "退款任务完成，共处理 %d 个过期红包", processed)
+	} else {
+		logger.InfoF(ctx, "没有需要退款的过期红包")
+	}
+}
+
+// processBounded 以最多 concurrency 个协程并发处理一批红包，全部处理完成后返回；concurrency 为1时即顺序处理
+func processBounded(envelopes []model.RedEnvelope, concurrency int, process func(envelope model.RedEnvelope)) {
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, max(concurrency, 1))
+	for _, envelope := range envelopes {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(envelope model.RedEnvelope) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			process(envelope)
+		}(envelope)
+	}
+	wg.Wait()
+}
+
+// refundConcurrency 退款任务并发数，未配置时顺序处理，且不超过数据库最大连接数的一半
+func refundConcurrency() int {
+	concurrency := config.Config.RedEnvelope.RefundConcurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	if maxOpen := config.Config.Database.MaxOpenConn; maxOpen > 0 && concurrency > maxOpen/2 {
+		concurrency = max(maxOpen/2, 1)
+	}
+	return concurrency
+}
+
+// refundExpiredRedEnvelope 在独立事务中将单个过期红包标记为已过期并退还剩余金额
+func refundExpiredRedEnvelope(ctx context.Context, envelope model.RedEnvelope, cutoff time.Time) error {
+	return db.DB(ctx).Transaction(func(tx *gorm.DB) error {
+		// 锁定并重新读取红包，避免退还宽限期内刚被领取的金额；已记录退款时间的红包不再处理，中断后重跑不会重复退款
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND status IN ? AND expires_at < ? AND refunded_at IS NULL", envelope.ID, openStatuses, cutoff).
+			First(&envelope).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil
+			}
+			return err
+		}
+
+		return refundRemaining(ctx, tx, &envelope, "红包过期退款")
+	})
+}
+
+// refundRemaining 将已锁定的红包置为已过期并记录退款时间，剩余金额退还创建者并创建退款订单
+func refundRemaining(ctx context.Context, tx *gorm.DB, envelope *model.RedEnvelope, reason string) error {
+	// 更新红包状态为已过期并记录退款时间
+	if err := transitionStatus(tx, envelope, model.RedEnvelopeStatusExpired, map[string]interface{}{
+		"remaining_amount": 0,
+		"remaining_count":  0,
+		"refunded_at":      time.Now(),
+	}, statusEvent{ActorType: model.RedEnvelopeEventActorSystem, Reason: reason}); err != nil {
+		return err
+	}
+
+	// 退还剩余金额给创建者
+	if envelope.RemainingAmount.IsPositive() {
+		if err := refundToCreator(tx, envelope, envelope.RemainingAmount); err != nil {
+			return err
+		}
+
+		// 创建退款订单记录
+		order := model.Order{
+			OrderName:     "红包退款",
+			PayerUserID:   0,
+			PayeeUserID:   envelope.CreatorID,
+			Amount:        envelope.RemainingAmount,
+			Status:        model.OrderStatusSuccess,
+			Type:          model.OrderTypeRedEnvelopeRefund,
+			Remark:        refundOrderRemark(envelope, envelope.RemainingAmount, reason),
+			RedEnvelopeID: &envelope.ID,
+			TradeTime:     time.Now(),
+			ExpiresAt:     time.Now().Add(24 * time.Hour),
+		}
+
+		if err := tx.Create(markTestOrder(envelope, &order)).Error; err != nil {
+			return err
+		}
+
+		logger.InfoF(ctx, "红包ID:%d 退款成功，金额:%s", envelope.ID, envelope.RemainingAmount.String())
+	}
+
+	return nil
+}
+
+// HandleBannedCreatorEnvelopes 按系统配置暂停或退款已封禁创建者的进行中红包
+// 载荷指定 creator_id 时仅处理该创建者（管理员封禁用户时触发），否则扫描全部已封禁创建者
+func HandleBannedCreatorEnvelopes(ctx context.Context, t *asynq.Task) error {
+	var sc model.SystemConfig
+	if err := sc.GetByKey(ctx, model.ConfigKeyRedEnvelopeBannedCreatorAction); err != nil {
+		return err
+	}
+	action := strings.ToLower(strings.TrimSpace(sc.Value))
+	if action != BannedCreatorActionPause && action != BannedCreatorActionRefund {
+		logger.InfoF(ctx, "封禁创建者红包处理方式为 %s，跳过", sc.Value)
+		return nil
+	}
+	if action == BannedCreatorActionRefund {
+		// 自动退款暂停期间同样不退款，恢复后由下一轮处理
+		paused, err := model.GetBoolByKey(ctx, model.ConfigKeyRedEnvelopeRefundPaused)
+		if err != nil {
+			return err
+		}
+		if paused {
+			logger.InfoF(ctx, "过期红包自动退款已暂停，跳过封禁创建者红包退款")
+			return nil
+		}
+	}
+
+	var payload struct {
+		CreatorID uint64 `json:"creator_id,string"`
+	}
+	if len(t.Payload()) > 0 {
+		if err := json.Unmarshal(t.Payload(), &payload); err != nil {
+			return err
+		}
+	}
+
+	// 暂停仅处理进行中的红包，退款同时处理已暂停的红包
+	statuses := openStatuses
+	if action == BannedCreatorActionPause {
+		statuses = []model.RedEnvelopeStatus{model.RedEnvelopeStatusActive}
+	}
+
+	const batchSize = 100
+	var lastID uint64 = 0
+	var totalHandled, totalFailed int
+	for {
+		query := db.DB(ctx).Model(&model.RedEnvelope{}).
+			Where("id > ? AND status IN ? AND refunded_at IS NULL", lastID, statuses).
+			Where("creator_id IN (SELECT id FROM users WHERE is_active = ?)", false)
+		if payload.CreatorID != 0 {
+			query = query.Where("creator_id = ?", payload.CreatorID)
+		}
+		var envelopeIDs []uint64
+		if err := query.Order("id ASC").Limit(batchSize).Pluck("id", &envelopeIDs).Error; err != nil {
+			logger.ErrorF(ctx, "查询已封禁创建者的红包失败: %v", err)
+			return err
+		}
+
+		if len(envelopeIDs) == 0 {
+			break
+		}
+		lastID = envelopeIDs[len(envelopeIDs)-1]
+
+		for _, envelopeID := range envelopeIDs {
+			if err := handleBannedCreatorEnvelope(ctx, envelopeID, statuses, action); err != nil {
+				totalFailed++
+				logger.ErrorF(ctx, "红包ID:%d 封禁创建者处理失败: %v", envelopeID, err)
+				continue
+			}
+			totalHandled++
+		}
+	}
+
+	logger.InfoF(ctx, "封禁创建者红包处理完成，处理方式: %s，成功 %d 个，失败 %d 个", action, totalHandled, totalFailed)
+	return nil
+}
+
+// handleBannedCreatorEnvelope 锁定单个红包后暂停领取或退还剩余金额，状态已变化的红包跳过
+func handleBannedCreatorEnvelope(ctx context.Context, envelopeID uint64, statuses []model.RedEnvelopeStatus, action string) error {
+	return db.DB(ctx).Transaction(func(tx *gorm.DB) error {
+		var envelope model.RedEnvelope
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND status IN ? AND refunded_at IS NULL", envelopeID, statuses).
+			First(&envelope).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
 				return nil
-			}); err != nil {
-				logger.ErrorF(ctx, "红包ID:%d 退款失败: %v", envelope.ID, err)
-			} else {
-				totalProcessed++
 			}
+			return err
+		}
 
-			// 更新游标
-			lastID = envelope.ID
+		if action == BannedCreatorActionPause {
+			return transitionStatus(tx, &envelope, model.RedEnvelopeStatusPaused, map[string]interface{}{
+				"paused_at": time.Now(),
+			}, statusEvent{ActorType: model.RedEnvelopeEventActorSystem, Reason: "创建者账户已封禁，暂停领取"})
 		}
+		return refundRemaining(ctx, tx, &envelope, "创建者账户已封禁，红包退款")
+	})
+}
+
+// HandleArchiveRedEnvelopeClaims 处理红包领取记录归档的定时任务
+func HandleArchiveRedEnvelopeClaims(ctx context.Context, t *asynq.Task) error {
+	retentionDays, err := model.GetIntByKey(ctx, model.ConfigKeyRedEnvelopeRetentionDays)
+	if err != nil {
+		return err
+	}
+	if retentionDays <= 0 {
+		logger.InfoF(ctx, "红包领取记录保留期未配置，跳过归档")
+		return nil
 	}
 
-	if totalProcessed > 0 {
-		logger.InfoF(ctx, "退款任务完成，共处理 %d 个过期红包", totalProcessed)
+	dryRun, err := model.GetBoolByKey(ctx, model.ConfigKeyRedEnvelopeRetentionDryRun)
+	if err != nil {
+		return err
+	}
+
+	logger.InfoF(ctx, "开始归档红包领取记录，保留天数: %d，演练模式: %t", retentionDays, dryRun)
+	return archiveRedEnvelopeClaims(ctx, time.Now().AddDate(0, 0, -retentionDays), dryRun)
+}
+
+// archiveRedEnvelopeClaims 删除在 cutoff 之前已结束的红包的领取记录，并标记红包已归档
+// 订单记录不受影响，保证账务可追溯
+func archiveRedEnvelopeClaims(ctx context.Context, cutoff time.Time, dryRun bool) error {
+	const batchSize = 100
+	var lastID uint64 = 0
+	var totalEnvelopes int = 0
+	var totalClaims int64 = 0
+
+	for {
+		var envelopeIDs []uint64
+		if err := db.DB(ctx).Model(&model.RedEnvelope{}).
+			Where("id > ? AND status NOT IN ? AND claims_archived_at IS NULL AND updated_at < ?", lastID, openStatuses, cutoff).
+			Order("id ASC").
+			Limit(batchSize).
+			Pluck("id", &envelopeIDs).Error; err != nil {
+			logger.ErrorF(ctx, "查询待归档红包失败: %v", err)
+			return err
+		}
+
+		if len(envelopeIDs) == 0 {
+			break
+		}
+		lastID = envelopeIDs[len(envelopeIDs)-1]
+
+		if dryRun {
+			var claimCount int64
+			if err := db.DB(ctx).Model(&model.RedEnvelopeClaim{}).
+				Where("red_envelope_id IN ?", envelopeIDs).
+				Count(&claimCount).Error; err != nil {
+				return err
+			}
+			totalEnvelopes += len(envelopeIDs)
+			totalClaims += claimCount
+			continue
+		}
+
+		if err := db.DB(ctx).Transaction(func(tx *gorm.DB) error {
+			result := tx.Where("red_envelope_id IN ?", envelopeIDs).Delete(&model.RedEnvelopeClaim{})
+			if result.Error != nil {
+				return result.Error
+			}
+			totalClaims += result.RowsAffected
+
+			return tx.Model(&model.RedEnvelope{}).
+				Where("id IN ?", envelopeIDs).
+				Update("claims_archived_at", time.Now()).Error
+		}); err != nil {
+			logger.ErrorF(ctx, "归档红包领取记录失败，批次末尾红包ID:%d: %v", lastID, err)
+			return err
+		}
+		totalEnvelopes += len(envelopeIDs)
+	}
+
+	if dryRun {
+		logger.InfoF(ctx, "[演练] 共 %d 个红包的 %d 条领取记录将被归档", totalEnvelopes, totalClaims)
 	} else {
-		logger.InfoF(ctx, "没有需要退款的过期红包")
+		logger.InfoF(ctx, "归档完成，共处理 %d 个红包，删除 %d 条领取记录", totalEnvelopes, totalClaims)
+	}
+	return nil
+}
+
+// expiryNotifyPayload 红包即将过期通知内容
+type expiryNotifyPayload struct {
+	Event           string          `json:"event"`
+	RedEnvelopeID   uint64          `json:"red_envelope_id,string"`
+	CreatorID       uint64          `json:"creator_id,string"`
+	RemainingCount  int             `json:"remaining_count"`
+	RemainingAmount decimal.Decimal `json:"remaining_amount"`
+	ExpiresAt       time.Time       `json:"expires_at"`
+}
+
+// HandleNotifyExpiringRedEnvelopes 扫描即将过期且未领完的红包，为每个红包下发一次过期提醒
+func HandleNotifyExpiringRedEnvelopes(ctx context.Context, t *asynq.Task) error {
+	if config.Config.RedEnvelope.ExpiryNotifyURL == "" {
+		logger.InfoF(ctx, "红包过期提醒地址未配置，跳过")
+		return nil
+	}
+
+	noticeMinutes, err := model.GetIntByKey(ctx, model.ConfigKeyRedEnvelopeExpiryNoticeMinutes)
+	if err != nil {
+		return err
+	}
+	if noticeMinutes <= 0 {
+		return nil
+	}
+
+	const batchSize = 100
+	var lastID uint64 = 0
+	var totalNotified int = 0
+	deadline := time.Now().Add(time.Duration(noticeMinutes) * time.Minute)
+
+	for {
+		var envelopeIDs []uint64
+		if err := db.DB(ctx).Model(&model.RedEnvelope{}).
+			Where("id > ? AND status = ? AND remaining_count > 0 AND expiry_notified_at IS NULL AND expires_at > ? AND expires_at <= ?",
+				lastID, model.RedEnvelopeStatusActive, time.Now(), deadline).
+			Order("id ASC").
+			Limit(batchSize).
+			Pluck("id", &envelopeIDs).Error; err != nil {
+			logger.ErrorF(ctx, "查询即将过期红包失败: %v", err)
+			return err
+		}
+
+		if len(envelopeIDs) == 0 {
+			break
+		}
+		lastID = envelopeIDs[len(envelopeIDs)-1]
+
+		for _, envelopeID := range envelopeIDs {
+			// 先标记再下发，条件更新保证同一红包只通知一次
+			result := db.DB(ctx).Model(&model.RedEnvelope{}).
+				Where("id = ? AND expiry_notified_at IS NULL", envelopeID).
+				Update("expiry_notified_at", time.Now())
+			if result.Error != nil {
+				logger.ErrorF(ctx, "红包ID:%d 标记过期提醒失败: %v", envelopeID, result.Error)
+				continue
+			}
+			if result.RowsAffected == 0 {
+				continue
+			}
+
+			payload, _ := json.Marshal(map[string]interface{}{"red_envelope_id": envelopeID})
+			if _, err := scheduler.AsynqClient.Enqueue(
+				asynq.NewTask(task.RedEnvelopeExpiryNotifyTask, payload),
+				asynq.Queue(task.QueueWebhook),
+				asynq.MaxRetry(5),
+				asynq.Timeout(30*time.Second),
+			); err != nil {
+				// 下发失败时撤销标记，等待下次扫描重试
+				logger.ErrorF(ctx, "红包ID:%d 下发过期提醒失败: %v", envelopeID, err)
+				db.DB(ctx).Model(&model.RedEnvelope{}).Where("id = ?", envelopeID).Update("expiry_notified_at", nil)
+				continue
+			}
+			totalNotified++
+		}
+	}
+
+	logger.InfoF(ctx, "红包过期提醒任务完成，共下发 %d 个提醒", totalNotified)
+	return nil
+}
+
+// HandleRedEnvelopeExpiryNotify 推送单个红包的即将过期通知
+func HandleRedEnvelopeExpiryNotify(ctx context.Context, t *asynq.Task) error {
+	var payload struct {
+		RedEnvelopeID uint64 `json:"red_envelope_id"`
+	}
+	if err := json.Unmarshal(t.Payload(), &payload); err != nil {
+		logger.ErrorF(ctx, "解析红包过期提醒任务参数失败: %v", err)
+		return fmt.Errorf("解析任务参数失败: %w", err)
+	}
+
+	var redEnvelope model.RedEnvelope
+	if err := db.DB(ctx).Where("id = ?", payload.RedEnvelopeID).First(&redEnvelope).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			logger.ErrorF(ctx, "红包ID:%d 不存在，跳过过期提醒", payload.RedEnvelopeID)
+			return nil
+		}
+		return fmt.Errorf("查询红包失败: %w", err)
+	}
+
+	// 提醒下发后红包已领完或已过期，无需再通知
+	if redEnvelope.Status != model.RedEnvelopeStatusActive || redEnvelope.RemainingCount <= 0 {
+		return nil
+	}
+
+	body, err := json.Marshal(expiryNotifyPayload{
+		Event:           "red_envelope.expiring",
+		RedEnvelopeID:   redEnvelope.ID,
+		CreatorID:       redEnvelope.CreatorID,
+		RemainingCount:  redEnvelope.RemainingCount,
+		RemainingAmount: redEnvelope.RemainingAmount,
+		ExpiresAt:       redEnvelope.ExpiresAt,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := postNotification(ctx, config.Config.RedEnvelope.ExpiryNotifyURL, body); err != nil {
+		retried, _ := asynq.GetRetryCount(ctx)
+		logger.ErrorF(ctx, "红包ID:%d 过期提醒推送失败，重试次数[%d]: %v", redEnvelope.ID, retried+1, err)
+		return err
+	}
+
+	logger.InfoF(ctx, "红包ID:%d 过期提醒推送成功", redEnvelope.ID)
+	return nil
+}
+
+// postNotification 向通知地址推送 JSON，配置 webhook_secret 时附带签名，非 2xx 响应视为失败
+func postNotification(ctx context.Context, url string, body []byte) error {
+	headers := map[string]string{
+		"Content-Type": "application/json",
+		"User-Agent":   "LinuxDo-Credit/1.0",
+	}
+	if config.Config.RedEnvelope.WebhookSecret != "" {
+		headers[WebhookSignatureHeader] = signWebhookBody(config.Config.RedEnvelope.WebhookSecret, body)
+	}
+
+	resp, err := util.Request(ctx, http.MethodPost, url, bytes.NewReader(body), headers, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("通知推送返回异常状态码: %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// HandleAwardRedEnvelopeJackpot 处理红包奖池发放的定时任务，奖池为空或近期无人领取红包时跳过
+func HandleAwardRedEnvelopeJackpot(ctx context.Context, t *asynq.Task) error {
+	windowHours, err := model.GetIntByKey(ctx, model.ConfigKeyRedEnvelopeJackpotWindowHours)
+	if err != nil {
+		return err
+	}
+	if windowHours <= 0 {
+		logger.InfoF(ctx, "红包奖池抽奖时间范围未配置，跳过发放")
+		return nil
+	}
+	since := time.Now().Add(-time.Duration(windowHours) * time.Hour)
+
+	var winnerID uint64
+	var awarded decimal.Decimal
+	if err := db.DB(ctx).Transaction(func(tx *gorm.DB) error {
+		var jackpot model.RedEnvelopeJackpot
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ?", model.RedEnvelopeJackpotPoolID).First(&jackpot).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil
+			}
+			return err
+		}
+		if !jackpot.Balance.IsPositive() {
+			return nil
+		}
+
+		// 从时间范围内领取过红包的有效用户中随机抽取一位，每人中奖概率相同，测试红包的领取不参与
+		var winners []uint64
+		if err := tx.Raw(`SELECT user_id FROM (
+			SELECT DISTINCT red_envelope_claims.user_id FROM red_envelope_claims
+			JOIN users ON users.id = red_envelope_claims.user_id
+			JOIN red_envelopes ON red_envelopes.id = red_envelope_claims.red_envelope_id
+			WHERE red_envelope_claims.claimed_at >= ? AND users.is_active = ? AND red_envelopes.test_mode = ?
+		) AS candidates ORDER BY random() LIMIT 1`, since, true, false).Scan(&winners).Error; err != nil {
+			return err
+		}
+		if len(winners) == 0 {
+			return nil
+		}
+		winnerID, awarded = winners[0], jackpot.Balance
+
+		if err := tx.Model(&model.RedEnvelopeJackpot{}).Where("id = ?", jackpot.ID).
+			Update("balance", decimal.Zero).Error; err != nil {
+			return err
+		}
+		if err := service.UpdateBalance(tx, service.BalanceUpdateOptions{
+			UserID:     winnerID,
+			Amount:     awarded,
+			Operation:  service.BalanceAdd,
+			TotalField: "total_receive",
+		}); err != nil {
+			return err
+		}
+		return tx.Create(&model.Order{
+			OrderName:   "红包奖池",
+			PayerUserID: 0,
+			PayeeUserID: winnerID,
+			Amount:      awarded,
+			Status:      model.OrderStatusSuccess,
+			Type:        model.OrderTypeRedEnvelopeJackpot,
+			Remark:      fmt.Sprintf("获得红包奖池，金额: %s", util.FormatAmount(awarded)),
+			TradeTime:   time.Now(),
+			ExpiresAt:   time.Now().Add(24 * time.Hour),
+		}).Error
+	}); err != nil {
+		logger.ErrorF(ctx, "发放红包奖池失败: %v", err)
+		return err
+	}
+
+	if winnerID == 0 {
+		logger.InfoF(ctx, "红包奖池为空或近%d小时内无人领取红包，跳过发放", windowHours)
+		return nil
+	}
+	logger.InfoF(ctx, "红包奖池已发放，用户ID:%d，金额: %s", winnerID, awarded.String())
+	return nil
+}
+
+// recurringNotifyPayload 定期发放红包失败通知的推送内容
+type recurringNotifyPayload struct {
+	Event       string     `json:"event"`
+	RecurringID uint64     `json:"recurring_id,string"`
+	CreatorID   uint64     `json:"creator_id,string"`
+	Reason      string     `json:"reason"`
+	RunAt       time.Time  `json:"run_at"`
+	NextRunAt   *time.Time `json:"next_run_at,omitempty"`
+}
+
+// HandleRunRecurringEnvelopes 按定期发放计划创建到期的红包，先停用已到结束时间的计划
+func HandleRunRecurringEnvelopes(ctx context.Context, t *asynq.Task) error {
+	now := time.Now()
+	if err := db.DB(ctx).Model(&model.RecurringEnvelope{}).
+		Where("enabled = ? AND ends_at IS NOT NULL AND ends_at <= ?", true, now).
+		Update("enabled", false).Error; err != nil {
+		logger.ErrorF(ctx, "停用已结束的定期发放计划失败: %v", err)
+		return err
+	}
+
+	var lastID uint64 = 0
+	var totalCreated, totalFailed int
+	for {
+		var plans []model.RecurringEnvelope
+		if err := db.DB(ctx).
+			Where("id > ? AND enabled = ? AND next_run_at <= ?", lastID, true, now).
+			Where("creator_id IN (SELECT id FROM users WHERE is_active = ?)", true).
+			Order("id ASC").
+			Limit(RecurringBatchSize).
+			Find(&plans).Error; err != nil {
+			logger.ErrorF(ctx, "查询到期的定期发放计划失败: %v", err)
+			return err
+		}
+
+		if len(plans) == 0 {
+			break
+		}
+		lastID = plans[len(plans)-1].ID
+
+		for _, plan := range plans {
+			created, err := runRecurringEnvelope(ctx, plan, now)
+			if err != nil {
+				totalFailed++
+				logger.WarnF(ctx, "定期发放计划ID:%d 创建红包失败: %v", plan.ID, err)
+				continue
+			}
+			if created {
+				totalCreated++
+			}
+		}
+	}
+
+	logger.InfoF(ctx, "定期发放红包任务完成，创建 %d 个红包，失败 %d 个", totalCreated, totalFailed)
+	return nil
+}
+
+// runRecurringEnvelope 执行一次定期发放，先推进下次发放时间抢占本次执行，再按参数模板创建红包
+// 创建失败（如余额不足）时跳过本次发放，记录原因并通知创建者；返回是否创建了红包
+func runRecurringEnvelope(ctx context.Context, plan model.RecurringEnvelope, now time.Time) (bool, error) {
+	updates := map[string]interface{}{"last_run_at": now}
+	var nextRunAt *time.Time
+	schedule, err := parseRecurringSchedule(plan.Cron, now)
+	if err == nil {
+		next := schedule.Next(now)
+		nextRunAt = &next
+		updates["next_run_at"] = next
+		if plan.EndsAt != nil && !next.Before(*plan.EndsAt) {
+			updates["enabled"] = false
+		}
+	} else {
+		// 表达式已不满足校验规则（如最短间隔调整）时停用计划
+		updates["enabled"] = false
+	}
+
+	// 条件更新保证同一次发放只被一个任务执行
+	result := db.DB(ctx).Model(&model.RecurringEnvelope{}).
+		Where("id = ? AND enabled = ? AND next_run_at = ?", plan.ID, true, plan.NextRunAt).
+		Updates(updates)
+	if result.Error != nil {
+		return false, result.Error
 	}
+	if result.RowsAffected == 0 {
+		return false, nil
+	}
+
+	if err == nil {
+		var spec EnvelopeSpec
+		if err = json.Unmarshal([]byte(plan.Spec), &spec); err == nil {
+			var redEnvelope *model.RedEnvelope
+			if redEnvelope, err = CreateRedEnvelope(ctx, spec.createParams(plan.CreatorID)); err == nil {
+				db.DB(ctx).Model(&model.RecurringEnvelope{}).Where("id = ?", plan.ID).
+					Updates(map[string]interface{}{"last_red_envelope_id": redEnvelope.ID, "last_error": ""})
+				return true, nil
+			}
+		}
+	}
+
+	reason := err.Error()
+	if runes := []rune(reason); len(runes) > 255 {
+		reason = string(runes[:255])
+	}
+	db.DB(ctx).Model(&model.RecurringEnvelope{}).Where("id = ?", plan.ID).Update("last_error", reason)
+	enqueueRecurringNotify(ctx, recurringNotifyPayload{
+		Event:       "red_envelope.recurring_failed",
+		RecurringID: plan.ID,
+		CreatorID:   plan.CreatorID,
+		Reason:      reason,
+		RunAt:       now,
+		NextRunAt:   nextRunAt,
+	})
+	return false, err
+}
+
+// enqueueRecurringNotify 下发定期发放失败通知，未配置通知地址时跳过
+func enqueueRecurringNotify(ctx context.Context, payload recurringNotifyPayload) {
+	if config.Config.RedEnvelope.RecurringHookURL == "" {
+		return
+	}
+	data, _ := json.Marshal(payload)
+	if _, err := scheduler.AsynqClient.Enqueue(
+		asynq.NewTask(task.RecurringEnvelopeNotifyTask, data),
+		asynq.Queue(task.QueueWebhook),
+		asynq.MaxRetry(5),
+		asynq.Timeout(30*time.Second),
+	); err != nil {
+		logger.ErrorF(ctx, "定期发放计划ID:%d 下发失败通知失败: %v", payload.RecurringID, err)
+	}
+}
+
+// HandleRecurringEnvelopeNotify 推送定期发放失败通知
+func HandleRecurringEnvelopeNotify(ctx context.Context, t *asynq.Task) error {
+	if config.Config.RedEnvelope.RecurringHookURL == "" {
+		return nil
+	}
+	if err := postNotification(ctx, config.Config.RedEnvelope.RecurringHookURL, t.Payload()); err != nil {
+		retried, _ := asynq.GetRetryCount(ctx)
+		logger.ErrorF(ctx, "定期发放失败通知推送失败，重试次数[%d]: %v", retried+1, err)
+		return err
+	}
+	return nil
+}
+
+// HandleRefreshRedEnvelopeLiability 定期重新统计全平台待领取总额缓存的定时任务，Redis 未启用时跳过
+// 领取和退款不会实时扣减缓存，定期重算使创建红包时的总额上限检查及监控指标不依赖缓存过期才得到校正
+func HandleRefreshRedEnvelopeLiability(ctx context.Context, t *asynq.Task) error {
+	if db.Redis == nil {
+		logger.InfoF(ctx, "Redis 未启用，跳过红包待领取总额重算任务")
+		return nil
+	}
+
+	liability, err := refreshOutstandingLiability(ctx)
+	if err != nil {
+		logger.ErrorF(ctx, "重新统计红包待领取总额失败: %v", err)
+		return err
+	}
+	logger.InfoF(ctx, "红包待领取总额重算完成: %s", liability.String())
+	return nil
+}
+
+// envelopeKeyFormats 按红包ID存储的 Redis key 格式，生命周期跟随红包，红包结束后即为残留
+var envelopeKeyFormats = []string{ClaimGateKeyFormat, ReservedSlotsKeyFormat}
+
+// HandleCleanupRedEnvelopeKeys 清理红包相关残留 Redis key 的定时任务，Redis 未启用时跳过
+func HandleCleanupRedEnvelopeKeys(ctx context.Context, t *asynq.Task) error {
+	if db.Redis == nil {
+		logger.InfoF(ctx, "Redis 未启用，跳过红包残留 key 清理任务")
+		return nil
+	}
+
+	logger.InfoF(ctx, "开始清理红包残留 Redis key")
+	removed, err := cleanupStaleKeys(ctx)
+	if err != nil {
+		logger.ErrorF(ctx, "清理红包残留 Redis key 失败，已删除 %d 个: %v", removed, err)
+		return err
+	}
+	logger.InfoF(ctx, "红包残留 Redis key 清理完成，共删除 %d 个", removed)
+	return nil
+}
+
+// cleanupStaleKeys 扫描红包前缀下的全部 key 并删除残留，返回删除数量
+// 按红包ID存储的 key 在红包结束或不存在时删除；其余 key 写入时均带过期时间，未设置过期时间的
+// （如 INCR 与 EXPIRE 之间进程退出）永远不会自动失效，视为残留删除
+func cleanupStaleKeys(ctx context.Context) (int, error) {
+	pattern := db.PrefixedKey(KeyNamespace) + "*"
+	removed := 0
+	var cursor uint64
+	for {
+		keys, next, err := db.Redis.Scan(ctx, cursor, pattern, StaleKeyScanCount).Result()
+		if err != nil {
+			return removed, err
+		}
+		count, err := cleanupStaleKeyBatch(ctx, keys)
+		removed += count
+		if err != nil {
+			return removed, err
+		}
+		if cursor = next; cursor == 0 {
+			return removed, nil
+		}
+	}
+}
+
+// cleanupStaleKeyBatch 判断并删除一批 key 中的残留
+func cleanupStaleKeyBatch(ctx context.Context, keys []string) (int, error) {
+	if len(keys) == 0 {
+		return 0, nil
+	}
+
+	pipe := db.Redis.Pipeline()
+	ttls := make([]*redis.DurationCmd, len(keys))
+	for i, key := range keys {
+		ttls[i] = pipe.TTL(ctx, key)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, err
+	}
+
+	basePrefix := db.PrefixedKey("")
+	var stale []string
+	envelopeKeys := make(map[uint64][]string)
+	for i, key := range keys {
+		if id, ok := envelopeKeyID(strings.TrimPrefix(key, basePrefix)); ok {
+			envelopeKeys[id] = append(envelopeKeys[id], key)
+			continue
+		}
+		// TTL 为 -1 表示未设置过期时间，-2 表示 key 已不存在
+		if ttls[i].Val() == -1 {
+			stale = append(stale, key)
+		}
+	}
+
+	if len(envelopeKeys) > 0 {
+		ids := make([]uint64, 0, len(envelopeKeys))
+		for id := range envelopeKeys {
+			ids = append(ids, id)
+		}
+		var openIDs []uint64
+		if err := db.DB(ctx).Model(&model.RedEnvelope{}).
+			Where("id IN ? AND status IN ?", ids, openStatuses).
+			Pluck("id", &openIDs).Error; err != nil {
+			return 0, err
+		}
+		for id, idKeys := range envelopeKeys {
+			if !slices.Contains(openIDs, id) {
+				stale = append(stale, idKeys...)
+			}
+		}
+	}
+
+	if len(stale) == 0 {
+		return 0, nil
+	}
+	if err := db.Redis.Unlink(ctx, stale...).Err(); err != nil {
+		return 0, err
+	}
+
+	families := make(map[string]int)
+	for _, key := range stale {
+		families[staleKeyFamily(strings.TrimPrefix(key, basePrefix))]++
+	}
+	for family, count := range families {
+		recordStaleKeysRemoved(ctx, family, count)
+	}
+	return len(stale), nil
+}
+
+// envelopeKeyID 解析按红包ID存储的 key（不含全局前缀），返回红包ID
+func envelopeKeyID(key string) (uint64, bool) {
+	for _, format := range envelopeKeyFormats {
+		if rest, ok := strings.CutPrefix(key, strings.TrimSuffix(format, "%d")); ok {
+			if id, err := strconv.ParseUint(rest, 10, 64); err == nil {
+				return id, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// staleKeyFamily 返回 key（不含全局前缀）在红包前缀后的第一段作为指标分类，如 claim_gate、detail
+func staleKeyFamily(key string) string {
+	family, _, _ := strings.Cut(strings.TrimPrefix(key, KeyNamespace), ":")
+	return family
 }
@@ -0,0 +1,84 @@
+/*
+Copyright 2025 linux.do
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package redenvelope
+
+import (
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/linux-do/credit/internal/model"
+)
+
+// expiredBatch 构造一批ID互不相同的过期红包
+func expiredBatch(size int) []model.RedEnvelope {
+	envelopes := make([]model.RedEnvelope, size)
+	for i := range envelopes {
+		envelopes[i].ID = uint64(i + 1)
+	}
+	return envelopes
+}
+
+func TestProcessBoundedRespectsConcurrency(t *testing.T) {
+	for _, concurrency := range []int{0, 1, 4} {
+		t.Run(fmt.Sprintf("concurrency=%d", concurrency), func(t *testing.T) {
+			var inFlight, peak, processed atomic.Int64
+			seen := make([]atomic.Int32, 50)
+			processBounded(expiredBatch(len(seen)), concurrency, func(envelope model.RedEnvelope) {
+				current := inFlight.Add(1)
+				for {
+					old := peak.Load()
+					if current <= old || peak.CompareAndSwap(old, current) {
+						break
+					}
+				}
+				time.Sleep(time.Millisecond)
+				seen[envelope.ID-1].Add(1)
+				processed.Add(1)
+				inFlight.Add(-1)
+			})
+
+			if got := processed.Load(); got != int64(len(seen)) {
+				t.Fatalf("processed = %d, want %d", got, len(seen))
+			}
+			for i := range seen {
+				if n := seen[i].Load(); n != 1 {
+					t.Fatalf("envelope %d processed %d times, want 1", i+1, n)
+				}
+			}
+			if limit := int64(max(concurrency, 1)); peak.Load() > limit {
+				t.Fatalf("peak concurrency = %d, want at most %d", peak.Load(), limit)
+			}
+		})
+	}
+}
+
+// BenchmarkProcessBounded 对比顺序处理与并发处理一批过期红包的耗时，单个红包以固定延迟模拟一次退款事务的往返
+func BenchmarkProcessBounded(b *testing.B) {
+	const refundLatency = 2 * time.Millisecond
+	envelopes := expiredBatch(100)
+	for _, concurrency := range []int{1, 4, 8, 16} {
+		b.Run(fmt.Sprintf("concurrency=%d", concurrency), func(b *testing.B) {
+			for range b.N {
+				processBounded(envelopes, concurrency, func(model.RedEnvelope) {
+					time.Sleep(refundLatency)
+				})
+			}
+		})
+	}
+}
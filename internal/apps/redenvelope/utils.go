@@ -17,32 +17,1422 @@ limitations under the License.
 package redenvelope
 
 import (
+	"context"
+	"crypto/hmac"
+	cryptorand "crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"math/big"
 	"math/rand"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
 
+	"github.com/gin-gonic/gin"
+
+	"github.com/linux-do/credit/internal/apps/oauth"
+	"github.com/linux-do/credit/internal/common"
+	"github.com/linux-do/credit/internal/config"
+	"github.com/linux-do/credit/internal/db"
+	"github.com/linux-do/credit/internal/logger"
+	"github.com/linux-do/credit/internal/model"
+	"github.com/linux-do/credit/internal/util"
+	"github.com/redis/go-redis/v9"
+	"github.com/rivo/uniseg"
+	"github.com/robfig/cron/v3"
 	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
 )
 
+// handleClaimError 将领取红包的错误转换为对应的 HTTP 响应
+func handleClaimError(c *gin.Context, err error) {
+	errMsg := err.Error()
+	switch errMsg {
+	case RedEnvelopeNotFound:
+		c.JSON(http.StatusNotFound, util.Err(errMsg))
+	case RedEnvelopeExpired, RedEnvelopeFinished, RedEnvelopeAlreadyClaimed, CannotClaimOwnRedEnvelope, BalanceCapExceeded, CreatorClaimCapExceeded,
+		ConfirmRequired, ConfirmNotRequired, ReservationInvalid, RedEnvelopePaused, OpenSlotsExhausted:
+		c.JSON(http.StatusBadRequest, util.Err(errMsg))
+	case NotInAllowList, CreatorBanned:
+		c.JSON(http.StatusForbidden, util.Err(errMsg))
+	case ClaimsClosedNow:
+		var resp ClaimsClosedResponse
+		if _, openAt, err := claimBlackout(c.Request.Context(), time.Now()); err == nil && !openAt.IsZero() {
+			resp.NextOpenAt = &openAt
+		}
+		c.JSON(http.StatusForbidden, util.Response[ClaimsClosedResponse]{ErrorMsg: errMsg, Data: resp})
+	case DeepLinkDisabled:
+		c.JSON(http.StatusForbidden, util.Err(errMsg))
+	case DeepLinkTokenInvalid:
+		c.JSON(http.StatusUnauthorized, util.Err(errMsg))
+	case DeepLinkTokenUsed:
+		c.JSON(http.StatusConflict, util.Err(errMsg))
+	case ClaimNotFound:
+		c.JSON(http.StatusNotFound, util.Err(errMsg))
+	case ClaimReturnDisabled, ClaimReturnWindowPassed, common.InsufficientBalance, WalletInsufficient:
+		c.JSON(http.StatusBadRequest, util.Err(errMsg))
+	case ReservationFull:
+		c.JSON(http.StatusConflict, util.Err(errMsg))
+	// 领取后转发为新红包时的创建校验错误
+	case InvalidRedEnvelopeType, InvalidRedEnvelopeCount, AmountTooSmall, InvalidMaxClaimsPerUser,
+		UnknownGreetingVariable, GreetingTooLong, common.RedEnvelopeMinAmountRequired, common.RedEnvelopeAmountExceeded,
+		common.RedEnvelopeRecipientsExceeded, common.RedEnvelopeDailyLimitExceeded:
+		c.JSON(http.StatusBadRequest, util.Err(errMsg))
+	case TestModeUnsupported:
+		c.JSON(http.StatusBadRequest, util.Err(errMsg))
+	case SystemLiabilityCapReached:
+		c.JSON(http.StatusServiceUnavailable, util.Err(errMsg))
+	default:
+		c.JSON(http.StatusInternalServerError, util.Err(errMsg))
+	}
+}
+
+// buildShareMeta 根据红包信息生成分享卡片元数据，祝福语需在脱敏后传入
+func buildShareMeta(redEnvelope *model.RedEnvelope, claimedCount int) ShareMeta {
+	description := redEnvelope.Greeting
+	if description == "" {
+		description = DefaultShareDescription
+	}
+	return ShareMeta{
+		Title:        fmt.Sprintf("%s 的红包", redEnvelope.CreatorUsername),
+		Description:  description,
+		ClaimedCount: claimedCount,
+		TotalCount:   redEnvelope.TotalCount,
+	}
+}
+
+// signWebhookBody 计算请求体的 HMAC-SHA256 十六进制签名
+func signWebhookBody(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// receiptHash 返回领取回执签名算法对应的哈希函数，未配置时使用 HS256
+func receiptHash() (string, func() hash.Hash, error) {
+	switch alg := strings.ToUpper(strings.TrimSpace(config.Config.RedEnvelope.ReceiptAlgorithm)); alg {
+	case "", "HS256":
+		return "HS256", sha256.New, nil
+	case "HS512":
+		return "HS512", sha512.New, nil
+	default:
+		return "", nil, fmt.Errorf("不支持的领取回执签名算法: %s", alg)
+	}
+}
+
+// signClaimReceipt 将领取记录签发为 JWT 格式的领取回执（header.payload.signature，base64url 无填充）
+func signClaimReceipt(claim *model.RedEnvelopeClaim) (string, error) {
+	secret := config.Config.RedEnvelope.ReceiptSecret
+	if secret == "" {
+		return "", errors.New(ReceiptDisabled)
+	}
+	alg, newHash, err := receiptHash()
+	if err != nil {
+		return "", err
+	}
+
+	header, err := json.Marshal(map[string]string{"alg": alg, "typ": "JWT"})
+	if err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(ClaimReceipt{
+		ID:            strconv.FormatUint(claim.ID, 10),
+		Issuer:        config.Config.App.AppName,
+		UserID:        strconv.FormatUint(claim.UserID, 10),
+		Username:      claim.Username,
+		RedEnvelopeID: strconv.FormatUint(claim.RedEnvelopeID, 10),
+		Amount:        claim.Amount.String(),
+		ClaimedAt:     claim.ClaimedAt.Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	mac := hmac.New(newHash, []byte(secret))
+	mac.Write([]byte(signingInput))
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil)), nil
+}
+
+// VerifyClaimReceipt 校验领取回执的签名并返回回执内容，签名算法须与当前配置一致，防止算法替换攻击
+func VerifyClaimReceipt(receipt string) (*ClaimReceipt, error) {
+	secret := config.Config.RedEnvelope.ReceiptSecret
+	if secret == "" {
+		return nil, errors.New(ReceiptDisabled)
+	}
+	alg, newHash, err := receiptHash()
+	if err != nil {
+		return nil, err
+	}
+
+	parts := strings.Split(receipt, ".")
+	if len(parts) != 3 {
+		return nil, errors.New(ReceiptInvalid)
+	}
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	rawHeader, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil || json.Unmarshal(rawHeader, &header) != nil || header.Alg != alg {
+		return nil, errors.New(ReceiptInvalid)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, errors.New(ReceiptInvalid)
+	}
+	mac := hmac.New(newHash, []byte(secret))
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		return nil, errors.New(ReceiptInvalid)
+	}
+
+	var claims ClaimReceipt
+	rawPayload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil || json.Unmarshal(rawPayload, &claims) != nil {
+		return nil, errors.New(ReceiptInvalid)
+	}
+	return &claims, nil
+}
+
+// handleBindError 处理请求参数绑定错误，可按字段解析时附带字段错误明细
+func handleBindError(c *gin.Context, err error) {
+	if fields := util.FieldErrors(err); fields != nil {
+		c.JSON(http.StatusBadRequest, util.ErrFields(err.Error(), fields))
+		return
+	}
+	c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+}
+
+// convertDisplayAmount 按系统配置的换算表将积分金额换算为展示货币，未请求或不支持的货币返回 nil
+func convertDisplayAmount(ctx context.Context, amount decimal.Decimal, currency string) (*DisplayAmount, error) {
+	if currency == "" {
+		return nil, nil
+	}
+
+	var sc model.SystemConfig
+	if err := sc.GetByKey(ctx, model.ConfigKeyRedEnvelopeDisplayCurrencies); err != nil {
+		return nil, err
+	}
+
+	currency = strings.ToUpper(currency)
+	for _, pair := range strings.Split(sc.Value, ",") {
+		code, rateStr, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok || !strings.EqualFold(code, currency) {
+			continue
+		}
+		rate, err := decimal.NewFromString(strings.TrimSpace(rateStr))
+		if err != nil {
+			return nil, fmt.Errorf("货币 %s 的换算汇率 '%s' 无效: %w", currency, rateStr, err)
+		}
+		converted := amount.Mul(rate).Round(2)
+		return &DisplayAmount{
+			Currency:  currency,
+			Amount:    converted,
+			Formatted: fmt.Sprintf("%s %s", currency, util.FormatAmount(converted)),
+		}, nil
+	}
+
+	return nil, nil
+}
+
+// greetingVariablePattern 祝福语模板变量，如 {festival}
+var greetingVariablePattern = regexp.MustCompile(`\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// resolveGreeting 按系统配置的变量表替换祝福语中的模板变量并校验长度，存在未定义的变量或超长时返回错误
+func resolveGreeting(ctx context.Context, greeting string) (string, error) {
+	if !greetingVariablePattern.MatchString(greeting) {
+		return greeting, validateGreetingLength(ctx, greeting)
+	}
+
+	var sc model.SystemConfig
+	if err := sc.GetByKey(ctx, model.ConfigKeyRedEnvelopeGreetingVariables); err != nil {
+		return "", err
+	}
+	variables := make(map[string]string)
+	for _, pair := range strings.Split(sc.Value, ",") {
+		name, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok || strings.TrimSpace(name) == "" {
+			continue
+		}
+		variables[strings.TrimSpace(name)] = strings.TrimSpace(value)
+	}
+
+	unknown := false
+	resolved := greetingVariablePattern.ReplaceAllStringFunc(greeting, func(match string) string {
+		value, ok := variables[match[1:len(match)-1]]
+		if !ok {
+			unknown = true
+			return match
+		}
+		return value
+	})
+	if unknown {
+		return "", errors.New(UnknownGreetingVariable)
+	}
+	if err := validateGreetingLength(ctx, resolved); err != nil {
+		return "", err
+	}
+	return resolved, nil
+}
+
+// blackoutWeekdays 暂停领取时段配置中的星期缩写
+var blackoutWeekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// blackoutRule 暂停领取规则，start、end 为当天零点起的分钟数，end 不晚于 start 时表示持续到次日
+type blackoutRule struct {
+	days  [7]bool
+	start int
+	end   int
+}
+
+// blackoutWindow 一段具体的暂停领取时间
+type blackoutWindow struct {
+	start time.Time
+	end   time.Time
+}
+
+// parseBlackoutSchedule 解析暂停领取时段配置，规则以分号分隔，每条为“星期 开始-结束”
+// 星期可为 daily、单日（mon）、范围（mon-fri，可跨周如 fri-mon）或逗号分隔的组合，时间为 HH:MM，结束可为 24:00
+func parseBlackoutSchedule(value string) ([]blackoutRule, error) {
+	var rules []blackoutRule
+	for _, item := range strings.Split(value, ";") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		fields := strings.Fields(item)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("暂停领取时段 '%s' 格式错误", item)
+		}
+
+		var rule blackoutRule
+		for _, part := range strings.Split(strings.ToLower(fields[0]), ",") {
+			if part == "daily" {
+				rule.days = [7]bool{true, true, true, true, true, true, true}
+				continue
+			}
+			from, to, isRange := strings.Cut(part, "-")
+			first, ok1 := blackoutWeekdays[from]
+			last, ok2 := blackoutWeekdays[to]
+			if !isRange {
+				last, ok2 = first, ok1
+			}
+			if !ok1 || !ok2 {
+				return nil, fmt.Errorf("暂停领取时段 '%s' 的星期 '%s' 无效", item, part)
+			}
+			for day := first; ; day = (day + 1) % 7 {
+				rule.days[day] = true
+				if day == last {
+					break
+				}
+			}
+		}
+
+		startStr, endStr, ok := strings.Cut(fields[1], "-")
+		start, err1 := parseBlackoutClock(startStr)
+		end, err2 := parseBlackoutClock(endStr)
+		if !ok || err1 != nil || err2 != nil || start == 24*60 {
+			return nil, fmt.Errorf("暂停领取时段 '%s' 的时间 '%s' 无效", item, fields[1])
+		}
+		rule.start, rule.end = start, end
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// parseBlackoutClock 解析 HH:MM 格式的时间，返回当天零点起的分钟数，允许 24:00
+func parseBlackoutClock(value string) (int, error) {
+	hourStr, minuteStr, ok := strings.Cut(value, ":")
+	hour, err1 := strconv.Atoi(hourStr)
+	minute, err2 := strconv.Atoi(minuteStr)
+	if !ok || err1 != nil || err2 != nil || hour < 0 || minute < 0 || minute > 59 || hour*60+minute > 24*60 {
+		return 0, fmt.Errorf("时间 '%s' 格式错误", value)
+	}
+	return hour*60 + minute, nil
+}
+
+// claimBlackout 判断当前是否处于暂停领取时段，处于时段内时返回下次开放领取的时间
+// 开放时间超出查找范围（如全天暂停）时返回零值；时段按配置时区的当地日期计算，夏令时切换当天以当地时间为准
+func claimBlackout(ctx context.Context, now time.Time) (bool, time.Time, error) {
+	var sc model.SystemConfig
+	if err := sc.GetByKey(ctx, model.ConfigKeyRedEnvelopeBlackoutSchedule); err != nil {
+		return false, time.Time{}, err
+	}
+	if strings.TrimSpace(sc.Value) == "" {
+		return false, time.Time{}, nil
+	}
+	rules, err := parseBlackoutSchedule(sc.Value)
+	if err != nil {
+		return false, time.Time{}, err
+	}
+
+	var tz model.SystemConfig
+	if err := tz.GetByKey(ctx, model.ConfigKeyRedEnvelopeBlackoutTimezone); err != nil {
+		return false, time.Time{}, err
+	}
+	location, err := time.LoadLocation(strings.TrimSpace(tz.Value))
+	if err != nil {
+		return false, time.Time{}, fmt.Errorf("配置 %s 的值 '%s' 不是有效的时区: %w", model.ConfigKeyRedEnvelopeBlackoutTimezone, tz.Value, err)
+	}
+
+	closed, openAt := nextClaimOpenAt(rules, now.In(location))
+	return closed, openAt, nil
+}
+
+// nextClaimOpenAt 根据暂停规则判断 now 是否处于暂停时段，相邻或重叠的时段合并计算开放时间
+func nextClaimOpenAt(rules []blackoutRule, now time.Time) (bool, time.Time) {
+	// 从前一天开始展开，覆盖前一天跨天延续到今天的时段
+	year, month, day := now.Date()
+	location := now.Location()
+	var windows []blackoutWindow
+	for offset := -1; offset <= BlackoutLookaheadDays; offset++ {
+		weekday := time.Date(year, month, day+offset, 0, 0, 0, 0, location).Weekday()
+		for _, rule := range rules {
+			if !rule.days[weekday] {
+				continue
+			}
+			endDay := day + offset
+			if rule.end <= rule.start {
+				endDay++
+			}
+			windows = append(windows, blackoutWindow{
+				start: time.Date(year, month, day+offset, rule.start/60, rule.start%60, 0, 0, location),
+				end:   time.Date(year, month, endDay, rule.end/60, rule.end%60, 0, 0, location),
+			})
+		}
+	}
+
+	openAt := now
+	for extended := true; extended; {
+		extended = false
+		for _, window := range windows {
+			if !window.start.After(openAt) && window.end.After(openAt) {
+				openAt = window.end
+				extended = true
+			}
+		}
+	}
+	if openAt.Equal(now) {
+		return false, time.Time{}
+	}
+	if openAt.After(time.Date(year, month, day+BlackoutLookaheadDays, 0, 0, 0, 0, location)) {
+		return true, time.Time{}
+	}
+	return true, openAt
+}
+
+// sanitizeClaimMessage 清理领取成功提示语并校验长度：换行等空白统一为空格，去除其余控制字符及首尾空白
+func sanitizeClaimMessage(message string) (string, error) {
+	message = strings.TrimSpace(strings.Map(func(r rune) rune {
+		switch {
+		case unicode.IsSpace(r):
+			return ' '
+		case unicode.IsControl(r), r == utf8.RuneError:
+			return -1
+		}
+		return r
+	}, message))
+	if utf8.RuneCountInString(message) > MaxClaimMessageLength {
+		return "", errors.New(ClaimMessageTooLong)
+	}
+	return message, nil
+}
+
+// validateGreetingLength 校验祝福语长度：字符数不超过存储上限，且按用户实际看到的字形簇（如组合 emoji 计为1个）计数不超过配置上限
+func validateGreetingLength(ctx context.Context, greeting string) error {
+	if greeting == "" {
+		return nil
+	}
+	if utf8.RuneCountInString(greeting) > MaxGreetingLength {
+		return errors.New(GreetingTooLong)
+	}
+
+	maxGraphemes, err := model.GetIntByKey(ctx, model.ConfigKeyRedEnvelopeGreetingGraphemes)
+	if err != nil {
+		return err
+	}
+	if maxGraphemes > 0 && uniseg.GraphemeClusterCount(greeting) > maxGraphemes {
+		return errors.New(GreetingTooLong)
+	}
+	return nil
+}
+
+// validateAmountRange 校验金额不超过数据库列可存储的范围
+func validateAmountRange(amounts ...decimal.Decimal) error {
+	limit := decimal.New(1, MaxAmountIntegerDigits)
+	for _, amount := range amounts {
+		if amount.Abs().GreaterThanOrEqual(limit) {
+			return errors.New(AmountOutOfRange)
+		}
+	}
+	return nil
+}
+
+// resolvePerAmount 普通红包指定单个金额时计算红包总额（单个金额乘以红包个数），未指定时保持按总额发红包
+func resolvePerAmount(req *EnvelopeSpec) error {
+	if req.PerAmount.IsZero() {
+		return nil
+	}
+	if req.Type != model.RedEnvelopeTypeFixed || !req.TotalAmount.IsZero() {
+		return errors.New(InvalidPerAmount)
+	}
+	if err := validateAmountRange(req.PerAmount); err != nil {
+		return err
+	}
+	if err := util.ValidateAmount(req.PerAmount); err != nil {
+		return err
+	}
+
+	req.TotalAmount = req.PerAmount.Mul(decimal.NewFromInt(int64(req.TotalCount)))
+	return nil
+}
+
+// createParams 将红包参数转换为创建参数
+func (s *EnvelopeSpec) createParams(creatorID uint64) CreateParams {
+	return CreateParams{
+		CreatorID:           creatorID,
+		Type:                s.Type,
+		Split:               s.Split,
+		TotalAmount:         s.TotalAmount,
+		BaseAmount:          s.BaseAmount,
+		MinClaimAmount:      s.MinClaimAmount,
+		TotalCount:          s.TotalCount,
+		Greeting:            s.Greeting,
+		GreetingHidden:      s.GreetingHidden,
+		ClaimMessage:        s.ClaimMessage,
+		MaxClaimsPerUser:    s.MaxClaimsPerUser,
+		RequireConfirm:      s.RequireConfirm,
+		FromEscrow:          s.FromEscrow,
+		TargetWallet:        s.TargetWallet,
+		Visibility:          s.Visibility,
+		AllowedUsernames:    s.AllowedUsernames,
+		OpaqueLink:          s.OpaqueLink,
+		SignedReceipt:       s.SignedReceipt,
+		TestMode:            s.TestMode,
+		ReservedAllocations: s.ReservedAllocations,
+	}
+}
+
+// decimalFieldErrors 从缓存的请求体中找出无法解析为金额的字段，未找到时返回 nil
+// prefix 为金额字段所在对象的路径（如 spec），返回的字段名带有该前缀；为空时在请求体顶层查找
+func decimalFieldErrors(c *gin.Context, prefix string, fields ...string) map[string]string {
+	body, ok := c.Get(gin.BodyBytesKey)
+	if !ok {
+		return nil
+	}
+	bodyBytes, ok := body.([]byte)
+	if !ok {
+		return nil
+	}
+
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(bodyBytes, &raw); err != nil {
+		return nil
+	}
+	if prefix != "" {
+		for _, key := range strings.Split(prefix, ".") {
+			nested, exists := raw[key]
+			if !exists {
+				return nil
+			}
+			raw = nil
+			if err := json.Unmarshal(nested, &raw); err != nil {
+				return nil
+			}
+		}
+		prefix += "."
+	}
+
+	fieldErrors := make(map[string]string)
+	for _, field := range fields {
+		value, exists := raw[field]
+		if !exists {
+			continue
+		}
+		var amount decimal.Decimal
+		if err := amount.UnmarshalJSON(value); err != nil {
+			fieldErrors[prefix+field] = InvalidAmountFormat
+		}
+	}
+	if len(fieldErrors) == 0 {
+		return nil
+	}
+	return fieldErrors
+}
+
+// handleCreateError 将创建红包的错误转换为对应的 HTTP 响应
+func handleCreateError(c *gin.Context, err error) {
+	errMsg := err.Error()
+	switch errMsg {
+	case common.AmountMustBeGreaterThanZero, common.AmountDecimalPlacesExceeded:
+		c.JSON(http.StatusBadRequest, util.ErrFields(errMsg, map[string]string{"total_amount": errMsg}))
+	case AmountOutOfRange:
+		c.JSON(http.StatusBadRequest, util.ErrFields(errMsg, map[string]string{"total_amount": errMsg}))
+	case InvalidMinClaimAmount:
+		c.JSON(http.StatusBadRequest, util.ErrFields(errMsg, map[string]string{"min_claim_amount": errMsg}))
+	case InvalidBaseAmount:
+		c.JSON(http.StatusBadRequest, util.ErrFields(errMsg, map[string]string{"base_amount": errMsg}))
+	case InvalidDenomination:
+		c.JSON(http.StatusBadRequest, util.ErrFields(errMsg, map[string]string{"total_amount": errMsg}))
+	case InvalidMaxClaimsPerUser:
+		c.JSON(http.StatusBadRequest, util.ErrFields(errMsg, map[string]string{"max_claims_per_user": errMsg}))
+	case UnknownGreetingVariable, GreetingTooLong:
+		c.JSON(http.StatusBadRequest, util.ErrFields(errMsg, map[string]string{"greeting": errMsg}))
+	case InvalidSplit:
+		c.JSON(http.StatusBadRequest, util.ErrFields(errMsg, map[string]string{"split": errMsg}))
+	case ClaimMessageTooLong:
+		c.JSON(http.StatusBadRequest, util.ErrFields(errMsg, map[string]string{"claim_message": errMsg}))
+	case ReceiptDisabled:
+		c.JSON(http.StatusBadRequest, util.ErrFields(errMsg, map[string]string{"signed_receipt": errMsg}))
+	case TestModeUnsupported:
+		c.JSON(http.StatusBadRequest, util.ErrFields(errMsg, map[string]string{"test_mode": errMsg}))
+	case TestModeNotAllowed:
+		c.JSON(http.StatusForbidden, util.Err(errMsg))
+	case SystemLiabilityCapReached:
+		c.JSON(http.StatusServiceUnavailable, util.Err(errMsg))
+	case CreateInProgress, DuplicateCreate:
+		c.JSON(http.StatusConflict, util.Err(errMsg))
+	case InvalidVisibility:
+		c.JSON(http.StatusBadRequest, util.ErrFields(errMsg, map[string]string{"visibility": errMsg}))
+	case InvalidTargetWallet:
+		c.JSON(http.StatusBadRequest, util.ErrFields(errMsg, map[string]string{"target_wallet": errMsg}))
+	case AudienceTooSmall:
+		c.JSON(http.StatusBadRequest, util.ErrFields(errMsg, map[string]string{"total_count": errMsg}))
+	case InvalidReservations:
+		c.JSON(http.StatusBadRequest, util.ErrFields(errMsg, map[string]string{"reserved_allocations": errMsg}))
+	case AllowListRequired, AllowListNotAllowed:
+		c.JSON(http.StatusBadRequest, util.ErrFields(errMsg, map[string]string{"allowed_usernames": errMsg}))
+	case AllowListUserNotFound:
+		// 字段明细中列出不存在的用户名，便于创建者修正名单
+		detail := errMsg
+		var missingErr *allowListMissingError
+		if errors.As(err, &missingErr) {
+			detail = fmt.Sprintf("%s: %s", errMsg, strings.Join(missingErr.usernames, ", "))
+		}
+		c.JSON(http.StatusBadRequest, util.ErrFields(errMsg, map[string]string{"allowed_usernames": detail}))
+	case InvalidRedEnvelopeType, InvalidRedEnvelopeCount, AmountTooSmall, common.InsufficientBalance, EscrowInsufficient, WalletInsufficient,
+		common.RedEnvelopeMinAmountRequired, common.RedEnvelopeAmountExceeded,
+		common.RedEnvelopeRecipientsExceeded, common.RedEnvelopeDailyLimitExceeded:
+		c.JSON(http.StatusBadRequest, util.Err(errMsg))
+	default:
+		c.JSON(http.StatusInternalServerError, util.Err(errMsg))
+	}
+}
+
+// handleEscrowTransfer 校验托管资金存取请求并执行划转
+func handleEscrowTransfer(c *gin.Context, transfer func(ctx context.Context, userID uint64, amount decimal.Decimal) error) {
+	var req EscrowRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleBindError(c, err)
+		return
+	}
+
+	if err := util.ValidateAmount(req.Amount); err != nil {
+		c.JSON(http.StatusBadRequest, util.ErrFields(err.Error(), map[string]string{"amount": err.Error()}))
+		return
+	}
+
+	currentUser, _ := util.GetFromContext[*model.User](c, oauth.UserObjKey)
+	if !verifyPayKey(c, currentUser, req.PayKey) {
+		return
+	}
+
+	if err := transfer(c.Request.Context(), currentUser.ID, req.Amount); err != nil {
+		switch err.Error() {
+		case common.InsufficientBalance, EscrowInsufficient:
+			c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+		default:
+			c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, util.OKNil())
+}
+
+// getClaimGracePeriod 获取红包过期后仍允许领取的宽限时间
+func getClaimGracePeriod(ctx context.Context) (time.Duration, error) {
+	graceSeconds, err := model.GetIntByKey(ctx, model.ConfigKeyRedEnvelopeClaimGraceSeconds)
+	if err != nil {
+		return 0, err
+	}
+	if graceSeconds < 0 {
+		graceSeconds = 0
+	}
+	return time.Duration(graceSeconds) * time.Second, nil
+}
+
+// isClaimExpired 判断红包是否已超过可领取期限（过期时间加宽限时间）
+func isClaimExpired(redEnvelope *model.RedEnvelope, grace time.Duration) bool {
+	return redEnvelope.Status == model.RedEnvelopeStatusExpired || time.Now().After(redEnvelope.ExpiresAt.Add(grace))
+}
+
+// checkClaimable 检查红包当前是否可领取：未过期（含宽限时间）、未领完且未暂停
+func checkClaimable(redEnvelope *model.RedEnvelope, grace time.Duration) error {
+	if isClaimExpired(redEnvelope, grace) {
+		return errors.New(RedEnvelopeExpired)
+	}
+	if redEnvelope.Status == model.RedEnvelopeStatusFinished || redEnvelope.RemainingCount <= 0 {
+		return errors.New(RedEnvelopeFinished)
+	}
+	if redEnvelope.Status == model.RedEnvelopeStatusPaused {
+		return errors.New(RedEnvelopePaused)
+	}
+	return nil
+}
+
+// verifyPayKey 校验支付密钥，连续错误达到上限后临时锁定，近期已校验通过的密钥可跳过解密，未通过时已写入响应
+func verifyPayKey(c *gin.Context, user *model.User, payKey string) bool {
+	ctx := c.Request.Context()
+	if db.Redis == nil {
+		if !user.VerifyPayKey(payKey) {
+			c.JSON(http.StatusBadRequest, util.Err(common.PayKeyIncorrect))
+			return false
+		}
+		return true
+	}
+
+	lockKey := db.PrefixedKey(fmt.Sprintf(PayKeyLockKeyFormat, user.ID))
+	failureKey := db.PrefixedKey(fmt.Sprintf(PayKeyFailureKeyFormat, user.ID))
+
+	// 锁定期间不再校验密钥，直接返回解锁时间
+	ttl, err := db.Redis.TTL(ctx, lockKey).Result()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		return false
+	}
+	if ttl > 0 {
+		c.JSON(http.StatusTooManyRequests, util.Err(payKeyLockedMessage(ttl)))
+		return false
+	}
+
+	verifiedKey := db.PrefixedKey(fmt.Sprintf(PayKeyVerifiedKeyFormat, user.ID))
+	digest := payKeyDigest(user, payKey)
+	if cached, err := db.Redis.Get(ctx, verifiedKey).Result(); err == nil &&
+		hmac.Equal([]byte(cached), []byte(digest)) {
+		return true
+	}
+
+	if user.VerifyPayKey(payKey) {
+		db.Redis.Del(ctx, failureKey)
+		cachePayKeyVerified(ctx, verifiedKey, digest)
+		return true
+	}
+
+	maxFailures, err := model.GetIntByKey(ctx, model.ConfigKeyRedEnvelopePayKeyMaxFailures)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		return false
+	}
+	if maxFailures > 0 {
+		failures, err := db.Redis.Incr(ctx, failureKey).Result()
+		if err != nil {
+			logger.WarnF(ctx, "统计支付密钥错误次数失败: %v", err)
+		} else {
+			if failures == 1 {
+				db.Redis.Expire(ctx, failureKey, PayKeyFailureWindow)
+			}
+			if failures >= int64(maxFailures) {
+				if err := db.Redis.Set(ctx, lockKey, 1, PayKeyLockDuration).Err(); err != nil {
+					logger.WarnF(ctx, "锁定支付密钥失败: %v", err)
+				} else {
+					db.Redis.Del(ctx, failureKey)
+					c.JSON(http.StatusTooManyRequests, util.Err(payKeyLockedMessage(PayKeyLockDuration)))
+					return false
+				}
+			}
+		}
+	}
+
+	c.JSON(http.StatusBadRequest, util.Err(common.PayKeyIncorrect))
+	return false
+}
+
+// payKeyDigest 计算支付密钥校验缓存值，与用户当前加密后的支付密钥绑定，修改支付密钥后缓存自动失效
+func payKeyDigest(user *model.User, payKey string) string {
+	mac := hmac.New(sha256.New, []byte(user.SignKey+user.PayKey))
+	mac.Write([]byte(payKey))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// cachePayKeyVerified 按配置短时缓存支付密钥校验通过的结果，缓存时间不超过 MaxPayKeyVerifiedCacheTTL
+func cachePayKeyVerified(ctx context.Context, verifiedKey, digest string) {
+	cacheSeconds, err := model.GetIntByKey(ctx, model.ConfigKeyRedEnvelopePayKeyCacheSeconds)
+	if err != nil {
+		logger.WarnF(ctx, "获取支付密钥缓存配置失败: %v", err)
+		return
+	}
+	if cacheSeconds <= 0 {
+		return
+	}
+
+	ttl := min(time.Duration(cacheSeconds)*time.Second, MaxPayKeyVerifiedCacheTTL)
+	if err := db.Redis.Set(ctx, verifiedKey, digest, ttl).Err(); err != nil {
+		logger.WarnF(ctx, "缓存支付密钥校验结果失败: %v", err)
+	}
+}
+
+// payKeyLockedMessage 生成带解锁时间的支付密钥锁定提示
+func payKeyLockedMessage(remaining time.Duration) string {
+	return fmt.Sprintf(PayKeyLocked, time.Now().Add(remaining).Format("2006-01-02 15:04:05"))
+}
+
+// fillClaimUsers 为未快照用户信息的历史领取记录补充当前用户名及头像
+func fillClaimUsers(ctx context.Context, claims []*model.RedEnvelopeClaim) error {
+	var userIDs []uint64
+	for _, claim := range claims {
+		if claim.Username == "" {
+			userIDs = append(userIDs, claim.UserID)
+		}
+	}
+	if len(userIDs) == 0 {
+		return nil
+	}
+
+	var users []model.User
+	if err := db.DB(ctx).Select("id, username, avatar_url").Where("id IN ?", userIDs).Find(&users).Error; err != nil {
+		return err
+	}
+	userMap := make(map[uint64]*model.User, len(users))
+	for i := range users {
+		userMap[users[i].ID] = &users[i]
+	}
+
+	for _, claim := range claims {
+		if claim.Username != "" {
+			continue
+		}
+		if user, ok := userMap[claim.UserID]; ok {
+			claim.Username = user.Username
+			claim.AvatarURL = user.AvatarUrl
+		}
+	}
+	return nil
+}
+
+// claimSortColumns 领取记录可排序的字段，排序参数只能取其中的键，不直接拼接用户输入
+var claimSortColumns = map[string]string{
+	"time":   "claimed_at",
+	"amount": "amount",
+}
+
+// claimsPageQuery 按排序字段及方向构造领取记录查询，以 (排序字段, id) 作为键集分页游标，排序值相同时按 id 保证顺序稳定
+func claimsPageQuery(query *gorm.DB, req DetailClaimsRequest) (*gorm.DB, error) {
+	column, ok := claimSortColumns[req.Sort]
+	if !ok {
+		return nil, errors.New(InvalidClaimCursor)
+	}
+	direction, comparator := "DESC", "<"
+	if req.Order == "asc" {
+		direction, comparator = "ASC", ">"
+	}
+
+	if req.Cursor != "" {
+		value, lastID, err := decodeClaimCursor(req.Cursor, req.Sort)
+		if err != nil {
+			return nil, err
+		}
+		query = query.Where(fmt.Sprintf("(%s, id) %s (?, ?)", column, comparator), value, lastID)
+	}
+	return query.Order(fmt.Sprintf("%s %s, id %s", column, direction, direction)), nil
+}
+
+// pinClaim 将指定领取记录移至首位，其余记录保持原有顺序；firstPage 为 false 时仅从本页移除该记录
+func pinClaim(claims []model.RedEnvelopeClaim, pinned model.RedEnvelopeClaim, firstPage bool) []model.RedEnvelopeClaim {
+	result := make([]model.RedEnvelopeClaim, 0, len(claims)+1)
+	if firstPage {
+		result = append(result, pinned)
+	}
+	for _, claim := range claims {
+		if claim.ID != pinned.ID {
+			result = append(result, claim)
+		}
+	}
+	return result
+}
+
+// encodeClaimCursor 将一页最后一条领取记录的排序值及ID编码为游标（base64url）
+func encodeClaimCursor(claim *model.RedEnvelopeClaim, sort string) string {
+	value := claim.ClaimedAt.Format(time.RFC3339Nano)
+	if sort == "amount" {
+		value = claim.Amount.String()
+	}
+	return base64.RawURLEncoding.EncodeToString([]byte(value + "," + strconv.FormatUint(claim.ID, 10)))
+}
+
+// decodeClaimCursor 解析领取记录游标，返回排序值及记录ID
+func decodeClaimCursor(cursor string, sort string) (any, uint64, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, 0, errors.New(InvalidClaimCursor)
+	}
+	value, idPart, ok := strings.Cut(string(raw), ",")
+	if !ok {
+		return nil, 0, errors.New(InvalidClaimCursor)
+	}
+	lastID, err := strconv.ParseUint(idPart, 10, 64)
+	if err != nil {
+		return nil, 0, errors.New(InvalidClaimCursor)
+	}
+
+	if sort == "amount" {
+		amount, err := decimal.NewFromString(value)
+		if err != nil {
+			return nil, 0, errors.New(InvalidClaimCursor)
+		}
+		return amount, lastID, nil
+	}
+	claimedAt, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return nil, 0, errors.New(InvalidClaimCursor)
+	}
+	return claimedAt, lastID, nil
+}
+
+// claimDevice 领取请求的来源信息：设备信息的 HMAC 哈希（不保存原始 IP 及 User-Agent）及分享渠道
+type claimDevice struct {
+	IPHash     string
+	DeviceHash string
+	Channel    string
+}
+
+// newClaimDevice 计算请求的 IP 哈希及 IP 与 User-Agent 组合的设备哈希，未配置密钥时不采集
+// 领取链接携带的 channel 查询参数作为分享渠道，格式不合法时忽略
+func newClaimDevice(c *gin.Context) claimDevice {
+	var device claimDevice
+	if channel, ok := normalizeChannel(c.Query("channel")); ok {
+		device.Channel = channel
+	}
+
+	secret := config.Config.RedEnvelope.DeviceHashSecret
+	if secret == "" {
+		return device
+	}
+
+	ip := c.ClientIP()
+	device.IPHash = signWebhookBody(secret, []byte("ip:"+ip))
+	device.DeviceHash = signWebhookBody(secret, []byte("device:"+ip+"\n"+c.Request.UserAgent()))
+	return device
+}
+
+// channelPattern 领取渠道标识，如 telegram、forum、email
+var channelPattern = regexp.MustCompile(`^[a-z0-9_-]+$`)
+
+// normalizeChannel 去除首尾空白并转为小写，空字符串表示未标注渠道；格式不合法时返回 false
+func normalizeChannel(raw string) (string, bool) {
+	channel := strings.ToLower(strings.TrimSpace(raw))
+	if channel == "" {
+		return "", true
+	}
+	if len(channel) > MaxChannelLength || !channelPattern.MatchString(channel) {
+		return "", false
+	}
+	return channel, true
+}
+
+// GenerateDeepLinkToken 为指定用户和红包签发一键领取凭证，供通知等服务端流程嵌入链接
+// 凭证格式：红包ID.用户ID.过期时间戳.nonce.签名
+func GenerateDeepLinkToken(redEnvelopeID, userID uint64) (string, time.Time, error) {
+	secret := config.Config.RedEnvelope.DeepLinkSecret
+	if secret == "" {
+		return "", time.Time{}, errors.New(DeepLinkDisabled)
+	}
+
+	expiresAt := time.Now().Add(DeepLinkTokenExpiration)
+	payload := fmt.Sprintf("%d.%d.%d.%s", redEnvelopeID, userID, expiresAt.Unix(), util.GenerateUniqueIDSimple())
+	return payload + "." + signWebhookBody(secret, []byte(payload)), expiresAt, nil
+}
+
+// parseDeepLinkToken 校验一键领取凭证的签名及有效期，返回红包ID、用户ID、nonce 及过期时间
+func parseDeepLinkToken(token string) (redEnvelopeID, userID uint64, nonce string, expiresAt time.Time, err error) {
+	secret := config.Config.RedEnvelope.DeepLinkSecret
+	if secret == "" {
+		return 0, 0, "", time.Time{}, errors.New(DeepLinkDisabled)
+	}
+
+	invalid := errors.New(DeepLinkTokenInvalid)
+	idx := strings.LastIndex(token, ".")
+	if idx <= 0 {
+		return 0, 0, "", time.Time{}, invalid
+	}
+	payload, signature := token[:idx], token[idx+1:]
+	signatureBytes, err := hex.DecodeString(signature)
+	if err != nil {
+		return 0, 0, "", time.Time{}, invalid
+	}
+	expected, _ := hex.DecodeString(signWebhookBody(secret, []byte(payload)))
+	if !hmac.Equal(signatureBytes, expected) {
+		return 0, 0, "", time.Time{}, invalid
+	}
+
+	parts := strings.Split(payload, ".")
+	if len(parts) != 4 {
+		return 0, 0, "", time.Time{}, invalid
+	}
+	if redEnvelopeID, err = strconv.ParseUint(parts[0], 10, 64); err != nil {
+		return 0, 0, "", time.Time{}, invalid
+	}
+	if userID, err = strconv.ParseUint(parts[1], 10, 64); err != nil {
+		return 0, 0, "", time.Time{}, invalid
+	}
+	expiresUnix, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return 0, 0, "", time.Time{}, invalid
+	}
+	expiresAt = time.Unix(expiresUnix, 0)
+	if time.Now().After(expiresAt) {
+		return 0, 0, "", time.Time{}, invalid
+	}
+	return redEnvelopeID, userID, parts[3], expiresAt, nil
+}
+
+// handleBulkClaim 校验批量代领请求并执行，operatorID 为0时表示管理员操作
+func handleBulkClaim(c *gin.Context, operatorID uint64) {
+	var req BulkClaimRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleBindError(c, err)
+		return
+	}
+
+	items, failedUsername, err := bulkClaimRedEnvelope(c.Request.Context(), operatorID, c.Param("id"), req.Usernames)
+	if err != nil {
+		switch errMsg := err.Error(); errMsg {
+		case InvalidRedEnvelopeID, NotEnvelopeCreator:
+			handleCreatorError(c, err)
+		case InsufficientSlots:
+			c.JSON(http.StatusBadRequest, util.Err(errMsg))
+		case BulkClaimUserNotFound, RedEnvelopeAlreadyClaimed, NotInAllowList, BalanceCapExceeded, CreatorClaimCapExceeded:
+			c.JSON(http.StatusBadRequest, util.ErrFields(errMsg, map[string]string{"usernames": failedUsername}))
+		default:
+			handleClaimError(c, err)
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, util.OK(items))
+}
+
+// viewerRole 判断查看者与红包的关系：创建者、已领取用户或其他用户
+func viewerRole(redEnvelope *model.RedEnvelope, viewer *model.User, claimed bool) string {
+	switch {
+	case viewer != nil && viewer.ID == redEnvelope.CreatorID:
+		return ViewerRoleCreator
+	case viewer != nil && claimed:
+		return ViewerRoleClaimer
+	default:
+		return ViewerRolePublic
+	}
+}
+
+// projectClaims 按查看者角色投影领取记录：创建者可见全部金额，已领取用户仅可见自己的金额，其他用户不返回领取记录
+func projectClaims(claims []model.RedEnvelopeClaim, role string, viewer *model.User) []DetailClaim {
+	projected := make([]DetailClaim, 0, len(claims))
+	if role == ViewerRolePublic {
+		return projected
+	}
+	for i := range claims {
+		item := DetailClaim{RedEnvelopeClaim: claims[i]}
+		if role == ViewerRoleCreator || claims[i].UserID == viewer.ID {
+			item.Amount = &claims[i].Amount
+		}
+		projected = append(projected, item)
+	}
+	return projected
+}
+
+// projectStreamEvent 按查看者角色投影实时领取动态，与 projectClaims 的可见范围一致：
+// 创建者可见全部信息，已领取用户仅可见自己的领取金额，其他用户仅可见剩余个数、金额及状态
+func projectStreamEvent(event ClaimStreamEvent, role string, viewer *model.User) ClaimStreamEvent {
+	switch {
+	case role == ViewerRolePublic:
+		event.UserID, event.Username, event.AvatarURL, event.Amount = 0, "", "", nil
+	case role != ViewerRoleCreator && event.UserID != viewer.ID:
+		event.Amount = nil
+	}
+	return event
+}
+
+// publishClaimStream 领取事务提交后推送领取动态，红包领完时追加推送 finished 事件
+// 批量领取时各事件的剩余个数及金额均为提交后的最终值
+func publishClaimStream(ctx context.Context, redEnvelope *model.RedEnvelope, claims ...*model.RedEnvelopeClaim) {
+	for _, claim := range claims {
+		publishStreamEvent(ctx, redEnvelope.ID, ClaimStreamEvent{
+			Event:           StreamEventClaim,
+			UserID:          claim.UserID,
+			Username:        claim.Username,
+			AvatarURL:       claim.AvatarURL,
+			Amount:          &claim.Amount,
+			RemainingCount:  redEnvelope.RemainingCount,
+			RemainingAmount: redEnvelope.RemainingAmount,
+			Status:          redEnvelope.Status,
+		})
+	}
+	if redEnvelope.Status == model.RedEnvelopeStatusFinished {
+		publishStreamEvent(ctx, redEnvelope.ID, ClaimStreamEvent{Event: StreamEventFinished, Status: redEnvelope.Status})
+	}
+}
+
+// publishStreamEvent 发布红包实时动态事件，Redis 未启用时跳过，发布失败不影响业务流程
+func publishStreamEvent(ctx context.Context, redEnvelopeID uint64, event ClaimStreamEvent) {
+	if db.Redis == nil {
+		return
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		logger.WarnF(ctx, "红包ID:%d 序列化实时动态失败: %v", redEnvelopeID, err)
+		return
+	}
+	channel := db.PrefixedKey(fmt.Sprintf(ClaimStreamChannelFormat, redEnvelopeID))
+	if err := db.Redis.Publish(ctx, channel, payload).Err(); err != nil {
+		logger.WarnF(ctx, "红包ID:%d 发布实时动态失败: %v", redEnvelopeID, err)
+	}
+}
+
+// handleCreatorError 处理创建者专属操作的错误响应
+func handleCreatorError(c *gin.Context, err error) {
+	errMsg := err.Error()
+	switch errMsg {
+	case InvalidRedEnvelopeID:
+		c.JSON(http.StatusBadRequest, util.Err(errMsg))
+	case RedEnvelopeNotFound:
+		c.JSON(http.StatusNotFound, util.Err(errMsg))
+	case NotEnvelopeCreator:
+		c.JSON(http.StatusForbidden, util.Err(errMsg))
+	default:
+		c.JSON(http.StatusInternalServerError, util.Err(errMsg))
+	}
+}
+
+// consumeClaimTokenScript 比较并删除凭证，保证预约凭证只能被使用一次；同样用于释放创建红包互斥锁
+var consumeClaimTokenScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// takeClaimTokenScript 比较并取走领取凭证，返回取走前的剩余有效期（毫秒，未设置过期时间时为-1），凭证不匹配或不存在时返回-2
+var takeClaimTokenScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	local ttl = redis.call("PTTL", KEYS[1])
+	redis.call("DEL", KEYS[1])
+	return ttl
+end
+return -2
+`)
+
+// reserveSlotScript 清理过期预约后，在已预约人数小于剩余个数时为用户预约名额并写入预约凭证
+// 同一用户重复预约时刷新预约；名额已满返回0
+var reserveSlotScript = redis.NewScript(`
+redis.call("ZREMRANGEBYSCORE", KEYS[1], "-inf", ARGV[1])
+if not redis.call("ZSCORE", KEYS[1], ARGV[4]) and redis.call("ZCARD", KEYS[1]) >= tonumber(ARGV[3]) then
+	return 0
+end
+redis.call("ZADD", KEYS[1], ARGV[2], ARGV[4])
+redis.call("PEXPIRE", KEYS[1], ARGV[6])
+redis.call("SET", KEYS[2], ARGV[5], "PX", ARGV[6])
+return 1
+`)
+
+// acquireClaimGateScript 剩余个数大于0时扣减并放行；闸门不存在返回-2，已无剩余返回-1
+var acquireClaimGateScript = redis.NewScript(`
+local remaining = redis.call("GET", KEYS[1])
+if not remaining then
+	return -2
+end
+if tonumber(remaining) <= 0 then
+	return -1
+end
+return redis.call("DECR", KEYS[1])
+`)
+
+// incrLiabilityScript 缓存存在时累加待领取总额，缓存不存在时不做处理，等待下次重新统计
+var incrLiabilityScript = redis.NewScript(`
+if redis.call("EXISTS", KEYS[1]) == 1 then
+	return redis.call("INCRBYFLOAT", KEYS[1], ARGV[1])
+end
+return false
+`)
+
+// getOutstandingLiability 获取全平台进行中红包待领取总额，优先读取 Redis 缓存
+func getOutstandingLiability(ctx context.Context) (decimal.Decimal, error) {
+	if db.Redis != nil {
+		cached, err := db.Redis.Get(ctx, db.PrefixedKey(LiabilityKey)).Result()
+		if err == nil {
+			if liability, err := decimal.NewFromString(cached); err == nil {
+				return liability, nil
+			}
+		} else if !errors.Is(err, redis.Nil) {
+			logger.WarnF(ctx, "读取红包待领取总额缓存失败: %v", err)
+		}
+	}
+
+	return refreshOutstandingLiability(ctx)
+}
+
+// refreshOutstandingLiability 从数据库重新统计全平台进行中红包待领取总额并写入 Redis 缓存，校正增量累加产生的偏差
+func refreshOutstandingLiability(ctx context.Context) (decimal.Decimal, error) {
+	var liability decimal.Decimal
+	if err := db.DB(ctx).Model(&model.RedEnvelope{}).
+		Where("status IN ? AND test_mode = ?", openStatuses, false).
+		Select("COALESCE(SUM(remaining_amount), 0)").
+		Scan(&liability).Error; err != nil {
+		return decimal.Zero, err
+	}
+
+	if db.Redis != nil {
+		if err := db.Redis.Set(ctx, db.PrefixedKey(LiabilityKey), liability.String(), LiabilityCacheExpiration).Err(); err != nil {
+			logger.WarnF(ctx, "写入红包待领取总额缓存失败: %v", err)
+		}
+	}
+	return liability, nil
+}
+
+// addOutstandingLiability 创建红包后累加缓存中的待领取总额，领取和退款的减少在缓存过期后统一校正
+func addOutstandingLiability(ctx context.Context, amount decimal.Decimal) {
+	if db.Redis == nil {
+		return
+	}
+	if err := incrLiabilityScript.Run(ctx, db.Redis, []string{db.PrefixedKey(LiabilityKey)}, amount.String()).Err(); err != nil &&
+		!errors.Is(err, redis.Nil) {
+		logger.WarnF(ctx, "更新红包待领取总额缓存失败: %v", err)
+	}
+}
+
+// releaseClaimGateScript 闸门存在时归还一个名额，闸门已移除时不做处理
+var releaseClaimGateScript = redis.NewScript(`
+if redis.call("EXISTS", KEYS[1]) == 1 then
+	return redis.call("INCR", KEYS[1])
+end
+return false
+`)
+
+// acquireCreateLock 获取同一用户创建红包的互斥锁，其他创建请求处理中时等待其完成，返回释放函数
+// 相同参数的请求在上一次创建成功后短时间内再次到达时视为重复提交；Redis 未启用时不加锁
+func acquireCreateLock(ctx context.Context, userID uint64, fingerprint string) (func(created bool), error) {
+	if db.Redis == nil {
+		return func(bool) {}, nil
+	}
+
+	lockKey := db.PrefixedKey(fmt.Sprintf(CreateLockKeyFormat, userID))
+	token := util.GenerateUniqueIDSimple()
+	deadline := time.Now().Add(CreateLockWait)
+	for {
+		ok, err := db.Redis.SetNX(ctx, lockKey, token, CreateLockTTL).Result()
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			break
+		}
+		if time.Now().After(deadline) {
+			return nil, errors.New(CreateInProgress)
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(CreateLockPollInterval):
+		}
+	}
+
+	release := func(created bool) {
+		if created {
+			recentKey := db.PrefixedKey(fmt.Sprintf(RecentCreateKeyFormat, userID))
+			if err := db.Redis.Set(ctx, recentKey, fingerprint, DuplicateCreateWindow).Err(); err != nil {
+				logger.WarnF(ctx, "用户ID:%d 记录红包创建指纹失败: %v", userID, err)
+			}
+		}
+		if err := consumeClaimTokenScript.Run(ctx, db.Redis, []string{lockKey}, token).Err(); err != nil {
+			logger.WarnF(ctx, "用户ID:%d 释放红包创建锁失败: %v", userID, err)
+		}
+	}
+
+	// 等待期间前一个请求已用相同参数创建成功，当前请求为重复提交
+	recent, err := db.Redis.Get(ctx, db.PrefixedKey(fmt.Sprintf(RecentCreateKeyFormat, userID))).Result()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		release(false)
+		return nil, err
+	}
+	if recent == fingerprint {
+		release(false)
+		return nil, errors.New(DuplicateCreate)
+	}
+
+	return release, nil
+}
+
+// createFingerprint 计算创建红包参数的指纹，用于识别重复提交
+func createFingerprint(spec *EnvelopeSpec) string {
+	data, _ := json.Marshal(spec)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// initClaimGate 初始化红包的 Redis 剩余个数闸门，随红包过期自动失效
+func initClaimGate(ctx context.Context, redEnvelopeID uint64, count int, expiresAt time.Time) error {
+	key := db.PrefixedKey(fmt.Sprintf(ClaimGateKeyFormat, redEnvelopeID))
+	return db.Redis.Set(ctx, key, count, time.Until(expiresAt)+time.Hour).Err()
+}
+
+// acquireClaimGate 尝试通过闸门，返回是否被放行以及闸门是否存在
+// 闸门不存在（功能启用前创建的红包或 Redis 数据丢失）时直接回落到数据库校验
+func acquireClaimGate(ctx context.Context, redEnvelopeID uint64) (admitted bool, tracked bool, err error) {
+	key := db.PrefixedKey(fmt.Sprintf(ClaimGateKeyFormat, redEnvelopeID))
+	result, err := acquireClaimGateScript.Run(ctx, db.Redis, []string{key}).Int()
+	if err != nil {
+		return false, false, err
+	}
+	switch result {
+	case -2:
+		return true, false, nil
+	case -1:
+		return false, true, nil
+	default:
+		return true, true, nil
+	}
+}
+
+// releaseClaimGate 领取失败时归还闸门名额
+func releaseClaimGate(ctx context.Context, redEnvelopeID uint64) {
+	key := db.PrefixedKey(fmt.Sprintf(ClaimGateKeyFormat, redEnvelopeID))
+	if err := releaseClaimGateScript.Run(ctx, db.Redis, []string{key}).Err(); err != nil && !errors.Is(err, redis.Nil) {
+		logger.WarnF(ctx, "红包ID:%d 归还领取闸门名额失败: %v", redEnvelopeID, err)
+	}
+}
+
+// removeClaimGate 红包结束（领完或过期）后移除闸门，以数据库状态为准
+func removeClaimGate(ctx context.Context, redEnvelopeID uint64) {
+	key := db.PrefixedKey(fmt.Sprintf(ClaimGateKeyFormat, redEnvelopeID))
+	if err := db.Redis.Del(ctx, key).Err(); err != nil {
+		logger.WarnF(ctx, "红包ID:%d 移除领取闸门失败: %v", redEnvelopeID, err)
+	}
+}
+
+// issueClaimToken 为用户签发针对指定红包的一次性领取凭证
+func issueClaimToken(ctx context.Context, redEnvelopeID, userID uint64) (string, error) {
+	token := util.GenerateUniqueIDSimple()
+	key := db.PrefixedKey(fmt.Sprintf(ClaimTokenKeyFormat, redEnvelopeID, userID))
+	if err := db.Redis.Set(ctx, key, token, ClaimTokenExpiration).Err(); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// takeClaimToken 原子地校验并取走领取凭证，返回凭证剩余有效期（0表示未设置过期时间）供领取失败时恢复；凭证不匹配或已过期时 ok 为 false
+// 取走后并发的重复请求无法再使用同一凭证
+func takeClaimToken(ctx context.Context, redEnvelopeID, userID uint64, token string) (ttl time.Duration, ok bool, err error) {
+	if token == "" {
+		return 0, false, nil
+	}
+	key := db.PrefixedKey(fmt.Sprintf(ClaimTokenKeyFormat, redEnvelopeID, userID))
+	remaining, err := takeClaimTokenScript.Run(ctx, db.Redis, []string{key}, token).Int64()
+	if err != nil {
+		return 0, false, err
+	}
+	if remaining == -2 {
+		return 0, false, nil
+	}
+	return time.Duration(max(remaining, 0)) * time.Millisecond, true, nil
+}
+
+// restoreClaimToken 领取失败后按原剩余有效期恢复领取凭证，使用户可以凭同一凭证重试；期间已签发新凭证时不覆盖
+func restoreClaimToken(ctx context.Context, redEnvelopeID, userID uint64, token string, ttl time.Duration) {
+	key := db.PrefixedKey(fmt.Sprintf(ClaimTokenKeyFormat, redEnvelopeID, userID))
+	if err := db.Redis.SetNX(ctx, key, token, ttl).Err(); err != nil {
+		logger.WarnF(ctx, "红包ID:%d 用户ID:%d 恢复领取凭证失败: %v", redEnvelopeID, userID, err)
+	}
+}
+
+// snapToDenomination 将领取金额向下取整为面额的整数倍（至少一个面额），并为其余领取者各保留一个面额
+// 最后一个领取者领取全部剩余金额，红包总额保持不变
+func snapToDenomination(amount, remaining, denomination decimal.Decimal, count int) decimal.Decimal {
+	if count == 1 || denomination.LessThanOrEqual(util.MinAmountUnit()) {
+		return amount
+	}
+
+	snapped := amount.Div(denomination).Floor().Mul(denomination)
+	if snapped.LessThan(denomination) {
+		snapped = denomination
+	}
+	maxAllowed := remaining.Sub(denomination.Mul(decimal.NewFromInt(int64(count - 1))))
+	if snapped.GreaterThan(maxAllowed) {
+		snapped = maxAllowed
+	}
+	return snapped
+}
+
+// isMultipleOf 判断金额是否为面额的整数倍
+func isMultipleOf(amount, denomination decimal.Decimal) bool {
+	return amount.Mod(denomination).IsZero()
+}
+
+// calculateHybridAmount 计算保底加随机红包金额：保底金额加奖池（剩余金额减去剩余保底总额）的随机部分
+func calculateHybridAmount(remaining decimal.Decimal, base decimal.Decimal, count int) decimal.Decimal {
+	// 最后一个红包领取全部剩余金额（吸收舍入误差）
+	if count == 1 {
+		return remaining
+	}
+
+	// 奖池不足以给每人分配最小金额单位时仅发放保底金额，剩余奖池由最后一人领取
+	bonusPool := remaining.Sub(base.Mul(decimal.NewFromInt(int64(count))))
+	if bonusPool.LessThan(util.MinAmountUnit().Mul(decimal.NewFromInt(int64(count)))) {
+		return base
+	}
+
+	return base.Add(calculateRandomAmount(bonusPool, count))
+}
+
 // calculateRandomAmount 二倍均值算法计算随机红包金额
 func calculateRandomAmount(remaining decimal.Decimal, count int) decimal.Decimal {
+	return calculateRandomAmountWithFloor(remaining, count, decimal.Zero)
+}
+
+// calculateRandomAmountWithFloor 二倍均值算法计算随机红包金额，每人至少领取 floor（不足最小金额单位时按最小金额单位）
+func calculateRandomAmountWithFloor(remaining decimal.Decimal, count int, floor decimal.Decimal) decimal.Decimal {
+	return calculateWeightedRandomAmount(remaining, count, floor, decimal.NewFromInt(1))
+}
+
+// calculateWeightedRandomAmount 加权二倍均值算法，随机区间上限为平均金额乘以 factor 的两倍，factor 为1时即二倍均值算法
+// 无论 factor 大小，金额都不低于下限且为其余每人保留下限金额，最后一人领取全部剩余，总额严格守恒
+func calculateWeightedRandomAmount(remaining decimal.Decimal, count int, floor decimal.Decimal, factor decimal.Decimal) decimal.Decimal {
 	// 如果是最后一个红包，返回所有剩余金额（避免舍入误差）
 	if count == 1 {
 		return remaining
 	}
 
-	minAmount := decimal.NewFromFloat(0.01)
+	precision := util.AmountPrecision()
+	minAmount := util.MinAmountUnit()
+	if floor.GreaterThan(minAmount) {
+		minAmount = floor
+	}
 
-	// 确保剩余金额足够分配给所有人至少0.01
+	// 确保剩余金额足够分配给所有人至少最低金额
 	minRequired := minAmount.Mul(decimal.NewFromInt(int64(count)))
 	if remaining.LessThanOrEqual(minRequired) {
-		// 如果剩余金额刚好或不足，每人分配0.01（确保不会出现0 LDC的情况）
+		// 如果剩余金额刚好或不足，每人分配最低金额（确保不会出现0 LDC的情况）
 		return minAmount
 	}
 
-	// 二倍均值算法：金额范围 [0.01, min(剩余金额/剩余人数*2, 剩余金额-其他人最小金额)]
-	avg := remaining.Div(decimal.NewFromInt(int64(count)))
+	// 二倍均值算法：金额范围 [最低金额, min(剩余金额/剩余人数*权重系数*2, 剩余金额-其他人最小金额)]
+	avg := remaining.Div(decimal.NewFromInt(int64(count))).Mul(factor)
 	maxAmount := avg.Mul(decimal.NewFromInt(2))
 
-	// 确保给其他人留下足够的金额（每人至少0.01）
+	// 确保给其他人留下足够的金额（每人至少最低金额）
 	maxPossible := remaining.Sub(minAmount.Mul(decimal.NewFromInt(int64(count - 1))))
 	if maxAmount.GreaterThan(maxPossible) {
 		maxAmount = maxPossible
@@ -59,15 +1449,554 @@ func calculateRandomAmount(remaining decimal.Decimal, count int) decimal.Decimal
 		return minAmount
 	}
 
-	// 生成随机数：转换为分（cents）来处理，避免精度问题
-	diffCents := diff.Mul(decimal.NewFromInt(100)).IntPart()
-	if diffCents <= 0 {
+	// 生成随机数：转换为最小金额单位的整数倍来处理，避免精度问题
+	diffUnits := diff.Shift(precision).IntPart()
+	if diffUnits <= 0 {
 		return minAmount
 	}
 
-	randCents := rand.Int63n(diffCents + 1) // [0, diffCents]
-	randAmount := decimal.NewFromInt(randCents).Div(decimal.NewFromInt(100))
+	randUnits := rand.Int63n(diffUnits + 1) // [0, diffUnits]
+	randAmount := decimal.New(randUnits, -precision)
 	amount := minAmount.Add(randAmount)
 
-	return amount.Round(2)
+	return amount.Round(precision)
+}
+
+// loadTrustWeights 读取各信任等级的拆分权重，须为每个信任等级配置一个正数
+func loadTrustWeights(ctx context.Context) ([]decimal.Decimal, error) {
+	var sc model.SystemConfig
+	if err := sc.GetByKey(ctx, model.ConfigKeyRedEnvelopeTrustWeights); err != nil {
+		return nil, err
+	}
+	parts := strings.Split(sc.Value, ",")
+	if len(parts) != int(model.TrustLevelLeader)+1 {
+		return nil, fmt.Errorf("配置 %s 须为 %d 个信任等级各配置一个权重", model.ConfigKeyRedEnvelopeTrustWeights, int(model.TrustLevelLeader)+1)
+	}
+	weights := make([]decimal.Decimal, len(parts))
+	for i, part := range parts {
+		weight, err := decimal.NewFromString(strings.TrimSpace(part))
+		if err != nil || !weight.IsPositive() {
+			return nil, fmt.Errorf("配置 %s 的权重 '%s' 须为正数", model.ConfigKeyRedEnvelopeTrustWeights, part)
+		}
+		weights[i] = weight
+	}
+	return weights, nil
+}
+
+// claimTxOptions 按配置返回领取红包事务的隔离级别，未配置时返回 nil 使用数据库默认级别，配置无效时记录警告后同样回落到默认级别
+func claimTxOptions(ctx context.Context) *sql.TxOptions {
+	switch strings.ToLower(strings.TrimSpace(config.Config.RedEnvelope.ClaimIsolation)) {
+	case "":
+		return nil
+	case "read_committed":
+		return &sql.TxOptions{Isolation: sql.LevelReadCommitted}
+	case "repeatable_read":
+		return &sql.TxOptions{Isolation: sql.LevelRepeatableRead}
+	case "serializable":
+		return &sql.TxOptions{Isolation: sql.LevelSerializable}
+	default:
+		logger.WarnF(ctx, "领取红包事务隔离级别配置无效: %s，使用数据库默认级别", config.Config.RedEnvelope.ClaimIsolation)
+		return nil
+	}
+}
+
+// resolveListPaging 为未指定的页码及每页数量填充默认值：页码默认为1，每页数量取配置的默认值并限制在1到 MaxListPageSize 之间
+// 仅在未指定每页数量时读取配置，超出范围的请求值由 ListRequest 的绑定校验拒绝
+func resolveListPaging(req *ListRequest, defaultPageSize func() (int, error)) error {
+	if req.Page == 0 {
+		req.Page = 1
+	}
+	if req.PageSize == 0 {
+		pageSize, err := defaultPageSize()
+		if err != nil {
+			return err
+		}
+		req.PageSize = min(max(pageSize, 1), MaxListPageSize)
+	}
+	return nil
+}
+
+// applyBalanceCap 按领取者可用余额上限计算实际入账金额与需退还创建者的金额
+// 入账后不超过上限时全额入账；超出时拒绝模式返回 BalanceCapExceeded，部分入账模式仅入账至上限，
+// 超出部分退还创建者（已达上限时无可入账金额，同样拒绝领取）
+func applyBalanceCap(amount, availableBalance, balanceCap decimal.Decimal, partial bool) (credited, refund decimal.Decimal, err error) {
+	headroom := balanceCap.Sub(availableBalance)
+	if !amount.GreaterThan(headroom) {
+		return amount, decimal.Zero, nil
+	}
+	if !partial || !headroom.IsPositive() {
+		return decimal.Zero, decimal.Zero, errors.New(BalanceCapExceeded)
+	}
+	return headroom, amount.Sub(headroom), nil
+}
+
+// isDustRemainder 判断领取后的剩余金额是否为无法再领取的零头：低于零头阈值，且不足以让剩余名额各领取最低金额
+// 最低金额取最小金额单位、最低领取金额、保底金额及领取面额中的最大值；设置了预留名额的红包不判断
+func isDustRemainder(redEnvelope *model.RedEnvelope, remainingAmount decimal.Decimal, remainingCount int, threshold decimal.Decimal) bool {
+	if !threshold.IsPositive() || remainingCount <= 0 || redEnvelope.ReservedCount > 0 {
+		return false
+	}
+	if !remainingAmount.LessThan(threshold) {
+		return false
+	}
+	floor := decimal.Max(util.MinAmountUnit(), redEnvelope.MinClaimAmount, redEnvelope.BaseAmount, redEnvelope.Denomination)
+	return remainingAmount.LessThan(floor.Mul(decimal.NewFromInt(int64(remainingCount))))
+}
+
+// trustWeightFactor 返回信任等级权重相对各等级平均权重的系数，平均系数为1，使红包整体期望不变
+func trustWeightFactor(weights []decimal.Decimal, level model.TrustLevel) decimal.Decimal {
+	if len(weights) == 0 {
+		return decimal.NewFromInt(1)
+	}
+	total := decimal.Zero
+	for _, weight := range weights {
+		total = total.Add(weight)
+	}
+	index := min(int(level), len(weights)-1)
+	return weights[index].Mul(decimal.NewFromInt(int64(len(weights)))).Div(total)
+}
+
+// openStatuses 尚未结束、仍锁定剩余金额的红包状态
+var openStatuses = []model.RedEnvelopeStatus{model.RedEnvelopeStatusActive, model.RedEnvelopeStatusPaused}
+
+// redEnvelopeOrderTypes 红包相关的订单类型
+var redEnvelopeOrderTypes = []model.OrderType{
+	model.OrderTypeRedEnvelopeSend,
+	model.OrderTypeRedEnvelopeReceive,
+	model.OrderTypeRedEnvelopeRefund,
+	model.OrderTypeRedEnvelopeEscrow,
+	model.OrderTypeRedEnvelopeReturn,
+	model.OrderTypeRedEnvelopeWallet,
+	model.OrderTypeRedEnvelopeJackpot,
+}
+
+// writeExportSection 按批查询记录并以 "name":[...] 形式写入响应，每批写入后立即刷新
+func writeExportSection[T any](c *gin.Context, name string, query *gorm.DB) error {
+	if _, err := fmt.Fprintf(c.Writer, "%q:[", name); err != nil {
+		return err
+	}
+
+	first := true
+	var batch []T
+	if err := query.FindInBatches(&batch, ExportBatchSize, func(_ *gorm.DB, _ int) error {
+		for i := range batch {
+			data, err := json.Marshal(&batch[i])
+			if err != nil {
+				return err
+			}
+			if !first {
+				c.Writer.WriteString(",")
+			}
+			first = false
+			if _, err := c.Writer.Write(data); err != nil {
+				return err
+			}
+		}
+		c.Writer.Flush()
+		return nil
+	}).Error; err != nil {
+		return err
+	}
+
+	_, err := c.Writer.WriteString("]")
+	return err
+}
+
+// sendOrderRemark 生成红包支出订单备注，包含红包ID、个数、金额及手续费
+func sendOrderRemark(redEnvelope *model.RedEnvelope, feeAmount decimal.Decimal) string {
+	remark := fmt.Sprintf("创建红包，红包ID:%d，共%d个，金额: %s", redEnvelope.ID, redEnvelope.TotalCount, util.FormatAmount(redEnvelope.TotalAmount))
+	if feeAmount.IsPositive() {
+		remark = fmt.Sprintf("%s，手续费: %s", remark, util.FormatAmount(feeAmount))
+	}
+	if redEnvelope.FundedByEscrow {
+		remark = fmt.Sprintf("%s（托管资金）", remark)
+	}
+	if redEnvelope.TargetWallet != "" {
+		remark = fmt.Sprintf("%s（钱包: %s）", remark, redEnvelope.TargetWallet)
+	}
+	return withGreeting(remark, redEnvelope)
+}
+
+// receiveOrderRemark 生成红包收入订单备注，包含红包ID、领取金额及红包总额
+func receiveOrderRemark(redEnvelope *model.RedEnvelope, amount decimal.Decimal) string {
+	remark := fmt.Sprintf("领取红包，红包ID:%d，金额: %s / 总额: %s", redEnvelope.ID, util.FormatAmount(amount), util.FormatAmount(redEnvelope.TotalAmount))
+	if redEnvelope.TargetWallet != "" {
+		remark = fmt.Sprintf("%s（计入钱包: %s）", remark, redEnvelope.TargetWallet)
+	}
+	return withGreeting(remark, redEnvelope)
+}
+
+// refundOrderRemark 生成红包退款订单备注，包含退款原因、红包ID及退款金额，对账时按 "红包ID:" 解析
+func refundOrderRemark(redEnvelope *model.RedEnvelope, amount decimal.Decimal, reason string) string {
+	remark := fmt.Sprintf("%s，红包ID:%d，退款金额: %s", reason, redEnvelope.ID, util.FormatAmount(amount))
+	if redEnvelope.FundedByEscrow {
+		remark = fmt.Sprintf("%s（已退回托管余额）", remark)
+	}
+	if redEnvelope.TargetWallet != "" {
+		remark = fmt.Sprintf("%s（已退回钱包: %s）", remark, redEnvelope.TargetWallet)
+	}
+	return withGreeting(remark, redEnvelope)
+}
+
+// withGreeting 在订单备注末尾附加祝福语
+func withGreeting(remark string, redEnvelope *model.RedEnvelope) string {
+	if redEnvelope.Greeting == "" {
+		return remark
+	}
+	return fmt.Sprintf("%s，祝福语: %s", remark, redEnvelope.Greeting)
+}
+
+// validateCodeAlphabet 校验红包码字符集：至少2个不重复的可打印 ASCII 字符，不含空白及路径分隔符 /
+// 字符集按字节取字符，非 ASCII 字符会被截断为无效的 UTF-8
+func validateCodeAlphabet(alphabet string) error {
+	if len(alphabet) < 2 {
+		return fmt.Errorf("配置 red_envelope.code_alphabet 的值 '%s' 至少需要2个字符", alphabet)
+	}
+	var seen [128]bool
+	for i := 0; i < len(alphabet); i++ {
+		ch := alphabet[i]
+		if ch <= ' ' || ch > '~' || ch == '/' {
+			return fmt.Errorf("配置 red_envelope.code_alphabet 的值 '%s' 包含不支持的字符", alphabet)
+		}
+		if seen[ch] {
+			return fmt.Errorf("配置 red_envelope.code_alphabet 的值 '%s' 包含重复字符 '%c'", alphabet, ch)
+		}
+		seen[ch] = true
+	}
+	return nil
+}
+
+// generateEnvelopeCode 按配置的长度、字符集及前缀生成红包码
+func generateEnvelopeCode() (string, error) {
+	cfg := config.Config.RedEnvelope
+	alphabet := cfg.CodeAlphabet
+	if alphabet == "" {
+		alphabet = DefaultCodeAlphabet
+	}
+	if err := validateCodeAlphabet(alphabet); err != nil {
+		return "", err
+	}
+	length := cfg.CodeLength
+	if length <= 0 {
+		length = DefaultCodeLength
+	}
+	length = min(length, MaxCodeLength-len(cfg.CodePrefix))
+	if length <= 0 {
+		return "", errors.New(CodeGenerationFailed)
+	}
+
+	code := make([]byte, length)
+	limit := big.NewInt(int64(len(alphabet)))
+	for i := range code {
+		n, err := cryptorand.Int(cryptorand.Reader, limit)
+		if err != nil {
+			return "", err
+		}
+		code[i] = alphabet[n.Int64()]
+	}
+	return cfg.CodePrefix + string(code), nil
+}
+
+// newUniqueEnvelopeCode 生成未被占用的红包码，冲突时重试，超过 MaxCodeAttempts 次返回 CodeGenerationFailed
+func newUniqueEnvelopeCode(tx *gorm.DB) (string, error) {
+	for range MaxCodeAttempts {
+		code, err := generateEnvelopeCode()
+		if err != nil {
+			return "", err
+		}
+
+		var count int64
+		if err := tx.Model(&model.RedEnvelope{}).Where("code = ?", code).Count(&count).Error; err != nil {
+			return "", err
+		}
+		if count == 0 {
+			return code, nil
+		}
+	}
+	return "", errors.New(CodeGenerationFailed)
+}
+
+// generateLinkToken 生成不透明领取链接凭证
+func generateLinkToken() (string, error) {
+	buf := make([]byte, LinkTokenBytes)
+	if _, err := cryptorand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// hashLinkToken 计算领取链接凭证的 SHA-256 哈希，数据库仅保存哈希
+func hashLinkToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// resolveRedEnvelopeID 将路径参数解析为红包ID，先按不透明领取链接凭证或红包码查询，未找到时再按数字红包ID解析
+func resolveRedEnvelopeID(ctx context.Context, idOrCode string) (uint64, error) {
+	return resolveEnvelopeRef(idOrCode, func(column string, value string) (uint64, error) {
+		var redEnvelope model.RedEnvelope
+		if err := db.DB(ctx).Select("id").Where(column+" = ?", value).First(&redEnvelope).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return 0, errors.New(RedEnvelopeNotFound)
+			}
+			return 0, err
+		}
+		return redEnvelope.ID, nil
+	})
+}
+
+// resolveEnvelopeRef 按 lookup 查询红包码或领取链接凭证对应的红包ID
+// 自定义字符集可生成纯数字的红包码，因此红包码优先于数字红包ID；lookup 未找到时返回 RedEnvelopeNotFound
+func resolveEnvelopeRef(idOrCode string, lookup func(column string, value string) (uint64, error)) (uint64, error) {
+	var column, value string
+	switch {
+	case len(idOrCode) == LinkTokenLength:
+		column, value = "link_token_hash", hashLinkToken(idOrCode)
+	case idOrCode != "" && len(idOrCode) <= MaxCodeLength:
+		column, value = "code", idOrCode
+	}
+
+	if column != "" {
+		id, err := lookup(column, value)
+		if err == nil || err.Error() != RedEnvelopeNotFound {
+			return id, err
+		}
+	}
+
+	if id, err := strconv.ParseUint(idOrCode, 10, 64); err == nil {
+		return id, nil
+	}
+	if column != "" {
+		return 0, errors.New(RedEnvelopeNotFound)
+	}
+	return 0, errors.New(InvalidRedEnvelopeID)
+}
+
+// handlePauseToggle 按路径参数中的红包ID或红包码执行暂停或恢复领取
+func handlePauseToggle(c *gin.Context, toggle func(ctx context.Context, idOrCode string, userID uint64) error) {
+	currentUser, _ := util.GetFromContext[*model.User](c, oauth.UserObjKey)
+
+	if err := toggle(c.Request.Context(), c.Param("id"), currentUser.ID); err != nil {
+		switch err.Error() {
+		case RedEnvelopeExpired, RedEnvelopeFinished, RedEnvelopePaused, RedEnvelopeNotPaused:
+			c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+		default:
+			handleCreatorError(c, err)
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, util.OKNil())
+}
+
+// checkAudienceSize 私密红包个数超过名单可领取总次数（名单人数乘以每人可领取次数）时按配置处理
+// 提示模式返回提示信息，拒绝模式返回 AudienceTooSmall，调整模式将红包个数调整为可领取总次数并返回提示
+func checkAudienceSize(ctx context.Context, params *CreateParams, audience int) ([]string, error) {
+	maxClaims := audience * params.MaxClaimsPerUser
+	if params.TotalCount <= maxClaims {
+		return nil, nil
+	}
+
+	var sc model.SystemConfig
+	if err := sc.GetByKey(ctx, model.ConfigKeyRedEnvelopeAudienceOverflow); err != nil {
+		return nil, err
+	}
+
+	switch strings.TrimSpace(sc.Value) {
+	case AudienceOverflowReject:
+		return nil, errors.New(AudienceTooSmall)
+	case AudienceOverflowCap:
+		warning := fmt.Sprintf("红包个数 %d 超过可领取名单最多可领取的 %d 次，已调整为 %d 个", params.TotalCount, maxClaims, maxClaims)
+		params.TotalCount = maxClaims
+		return []string{warning}, nil
+	default:
+		return []string{fmt.Sprintf("红包个数 %d 超过可领取名单最多可领取的 %d 次，未领取的部分将在过期后退款", params.TotalCount, maxClaims)}, nil
+	}
+}
+
+// validateWalletName 校验专用钱包名称已在系统配置中启用
+func validateWalletName(ctx context.Context, name string) error {
+	var sc model.SystemConfig
+	if err := sc.GetByKey(ctx, model.ConfigKeyRedEnvelopeWallets); err != nil {
+		return err
+	}
+	for _, wallet := range strings.Split(sc.Value, ",") {
+		if wallet = strings.TrimSpace(wallet); wallet != "" && wallet == name {
+			return nil
+		}
+	}
+	return errors.New(InvalidTargetWallet)
+}
+
+// checkTestModeAllowed 校验用户是否可创建测试红包：管理员或配置名单内的用户
+func checkTestModeAllowed(ctx context.Context, tx *gorm.DB, userID uint64) error {
+	var user model.User
+	if err := tx.Select("id, username, is_admin").Where("id = ?", userID).First(&user).Error; err != nil {
+		return err
+	}
+	if user.IsAdmin {
+		return nil
+	}
+
+	var sc model.SystemConfig
+	if err := sc.GetByKey(ctx, model.ConfigKeyRedEnvelopeTestModeUsers); err != nil {
+		return err
+	}
+	for _, username := range strings.Split(sc.Value, ",") {
+		if username = strings.TrimSpace(username); username != "" && username == user.Username {
+			return nil
+		}
+	}
+	return errors.New(TestModeNotAllowed)
+}
+
+// markTestOrder 测试红包产生的订单记为测试订单，与真实资金流水隔离
+func markTestOrder(redEnvelope *model.RedEnvelope, order *model.Order) *model.Order {
+	if redEnvelope.TestMode {
+		order.Type = model.OrderTypeTest
+		order.Remark = common.TestModeOrderRemark + " " + order.Remark
+	}
+	return order
+}
+
+// handleWalletTransfer 校验专用钱包存取请求并执行划转
+func handleWalletTransfer(c *gin.Context, transfer func(ctx context.Context, userID uint64, name string, amount decimal.Decimal) error) {
+	var req WalletTransferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleBindError(c, err)
+		return
+	}
+
+	if err := util.ValidateAmount(req.Amount); err != nil {
+		c.JSON(http.StatusBadRequest, util.ErrFields(err.Error(), map[string]string{"amount": err.Error()}))
+		return
+	}
+	if err := validateWalletName(c.Request.Context(), req.Wallet); err != nil {
+		if err.Error() == InvalidTargetWallet {
+			c.JSON(http.StatusBadRequest, util.ErrFields(err.Error(), map[string]string{"wallet": err.Error()}))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		return
+	}
+
+	currentUser, _ := util.GetFromContext[*model.User](c, oauth.UserObjKey)
+	if !verifyPayKey(c, currentUser, req.PayKey) {
+		return
+	}
+
+	if err := transfer(c.Request.Context(), currentUser.ID, req.Wallet, req.Amount); err != nil {
+		switch err.Error() {
+		case common.InsufficientBalance, WalletInsufficient:
+			c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+		default:
+			c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, util.OKNil())
+}
+
+// recurringScheduleChecks 校验定期发放间隔时检查的后续发放次数
+const recurringScheduleChecks = 24
+
+// parseRecurringSchedule 解析定期发放的标准 5 段 Cron 表达式（按服务器时区），相邻发放间隔不得短于 MinRecurringInterval
+func parseRecurringSchedule(expr string, now time.Time) (cron.Schedule, error) {
+	schedule, err := cron.ParseStandard(strings.TrimSpace(expr))
+	if err != nil {
+		return nil, errors.New(InvalidRecurringCron)
+	}
+
+	prev := schedule.Next(now)
+	if prev.IsZero() {
+		return nil, errors.New(InvalidRecurringCron)
+	}
+	for i := 0; i < recurringScheduleChecks; i++ {
+		next := schedule.Next(prev)
+		if next.IsZero() {
+			break
+		}
+		if next.Sub(prev) < MinRecurringInterval {
+			return nil, errors.New(InvalidRecurringCron)
+		}
+		prev = next
+	}
+	return schedule, nil
+}
+
+// recurringEnvelopeItem 解析定期发放计划的参数模板，解析失败时返回空模板
+func recurringEnvelopeItem(ctx context.Context, plan model.RecurringEnvelope) RecurringEnvelopeItem {
+	item := RecurringEnvelopeItem{RecurringEnvelope: plan}
+	if err := json.Unmarshal([]byte(plan.Spec), &item.Spec); err != nil {
+		logger.WarnF(ctx, "定期发放计划ID:%d 解析参数模板失败: %v", plan.ID, err)
+	}
+	return item
+}
+
+// handleRecurringError 定期发放计划接口的错误响应，参数模板的校验错误按创建红包处理
+func handleRecurringError(c *gin.Context, err error) {
+	errMsg := err.Error()
+	switch errMsg {
+	case InvalidRecurringCron:
+		c.JSON(http.StatusBadRequest, util.ErrFields(errMsg, map[string]string{"cron": errMsg}))
+	case InvalidRecurringEndsAt:
+		c.JSON(http.StatusBadRequest, util.ErrFields(errMsg, map[string]string{"ends_at": errMsg}))
+	case RecurringOpaqueLink:
+		c.JSON(http.StatusBadRequest, util.ErrFields(errMsg, map[string]string{"spec.opaque_link": errMsg}))
+	case RecurringLimitReached:
+		c.JSON(http.StatusBadRequest, util.Err(errMsg))
+	case RecurringNotFound:
+		c.JSON(http.StatusNotFound, util.Err(errMsg))
+	default:
+		handleCreateError(c, err)
+	}
+}
+
+// envelopeFairness 返回红包的分配均衡度，优先使用红包上保存的结果，未保存时按领取记录计算并保存
+// 无领取记录（含已归档且未保存）时返回 nil
+func envelopeFairness(ctx context.Context, redEnvelope *model.RedEnvelope, claims []ResultClaim) *Fairness {
+	if redEnvelope.FairnessGini != nil && redEnvelope.FairnessRatio != nil {
+		return &Fairness{Gini: *redEnvelope.FairnessGini, MaxMinRatio: *redEnvelope.FairnessRatio}
+	}
+	if len(claims) == 0 {
+		return nil
+	}
+
+	amounts := make([]decimal.Decimal, len(claims))
+	for i := range claims {
+		amounts[i] = claims[i].RedEnvelopeClaim.Amount
+	}
+	fairness := calculateFairness(amounts)
+
+	if err := db.DB(ctx).Model(&model.RedEnvelope{}).
+		Where("id = ? AND fairness_gini IS NULL", redEnvelope.ID).
+		Updates(map[string]interface{}{"fairness_gini": fairness.Gini, "fairness_ratio": fairness.MaxMinRatio}).Error; err != nil {
+		logger.WarnF(ctx, "红包ID:%d 保存分配均衡度失败: %v", redEnvelope.ID, err)
+	}
+	return fairness
+}
+
+// calculateFairness 计算领取金额的基尼系数及最大与最小金额之比，结果保留4位小数
+// 基尼系数按升序排列的金额 x(1..n) 计算：G = 2·Σ i·x(i) / (n·Σx) - (n+1)/n
+func calculateFairness(amounts []decimal.Decimal) *Fairness {
+	sorted := slices.Clone(amounts)
+	slices.SortFunc(sorted, func(a, b decimal.Decimal) int { return a.Cmp(b) })
+
+	n := decimal.NewFromInt(int64(len(sorted)))
+	total, weighted := decimal.Zero, decimal.Zero
+	for i, amount := range sorted {
+		total = total.Add(amount)
+		weighted = weighted.Add(amount.Mul(decimal.NewFromInt(int64(i + 1))))
+	}
+
+	fairness := &Fairness{Gini: decimal.Zero, MaxMinRatio: decimal.NewFromInt(1)}
+	if total.IsPositive() {
+		gini := weighted.Mul(decimal.NewFromInt(2)).Div(n.Mul(total)).Sub(n.Add(decimal.NewFromInt(1)).Div(n))
+		fairness.Gini = decimal.Max(gini, decimal.Zero).Round(4)
+	}
+	if minAmount := sorted[0]; minAmount.IsPositive() {
+		fairness.MaxMinRatio = sorted[len(sorted)-1].Div(minAmount).Round(4)
+	}
+	return fairness
 }
@@ -0,0 +1,773 @@
+/*
+Copyright 2025 linux.do
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package redenvelope
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+	_ "time/tzdata"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/linux-do/credit/internal/config"
+	"github.com/linux-do/credit/internal/model"
+	"github.com/linux-do/credit/internal/util"
+	"github.com/shopspring/decimal"
+)
+
+// setAmountPrecision 在测试期间修改配置的金额小数位数，测试结束后恢复
+func setAmountPrecision(t *testing.T, precision int) {
+	t.Helper()
+	previous := config.Config.App.AmountPrecision
+	config.Config.App.AmountPrecision = precision
+	t.Cleanup(func() { config.Config.App.AmountPrecision = previous })
+}
+
+// drainEnvelope 按 next 依次计算每个名额的领取金额直到领完，返回各名额金额
+func drainEnvelope(t *testing.T, total decimal.Decimal, count int, next func(remaining decimal.Decimal, left int) decimal.Decimal) []decimal.Decimal {
+	t.Helper()
+	amounts := make([]decimal.Decimal, 0, count)
+	remaining := total
+	for left := count; left > 0; left-- {
+		amount := next(remaining, left)
+		if amount.Exponent() < -util.AmountPrecision() {
+			t.Fatalf("amount %s exceeds %d decimal places", amount, util.AmountPrecision())
+		}
+		remaining = remaining.Sub(amount)
+		amounts = append(amounts, amount)
+	}
+	if !remaining.IsZero() {
+		t.Fatalf("remaining after all claims = %s, want 0", remaining)
+	}
+	return amounts
+}
+
+// sumAmounts 汇总金额
+func sumAmounts(amounts []decimal.Decimal) decimal.Decimal {
+	sum := decimal.Zero
+	for _, amount := range amounts {
+		sum = sum.Add(amount)
+	}
+	return sum
+}
+
+func TestRandomAmountSumsExactlyAtFourDecimals(t *testing.T) {
+	setAmountPrecision(t, 4)
+
+	cases := []struct {
+		total string
+		count int
+	}{
+		{"1", 7},
+		{"0.0010", 10},
+		{"12345.6789", 100},
+		{"3.3333", 3},
+	}
+	for _, tc := range cases {
+		total := decimal.RequireFromString(tc.total)
+		for range 200 {
+			amounts := drainEnvelope(t, total, tc.count, calculateRandomAmount)
+			for _, amount := range amounts {
+				if amount.LessThan(util.MinAmountUnit()) {
+					t.Fatalf("total %s / %d: amount %s below minimum unit", tc.total, tc.count, amount)
+				}
+			}
+			if sum := sumAmounts(amounts); !sum.Equal(total) {
+				t.Fatalf("total %s / %d: sum = %s", tc.total, tc.count, sum)
+			}
+		}
+	}
+}
+
+// mustLoadLocation 加载时区，失败时终止测试
+func mustLoadLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatalf("load location %s: %v", name, err)
+	}
+	return location
+}
+
+// mustParseBlackout 解析暂停领取时段配置，失败时终止测试
+func mustParseBlackout(t *testing.T, value string) []blackoutRule {
+	t.Helper()
+	rules, err := parseBlackoutSchedule(value)
+	if err != nil {
+		t.Fatalf("parse blackout schedule %q: %v", value, err)
+	}
+	return rules
+}
+
+func TestNextClaimOpenAt(t *testing.T) {
+	shanghai := mustLoadLocation(t, "Asia/Shanghai")
+
+	cases := []struct {
+		name       string
+		schedule   string
+		now        time.Time
+		wantClosed bool
+		wantOpenAt time.Time
+	}{
+		{
+			name:       "before window",
+			schedule:   "daily 23:00-07:00",
+			now:        time.Date(2026, 10, 16, 22, 59, 0, 0, shanghai),
+			wantClosed: false,
+		},
+		{
+			name:       "window start is closed",
+			schedule:   "daily 23:00-07:00",
+			now:        time.Date(2026, 10, 16, 23, 0, 0, 0, shanghai),
+			wantClosed: true,
+			wantOpenAt: time.Date(2026, 10, 17, 7, 0, 0, 0, shanghai),
+		},
+		{
+			name:       "window end is open",
+			schedule:   "daily 23:00-07:00",
+			now:        time.Date(2026, 10, 17, 7, 0, 0, 0, shanghai),
+			wantClosed: false,
+		},
+		{
+			name:       "carried over from previous day",
+			schedule:   "fri 22:00-02:00",
+			now:        time.Date(2026, 10, 17, 1, 30, 0, 0, shanghai),
+			wantClosed: true,
+			wantOpenAt: time.Date(2026, 10, 17, 2, 0, 0, 0, shanghai),
+		},
+		{
+			name:       "adjacent windows merge",
+			schedule:   "fri 09:00-12:00; fri 12:00-13:00",
+			now:        time.Date(2026, 10, 16, 11, 0, 0, 0, shanghai),
+			wantClosed: true,
+			wantOpenAt: time.Date(2026, 10, 16, 13, 0, 0, 0, shanghai),
+		},
+		{
+			name:       "weekday range wraps the week",
+			schedule:   "sat-sun 00:00-24:00",
+			now:        time.Date(2026, 10, 17, 12, 0, 0, 0, shanghai),
+			wantClosed: true,
+			wantOpenAt: time.Date(2026, 10, 19, 0, 0, 0, 0, shanghai),
+		},
+		{
+			name:       "closed beyond lookahead",
+			schedule:   "daily 00:00-24:00",
+			now:        time.Date(2026, 10, 16, 12, 0, 0, 0, shanghai),
+			wantClosed: true,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			closed, openAt := nextClaimOpenAt(mustParseBlackout(t, tc.schedule), tc.now)
+			if closed != tc.wantClosed {
+				t.Fatalf("closed = %v, want %v", closed, tc.wantClosed)
+			}
+			if !openAt.Equal(tc.wantOpenAt) {
+				t.Fatalf("openAt = %v, want %v", openAt, tc.wantOpenAt)
+			}
+		})
+	}
+}
+
+func TestNextClaimOpenAtUsesLocalDate(t *testing.T) {
+	shanghai := mustLoadLocation(t, "Asia/Shanghai")
+	rules := mustParseBlackout(t, "sat 00:00-06:00")
+
+	// UTC 周五 16:30 即上海时间周六 00:30，应按当地日期命中周六的时段
+	now := time.Date(2026, 10, 16, 16, 30, 0, 0, time.UTC)
+	closed, openAt := nextClaimOpenAt(rules, now.In(shanghai))
+	if !closed {
+		t.Fatal("closed = false, want true")
+	}
+	if want := time.Date(2026, 10, 16, 22, 0, 0, 0, time.UTC); !openAt.Equal(want) {
+		t.Fatalf("openAt = %v, want %v", openAt.UTC(), want)
+	}
+
+	// 同一时刻按 UTC 计算仍是周五，不处于暂停时段
+	if closed, _ := nextClaimOpenAt(rules, now); closed {
+		t.Fatal("closed in UTC = true, want false")
+	}
+}
+
+func TestNextClaimOpenAtAcrossDST(t *testing.T) {
+	newYork := mustLoadLocation(t, "America/New_York")
+
+	cases := []struct {
+		name       string
+		schedule   string
+		now        time.Time
+		wantOpenAt time.Time
+		wantWait   time.Duration
+	}{
+		{
+			// 2026-03-08 02:00 EST 跳至 03:00 EDT，当天当地时间 01:30-03:00 实际只有30分钟
+			name:       "spring forward",
+			schedule:   "sun 01:00-03:00",
+			now:        time.Date(2026, 3, 8, 1, 30, 0, 0, newYork),
+			wantOpenAt: time.Date(2026, 3, 8, 3, 0, 0, 0, newYork),
+			wantWait:   30 * time.Minute,
+		},
+		{
+			// 2026-11-01 02:00 EDT 回拨至 01:00 EST，当天当地时间 01:30-03:00 实际为2.5小时
+			name:       "fall back",
+			schedule:   "sun 00:00-03:00",
+			now:        time.Date(2026, 11, 1, 1, 30, 0, 0, newYork),
+			wantOpenAt: time.Date(2026, 11, 1, 3, 0, 0, 0, newYork),
+			wantWait:   150 * time.Minute,
+		},
+		{
+			name:       "overnight window ends after spring forward",
+			schedule:   "sat 22:00-06:00",
+			now:        time.Date(2026, 3, 7, 23, 0, 0, 0, newYork),
+			wantOpenAt: time.Date(2026, 3, 8, 6, 0, 0, 0, newYork),
+			wantWait:   6 * time.Hour,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			closed, openAt := nextClaimOpenAt(mustParseBlackout(t, tc.schedule), tc.now)
+			if !closed {
+				t.Fatal("closed = false, want true")
+			}
+			if !openAt.Equal(tc.wantOpenAt) {
+				t.Fatalf("openAt = %v, want %v", openAt, tc.wantOpenAt)
+			}
+			if wait := openAt.Sub(tc.now); wait != tc.wantWait {
+				t.Fatalf("wait = %v, want %v", wait, tc.wantWait)
+			}
+		})
+	}
+}
+
+func TestFinishedEnvelopeOnlyReopensOnReturn(t *testing.T) {
+	// 状态校验先于数据库操作，非法流转不会访问事务
+	finished := &model.RedEnvelope{Status: model.RedEnvelopeStatusFinished}
+	for _, to := range []model.RedEnvelopeStatus{model.RedEnvelopeStatusActive, model.RedEnvelopeStatusPaused, model.RedEnvelopeStatusExpired} {
+		err := transitionStatus(nil, finished, to, nil, statusEvent{})
+		if err == nil || !strings.HasPrefix(err.Error(), InvalidStatusTransition) {
+			t.Fatalf("finished -> %s: err = %v, want %s", to, err, InvalidStatusTransition)
+		}
+	}
+
+	for _, from := range []model.RedEnvelopeStatus{model.RedEnvelopeStatusActive, model.RedEnvelopeStatusPaused, model.RedEnvelopeStatusExpired} {
+		err := reopenFinishedEnvelope(nil, &model.RedEnvelope{Status: from}, nil, statusEvent{})
+		if err == nil || !strings.HasPrefix(err.Error(), InvalidStatusTransition) {
+			t.Fatalf("reopen from %s: err = %v, want %s", from, err, InvalidStatusTransition)
+		}
+	}
+}
+
+// setCodeConfig 在测试期间修改红包码配置，测试结束后恢复
+func setCodeConfig(t *testing.T, length int, alphabet string, prefix string) {
+	t.Helper()
+	previous := config.Config.RedEnvelope
+	config.Config.RedEnvelope.CodeLength = length
+	config.Config.RedEnvelope.CodeAlphabet = alphabet
+	config.Config.RedEnvelope.CodePrefix = prefix
+	t.Cleanup(func() { config.Config.RedEnvelope = previous })
+}
+
+func TestGenerateEnvelopeCodeFormat(t *testing.T) {
+	cases := []struct {
+		name     string
+		length   int
+		alphabet string
+		prefix   string
+		wantLen  int
+		charset  string
+	}{
+		{"defaults", 0, "", "", DefaultCodeLength, DefaultCodeAlphabet},
+		{"digits only", 6, "0123456789", "", 6, "0123456789"},
+		{"with prefix", 10, "ab", "HB-", 13, "ab"},
+		{"clamped to column length", 64, "XYZ", "HB-", MaxCodeLength, "XYZ"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			setCodeConfig(t, tc.length, tc.alphabet, tc.prefix)
+			for range 100 {
+				code, err := generateEnvelopeCode()
+				if err != nil {
+					t.Fatalf("generateEnvelopeCode: %v", err)
+				}
+				if len(code) != tc.wantLen {
+					t.Fatalf("code %q length = %d, want %d", code, len(code), tc.wantLen)
+				}
+				body, ok := strings.CutPrefix(code, tc.prefix)
+				if !ok {
+					t.Fatalf("code %q missing prefix %q", code, tc.prefix)
+				}
+				if i := strings.IndexFunc(body, func(r rune) bool { return !strings.ContainsRune(tc.charset, r) }); i >= 0 {
+					t.Fatalf("code %q has %q outside alphabet %q", code, body[i], tc.charset)
+				}
+			}
+		})
+	}
+}
+
+func TestGenerateEnvelopeCodeRejectsInvalidAlphabet(t *testing.T) {
+	for _, alphabet := range []string{"A", "AAB", "红包码", "AB CD", "AB/CD", "AB\tC"} {
+		setCodeConfig(t, 8, alphabet, "")
+		if code, err := generateEnvelopeCode(); err == nil {
+			t.Fatalf("alphabet %q: got code %q, want error", alphabet, code)
+		}
+	}
+	prefixOnly := strings.Repeat("P", MaxCodeLength)
+	setCodeConfig(t, 8, "", prefixOnly)
+	if _, err := generateEnvelopeCode(); err == nil || err.Error() != CodeGenerationFailed {
+		t.Fatalf("prefix filling the column: err = %v, want %s", err, CodeGenerationFailed)
+	}
+}
+
+func TestResolveEnvelopeRefPrefersCode(t *testing.T) {
+	token := strings.Repeat("t", LinkTokenLength)
+	rows := map[string]uint64{
+		"code:12345":   900,
+		"code:HB-ABCD": 901,
+		"link_token_hash:" + hashLinkToken(token): 902,
+	}
+	lookup := func(column string, value string) (uint64, error) {
+		if id, ok := rows[column+":"+value]; ok {
+			return id, nil
+		}
+		return 0, errors.New(RedEnvelopeNotFound)
+	}
+
+	cases := []struct {
+		ref     string
+		wantID  uint64
+		wantErr string
+	}{
+		{"12345", 900, ""},
+		{"HB-ABCD", 901, ""},
+		{token, 902, ""},
+		{"67890", 67890, ""},
+		{"NOPE", 0, RedEnvelopeNotFound},
+		{"", 0, InvalidRedEnvelopeID},
+		{strings.Repeat("x", MaxCodeLength+1), 0, InvalidRedEnvelopeID},
+	}
+	for _, tc := range cases {
+		id, err := resolveEnvelopeRef(tc.ref, lookup)
+		if tc.wantErr != "" {
+			if err == nil || err.Error() != tc.wantErr {
+				t.Fatalf("ref %q: err = %v, want %s", tc.ref, err, tc.wantErr)
+			}
+			continue
+		}
+		if err != nil || id != tc.wantID {
+			t.Fatalf("ref %q: id = %d, err = %v, want %d", tc.ref, id, err, tc.wantID)
+		}
+	}
+
+	// 查询出错时直接返回，不回落到数字ID
+	failing := func(string, string) (uint64, error) { return 0, errors.New("db down") }
+	if _, err := resolveEnvelopeRef("12345", failing); err == nil || err.Error() != "db down" {
+		t.Fatalf("lookup failure: err = %v, want db down", err)
+	}
+}
+
+func TestDeepLinkTokenRoundTrip(t *testing.T) {
+	previous := config.Config.RedEnvelope.DeepLinkSecret
+	config.Config.RedEnvelope.DeepLinkSecret = "test-secret"
+	t.Cleanup(func() { config.Config.RedEnvelope.DeepLinkSecret = previous })
+
+	token, expiresAt, err := GenerateDeepLinkToken(100, 7)
+	if err != nil {
+		t.Fatalf("GenerateDeepLinkToken: %v", err)
+	}
+	redEnvelopeID, userID, nonce, parsedExpiresAt, err := parseDeepLinkToken(token)
+	if err != nil {
+		t.Fatalf("parseDeepLinkToken: %v", err)
+	}
+	if redEnvelopeID != 100 || userID != 7 || nonce == "" || parsedExpiresAt.Unix() != expiresAt.Unix() {
+		t.Fatalf("parsed (%d, %d, %q, %v), want (100, 7, nonce, %v)", redEnvelopeID, userID, nonce, parsedExpiresAt, expiresAt)
+	}
+
+	// 篡改用户ID后签名不再匹配
+	tampered := strings.Replace(token, "100.7.", "100.8.", 1)
+	if _, _, _, _, err := parseDeepLinkToken(tampered); err == nil || err.Error() != DeepLinkTokenInvalid {
+		t.Fatalf("tampered token: err = %v, want %s", err, DeepLinkTokenInvalid)
+	}
+
+	config.Config.RedEnvelope.DeepLinkSecret = "rotated-secret"
+	if _, _, _, _, err := parseDeepLinkToken(token); err == nil || err.Error() != DeepLinkTokenInvalid {
+		t.Fatalf("rotated secret: err = %v, want %s", err, DeepLinkTokenInvalid)
+	}
+
+	config.Config.RedEnvelope.DeepLinkSecret = ""
+	if _, _, err := GenerateDeepLinkToken(100, 7); err == nil || err.Error() != DeepLinkDisabled {
+		t.Fatalf("disabled: err = %v, want %s", err, DeepLinkDisabled)
+	}
+}
+
+// defaultTrustWeights 与系统配置默认值一致的各信任等级权重
+func defaultTrustWeights() []decimal.Decimal {
+	weights := make([]decimal.Decimal, 0, 5)
+	for _, weight := range []string{"0.8", "0.9", "1", "1.1", "1.2"} {
+		weights = append(weights, decimal.RequireFromString(weight))
+	}
+	return weights
+}
+
+func TestTrustWeightFactor(t *testing.T) {
+	weights := defaultTrustWeights()
+
+	sum := decimal.Zero
+	for level := model.TrustLevelNewUser; level <= model.TrustLevelLeader; level++ {
+		factor := trustWeightFactor(weights, level)
+		if level > model.TrustLevelNewUser && !factor.GreaterThan(trustWeightFactor(weights, level-1)) {
+			t.Fatalf("factor for level %d = %s, want greater than level %d", level, factor, level-1)
+		}
+		sum = sum.Add(factor)
+	}
+	if !sum.Equal(decimal.NewFromInt(5)) {
+		t.Fatalf("factors sum = %s, want 5 (average 1)", sum)
+	}
+
+	// 超出配置范围的等级按最高等级计算，未配置权重时系数为1
+	if got, want := trustWeightFactor(weights, model.TrustLevelLeader+3), trustWeightFactor(weights, model.TrustLevelLeader); !got.Equal(want) {
+		t.Fatalf("factor beyond range = %s, want %s", got, want)
+	}
+	if got := trustWeightFactor(nil, model.TrustLevelUser); !got.Equal(decimal.NewFromInt(1)) {
+		t.Fatalf("factor without weights = %s, want 1", got)
+	}
+}
+
+func TestWeightedRandomAmountSumsAndKeepsFloor(t *testing.T) {
+	setAmountPrecision(t, 2)
+	weights := defaultTrustWeights()
+
+	cases := []struct {
+		name  string
+		total string
+		count int
+		floor string
+	}{
+		{"no floor", "100", 10, "0"},
+		{"with floor", "100", 10, "5"},
+		{"floor leaves little room", "10.5", 10, "1"},
+		{"floor equals share", "10", 10, "1"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			total := decimal.RequireFromString(tc.total)
+			floor := decimal.Max(decimal.RequireFromString(tc.floor), util.MinAmountUnit())
+			for _, levels := range [][]model.TrustLevel{
+				{model.TrustLevelNewUser},
+				{model.TrustLevelLeader},
+				{model.TrustLevelNewUser, model.TrustLevelLeader, model.TrustLevelUser},
+			} {
+				for range 200 {
+					claimed := 0
+					amounts := drainEnvelope(t, total, tc.count, func(remaining decimal.Decimal, left int) decimal.Decimal {
+						factor := trustWeightFactor(weights, levels[claimed%len(levels)])
+						claimed++
+						return calculateWeightedRandomAmount(remaining, left, decimal.RequireFromString(tc.floor), factor)
+					})
+					for i, amount := range amounts {
+						if amount.LessThan(floor) {
+							t.Fatalf("levels %v: amount #%d = %s below floor %s", levels, i, amount, floor)
+						}
+					}
+					if sum := sumAmounts(amounts); !sum.Equal(total) {
+						t.Fatalf("levels %v: sum = %s, want %s", levels, sum, total)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestIsDustRemainder(t *testing.T) {
+	setAmountPrecision(t, 2)
+	d := decimal.RequireFromString
+
+	cases := []struct {
+		name      string
+		envelope  model.RedEnvelope
+		remaining string
+		count     int
+		threshold string
+		want      bool
+	}{
+		{"threshold disabled", model.RedEnvelope{}, "0.01", 5, "0", false},
+		{"remaining equals threshold", model.RedEnvelope{}, "0.03", 5, "0.03", false},
+		{"remaining above threshold", model.RedEnvelope{}, "0.04", 5, "0.03", false},
+		{"below threshold and below unit floor", model.RedEnvelope{}, "0.02", 5, "0.03", true},
+		{"below threshold but covers unit floor", model.RedEnvelope{}, "0.05", 5, "1", false},
+		{"exactly covers min claim floor", model.RedEnvelope{MinClaimAmount: d("1")}, "5", 5, "10", false},
+		{"one unit short of min claim floor", model.RedEnvelope{MinClaimAmount: d("1")}, "4.99", 5, "10", true},
+		{"base amount raises floor", model.RedEnvelope{MinClaimAmount: d("1"), BaseAmount: d("2")}, "9.99", 5, "10", true},
+		{"denomination raises floor", model.RedEnvelope{Denomination: d("5")}, "20", 5, "30", true},
+		{"reserved slots never dust", model.RedEnvelope{MinClaimAmount: d("1"), ReservedCount: 1}, "0.5", 5, "10", false},
+		{"no claims left", model.RedEnvelope{}, "0.01", 0, "1", false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			envelope := tc.envelope
+			if got := isDustRemainder(&envelope, d(tc.remaining), tc.count, d(tc.threshold)); got != tc.want {
+				t.Fatalf("isDustRemainder(%s, %d, %s) = %v, want %v", tc.remaining, tc.count, tc.threshold, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestDenominationSnapSumsExactly(t *testing.T) {
+	setAmountPrecision(t, 2)
+
+	cases := []struct {
+		name         string
+		total        string
+		count        int
+		denomination string
+	}{
+		{"whole units", "100", 10, "1"},
+		{"fives", "500", 7, "5"},
+		{"fractional denomination", "12.5", 9, "0.5"},
+		{"total equals one denomination each", "50", 10, "5"},
+		{"one spare denomination", "55", 10, "5"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			total := decimal.RequireFromString(tc.total)
+			denomination := decimal.RequireFromString(tc.denomination)
+			for range 200 {
+				amounts := drainEnvelope(t, total, tc.count, func(remaining decimal.Decimal, left int) decimal.Decimal {
+					return snapToDenomination(calculateRandomAmount(remaining, left), remaining, denomination, left)
+				})
+				for i, amount := range amounts {
+					if amount.LessThan(denomination) || !isMultipleOf(amount, denomination) {
+						t.Fatalf("amount #%d = %s, want a positive multiple of %s", i, amount, denomination)
+					}
+				}
+				if sum := sumAmounts(amounts); !sum.Equal(total) {
+					t.Fatalf("sum = %s, want %s", sum, total)
+				}
+			}
+		})
+	}
+}
+
+func TestHybridAmountSumsAndKeepsBase(t *testing.T) {
+	setAmountPrecision(t, 2)
+
+	cases := []struct {
+		name  string
+		total string
+		count int
+		base  string
+	}{
+		{"large bonus pool", "100", 10, "2"},
+		{"zero bonus pool", "20", 10, "2"},
+		{"bonus pool below one unit each", "20.05", 10, "2"},
+		{"bonus pool of one unit each", "20.1", 10, "2"},
+		{"single claimer", "7.5", 1, "2"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			total := decimal.RequireFromString(tc.total)
+			base := decimal.RequireFromString(tc.base)
+			for range 200 {
+				amounts := drainEnvelope(t, total, tc.count, func(remaining decimal.Decimal, left int) decimal.Decimal {
+					return calculateHybridAmount(remaining, base, left)
+				})
+				for i, amount := range amounts {
+					if amount.LessThan(base) {
+						t.Fatalf("amount #%d = %s below base %s", i, amount, base)
+					}
+				}
+				if sum := sumAmounts(amounts); !sum.Equal(total) {
+					t.Fatalf("sum = %s, want %s", sum, total)
+				}
+			}
+		})
+	}
+}
+
+func TestRandomAmountWithFloorAtBoundary(t *testing.T) {
+	setAmountPrecision(t, 2)
+	floor := decimal.RequireFromString("1.5")
+	count := 8
+	exact := floor.Mul(decimal.NewFromInt(int64(count)))
+
+	// 总额恰好等于保底总额时每人只能领到保底金额
+	amounts := drainEnvelope(t, exact, count, func(remaining decimal.Decimal, left int) decimal.Decimal {
+		return calculateRandomAmountWithFloor(remaining, left, floor)
+	})
+	for i, amount := range amounts {
+		if !amount.Equal(floor) {
+			t.Fatalf("amount #%d = %s, want exactly %s", i, amount, floor)
+		}
+	}
+
+	// 比保底总额多一个最小单位时多出的部分只能落在某一人身上
+	total := exact.Add(util.MinAmountUnit())
+	for range 200 {
+		amounts := drainEnvelope(t, total, count, func(remaining decimal.Decimal, left int) decimal.Decimal {
+			return calculateRandomAmountWithFloor(remaining, left, floor)
+		})
+		for i, amount := range amounts {
+			if amount.LessThan(floor) {
+				t.Fatalf("amount #%d = %s below floor %s", i, amount, floor)
+			}
+		}
+		if sum := sumAmounts(amounts); !sum.Equal(total) {
+			t.Fatalf("sum = %s, want %s", sum, total)
+		}
+	}
+}
+
+func TestApplyBalanceCap(t *testing.T) {
+	d := decimal.RequireFromString
+
+	cases := []struct {
+		name         string
+		amount       string
+		available    string
+		partial      bool
+		wantCredited string
+		wantRefund   string
+		wantErr      bool
+	}{
+		{"under cap", "10", "80", false, "10", "0", false},
+		{"exactly reaches cap", "20", "80", false, "20", "0", false},
+		{"reject mode over cap", "30", "80", false, "", "", true},
+		{"partial mode over cap", "30", "80", true, "20", "10", false},
+		{"partial mode already at cap", "30", "100", true, "", "", true},
+		{"partial mode above cap", "30", "120", true, "", "", true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			credited, refund, err := applyBalanceCap(d(tc.amount), d(tc.available), d("100"), tc.partial)
+			if tc.wantErr {
+				if err == nil || err.Error() != BalanceCapExceeded {
+					t.Fatalf("err = %v, want %s", err, BalanceCapExceeded)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !credited.Equal(d(tc.wantCredited)) || !refund.Equal(d(tc.wantRefund)) {
+				t.Fatalf("credited, refund = %s, %s, want %s, %s", credited, refund, tc.wantCredited, tc.wantRefund)
+			}
+			if !credited.Add(refund).Equal(d(tc.amount)) {
+				t.Fatalf("credited + refund = %s, want %s", credited.Add(refund), tc.amount)
+			}
+		})
+	}
+}
+
+func TestResolveListPaging(t *testing.T) {
+	cases := []struct {
+		name         string
+		req          ListRequest
+		configured   int
+		wantPage     int
+		wantPageSize int
+	}{
+		{"both omitted", ListRequest{}, 20, 1, 20},
+		{"explicit values kept", ListRequest{Page: 3, PageSize: 50}, 20, 3, 50},
+		{"configured default above cap", ListRequest{Page: 2}, 500, 2, MaxListPageSize},
+		{"configured default not positive", ListRequest{}, 0, 1, 1},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := tc.req
+			lookups := 0
+			if err := resolveListPaging(&req, func() (int, error) {
+				lookups++
+				return tc.configured, nil
+			}); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if req.Page != tc.wantPage || req.PageSize != tc.wantPageSize {
+				t.Fatalf("page, page size = %d, %d, want %d, %d", req.Page, req.PageSize, tc.wantPage, tc.wantPageSize)
+			}
+			if tc.req.PageSize != 0 && lookups != 0 {
+				t.Fatalf("default page size looked up %d times for an explicit page size", lookups)
+			}
+		})
+	}
+
+	lookupErr := errors.New("config unavailable")
+	if err := resolveListPaging(&ListRequest{}, func() (int, error) { return 0, lookupErr }); !errors.Is(err, lookupErr) {
+		t.Fatalf("err = %v, want %v", err, lookupErr)
+	}
+}
+
+func TestListRequestRejectsOutOfRangePaging(t *testing.T) {
+	cases := []struct {
+		name    string
+		req     ListRequest
+		wantErr bool
+	}{
+		{"omitted", ListRequest{}, false},
+		{"at max page size", ListRequest{Page: 1, PageSize: MaxListPageSize}, false},
+		{"above max page size", ListRequest{PageSize: MaxListPageSize + 1}, true},
+		{"negative page size", ListRequest{PageSize: -1}, true},
+		{"negative page", ListRequest{Page: -1}, true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := binding.Validator.ValidateStruct(&tc.req)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("err = %v, want error %v", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestProjectStreamEventHidesClaimerFromPublic(t *testing.T) {
+	amount := decimal.NewFromInt(5)
+	event := ClaimStreamEvent{
+		Event:          StreamEventClaim,
+		UserID:         2,
+		Username:       "claimer",
+		AvatarURL:      "https://example.com/a.png",
+		Amount:         &amount,
+		RemainingCount: 1,
+		Status:         model.RedEnvelopeStatusActive,
+	}
+
+	cases := []struct {
+		name        string
+		role        string
+		viewerID    uint64
+		wantClaimer bool
+		wantAmount  bool
+	}{
+		{"creator", ViewerRoleCreator, 1, true, true},
+		{"claimer self", ViewerRoleClaimer, 2, true, true},
+		{"other claimer", ViewerRoleClaimer, 3, true, false},
+		{"public", ViewerRolePublic, 4, false, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := projectStreamEvent(event, tc.role, &model.User{ID: tc.viewerID})
+			if hasClaimer := got.UserID != 0 || got.Username != "" || got.AvatarURL != ""; hasClaimer != tc.wantClaimer {
+				t.Fatalf("claimer fields = %d/%q/%q, want visible %v", got.UserID, got.Username, got.AvatarURL, tc.wantClaimer)
+			}
+			if (got.Amount != nil) != tc.wantAmount {
+				t.Fatalf("amount = %v, want visible %v", got.Amount, tc.wantAmount)
+			}
+			if got.RemainingCount != event.RemainingCount || got.Status != event.Status {
+				t.Fatalf("counts and status changed: %+v", got)
+			}
+		})
+	}
+}
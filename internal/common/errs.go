@@ -19,7 +19,7 @@ package common
 const (
 	BannedAccount                 = "账号已被封禁"
 	AmountMustBeGreaterThanZero   = "金额必须大于0"
-	AmountDecimalPlacesExceeded   = "金额小数位数不能超过2位"
+	AmountDecimalPlacesExceeded   = "金额小数位数超过系统允许的精度"
 	RateMustBeBetweenZeroAndOne   = "比率必须在 0 到 1 之间"
 	RateDecimalPlacesExceeded     = "比率小数位数不能超过2位"
 	InsufficientBalance           = "余额不足"
@@ -17,16 +17,17 @@ limitations under the License.
 package config
 
 type configModel struct {
-	App        appConfig        `mapstructure:"app"`
-	OAuth2     OAuth2Config     `mapstructure:"oauth2"`
-	Database   databaseConfig   `mapstructure:"database"`
-	Redis      redisConfig      `mapstructure:"redis"`
-	Log        logConfig        `mapstructure:"log"`
-	Scheduler  schedulerConfig  `mapstructure:"scheduler"`
-	Worker     workerConfig     `mapstructure:"worker"`
-	ClickHouse clickHouseConfig `mapstructure:"clickhouse"`
-	LinuxDo    linuxDoConfig    `mapstructure:"linuxdo"`
-	Otel       otelConfig       `mapstructure:"otel"`
+	App         appConfig         `mapstructure:"app"`
+	OAuth2      OAuth2Config      `mapstructure:"oauth2"`
+	Database    databaseConfig    `mapstructure:"database"`
+	Redis       redisConfig       `mapstructure:"redis"`
+	Log         logConfig         `mapstructure:"log"`
+	Scheduler   schedulerConfig   `mapstructure:"scheduler"`
+	Worker      workerConfig      `mapstructure:"worker"`
+	ClickHouse  clickHouseConfig  `mapstructure:"clickhouse"`
+	LinuxDo     linuxDoConfig     `mapstructure:"linuxdo"`
+	Otel        otelConfig        `mapstructure:"otel"`
+	RedEnvelope redEnvelopeConfig `mapstructure:"red_envelope"`
 }
 
 // appConfig 应用基本配置
@@ -45,6 +46,8 @@ type appConfig struct {
 	SessionAge              int    `mapstructure:"session_age"`
 	SessionHttpOnly         bool   `mapstructure:"session_http_only"`
 	SessionSecure           bool   `mapstructure:"session_secure"`
+	AmountPrecision         int    `mapstructure:"amount_precision"`
+	AmountLocale            string `mapstructure:"amount_locale"`
 }
 
 // IsProduction 检查当前环境是否为生产环境
@@ -126,6 +129,7 @@ type redisConfig struct {
 	MaxRetries      int      `mapstructure:"max_retries"`
 	PoolTimeout     int      `mapstructure:"pool_timeout"`
 	ConnMaxIdleTime int      `mapstructure:"conn_max_idle_time"`
+	PingInterval    int      `mapstructure:"ping_interval"`
 }
 
 // logConfig 日志配置
@@ -147,6 +151,13 @@ type schedulerConfig struct {
 	AutoRefundExpiredDisputesTaskCron        string `mapstructure:"auto_refund_expired_disputes_task_cron"`
 	SyncOrdersToClickHouseTaskCron           string `mapstructure:"sync_orders_to_clickhouse_task_cron"`
 	RefundExpiredRedEnvelopesTaskCron        string `mapstructure:"refund_expired_red_envelopes_task_cron"`
+	ArchiveRedEnvelopeClaimsTaskCron         string `mapstructure:"archive_red_envelope_claims_task_cron"`
+	NotifyExpiringRedEnvelopesTaskCron       string `mapstructure:"notify_expiring_red_envelopes_task_cron"`
+	AwardRedEnvelopeJackpotTaskCron          string `mapstructure:"award_red_envelope_jackpot_task_cron"`
+	CleanupRedEnvelopeKeysTaskCron           string `mapstructure:"cleanup_red_envelope_keys_task_cron"`
+	RunRecurringEnvelopesTaskCron            string `mapstructure:"run_recurring_envelopes_task_cron"`
+	HandleBannedCreatorEnvelopesTaskCron     string `mapstructure:"handle_banned_creator_envelopes_task_cron"`
+	RefreshRedEnvelopeLiabilityTaskCron      string `mapstructure:"refresh_red_envelope_liability_task_cron"`
 }
 
 // workerConfig 工作配置
@@ -178,3 +189,19 @@ type linuxDoConfig struct {
 type otelConfig struct {
 	SamplingRate float64 `mapstructure:"sampling_rate"`
 }
+
+// redEnvelopeConfig 红包配置
+type redEnvelopeConfig struct {
+	WebhookSecret     string `mapstructure:"webhook_secret"`     // 外部系统回调领取红包的 HMAC 签名密钥，留空则禁用
+	ExpiryNotifyURL   string `mapstructure:"expiry_notify_url"`  // 红包即将过期通知的推送地址，留空则禁用
+	RefundConcurrency int    `mapstructure:"refund_concurrency"` // 过期红包退款任务的并发数，默认1（顺序处理）
+	DeepLinkSecret    string `mapstructure:"deep_link_secret"`   // 通知中一键领取链接凭证的 HMAC 签名密钥，留空则禁用
+	CodeLength        int    `mapstructure:"code_length"`        // 红包码随机部分长度，默认8
+	CodeAlphabet      string `mapstructure:"code_alphabet"`      // 红包码字符集，默认排除 0/O/1/I 等易混淆字符
+	CodePrefix        string `mapstructure:"code_prefix"`        // 红包码前缀，留空则不加前缀
+	DeviceHashSecret  string `mapstructure:"device_hash_secret"` // 领取设备信息哈希的 HMAC 密钥，留空则不记录领取设备信息
+	RecurringHookURL  string `mapstructure:"recurring_hook_url"` // 定期发放红包失败（如余额不足）通知的推送地址，留空则禁用
+	ClaimIsolation    string `mapstructure:"claim_isolation"`    // 领取红包事务的隔离级别：read_committed、repeatable_read 或 serializable，留空使用数据库默认
+	ReceiptSecret     string `mapstructure:"receipt_secret"`     // 领取回执（JWT）的 HMAC 签名密钥，留空则不能开启签名回执
+	ReceiptAlgorithm  string `mapstructure:"receipt_algorithm"`  // 领取回执的签名算法：HS256 或 HS512，默认 HS256
+}
@@ -0,0 +1,80 @@
+//go:build memtest
+
+/*
+Copyright 2025 linux.do
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package db
+
+import (
+	"fmt"
+	"log"
+	"sync/atomic"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// memoryDBSeq 内存数据库序号，每次初始化使用独立的数据库
+var memoryDBSeq atomic.Int64
+
+// UseMemory 以内存 SQLite 数据库及 miniredis 替代 PostgreSQL 与 Redis，返回释放资源并还原连接的函数
+// 仅在 memtest 构建标签下编译（go test -tags memtest），供不依赖外部服务的集成测试使用；
+// SQLite 忽略行锁子句，不覆盖 PostgreSQL 专有的 SQL（如正则匹配、NOWAIT），并发相关的正确性仍需在 PostgreSQL 上验证
+func UseMemory() (func(), error) {
+	server, err := miniredis.Run()
+	if err != nil {
+		return nil, fmt.Errorf("[Memory] start miniredis failed: %w", err)
+	}
+
+	// 共享缓存的命名内存库使同一进程内的多个连接访问同一份数据，最后一个连接关闭后数据随之释放
+	dsn := fmt.Sprintf("file:credit_memtest_%d?mode=memory&cache=shared&_busy_timeout=5000", memoryDBSeq.Add(1))
+	memoryDB, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{
+		DisableForeignKeyConstraintWhenMigrating: true,
+		Logger: logger.New(log.New(log.Writer(), "\r\n", log.LstdFlags), logger.Config{
+			SlowThreshold:             200 * time.Millisecond,
+			LogLevel:                  logger.Warn,
+			IgnoreRecordNotFoundError: true,
+		}),
+	})
+	if err != nil {
+		server.Close()
+		return nil, fmt.Errorf("[Memory] open sqlite failed: %w", err)
+	}
+	sqlDB, err := memoryDB.DB()
+	if err != nil {
+		server.Close()
+		return nil, fmt.Errorf("[Memory] load sql db failed: %w", err)
+	}
+
+	previousDB, previousRedis := db, Redis
+	db = memoryDB
+	Redis = redis.NewClient(&redis.Options{Addr: server.Addr()})
+
+	return func() {
+		if err := Redis.Close(); err != nil {
+			log.Printf("[Memory] close redis client failed: %v\n", err)
+		}
+		server.Close()
+		if err := sqlDB.Close(); err != nil {
+			log.Printf("[Memory] close sqlite failed: %v\n", err)
+		}
+		db, Redis = previousDB, previousRedis
+	}, nil
+}
@@ -0,0 +1,34 @@
+//go:build memtest
+
+/*
+Copyright 2025 linux.do
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package migrator
+
+import (
+	"github.com/linux-do/credit/internal/db"
+)
+
+// SetupMemory 切换到内存数据库及 Redis 并同步表结构、初始化默认配置，返回释放资源的函数
+// 仅在 memtest 构建标签下编译，测试通过 go test -tags memtest 运行
+func SetupMemory() (func(), error) {
+	release, err := db.UseMemory()
+	if err != nil {
+		return nil, err
+	}
+	migrate()
+	return release, nil
+}
@@ -25,13 +25,18 @@ import (
 	"github.com/linux-do/credit/internal/config"
 	"github.com/linux-do/credit/internal/db"
 	"github.com/shopspring/decimal"
+	"gorm.io/gorm/clause"
 )
 
 func Migrate() {
 	if !config.Config.Database.Enabled {
 		return
 	}
+	migrate()
+}
 
+// migrate 同步表结构、回填历史数据并初始化默认配置
+func migrate() {
 	if err := db.DB(context.Background()).AutoMigrate(
 		&model.User{},
 		&model.UserPayConfig{},
@@ -42,11 +47,42 @@ func Migrate() {
 		&model.Dispute{},
 		&model.RedEnvelope{},
 		&model.RedEnvelopeClaim{},
+		&model.RedEnvelopeEscrow{},
+		&model.RedEnvelopeAllowedUser{},
+		&model.RedEnvelopeWallet{},
+		&model.RedEnvelopeEvent{},
+		&model.RedEnvelopeReservation{},
+		&model.RedEnvelopeJackpot{},
+		&model.RecurringEnvelope{},
 	); err != nil {
 		log.Fatalf("[PostgreSQL] auto migrate failed: %v\n", err)
 	}
 	log.Printf("[PostgreSQL] auto migrate success\n")
 
+	// 红包领取记录唯一索引已加入领取序号，移除旧的（用户，红包）唯一索引
+	if db.DB(context.Background()).Migrator().HasIndex(&model.RedEnvelopeClaim{}, "idx_red_envelope_user") {
+		if err := db.DB(context.Background()).Migrator().DropIndex(&model.RedEnvelopeClaim{}, "idx_red_envelope_user"); err != nil {
+			log.Fatalf("[PostgreSQL] drop index idx_red_envelope_user failed: %v\n", err)
+		}
+	}
+
+	// 历史退款订单仅在备注中记录红包ID，解析后回填 red_envelope_id，已回填的订单不会重复处理
+	if err := db.DB(context.Background()).Exec(
+		`UPDATE orders SET red_envelope_id = CAST(substring(remark FROM '红包ID:([0-9]+)') AS BIGINT)
+		WHERE red_envelope_id IS NULL AND type = ? AND remark ~ '红包ID:[0-9]+'`,
+		model.OrderTypeRedEnvelopeRefund,
+	).Error; err != nil {
+		log.Printf("[PostgreSQL] backfill orders red_envelope_id failed: %v\n", err)
+	}
+
+	// 历史过期红包在过期时已退款，以最后更新时间回填退款时间
+	if err := db.DB(context.Background()).Exec(
+		`UPDATE red_envelopes SET refunded_at = updated_at WHERE refunded_at IS NULL AND status = ?`,
+		model.RedEnvelopeStatusExpired,
+	).Error; err != nil {
+		log.Printf("[PostgreSQL] backfill red_envelopes refunded_at failed: %v\n", err)
+	}
+
 	// 初始化系统配置数据
 	initSystemConfigs()
 
@@ -54,20 +90,10 @@ func Migrate() {
 	initUserPayConfigs()
 }
 
-// initSystemConfigs 初始化系统配置数据
+// initSystemConfigs 初始化系统配置数据（已存在的配置项保持不变，仅补充缺失项）
 func initSystemConfigs() {
 	tx := db.DB(context.Background())
 
-	var count int64
-	if err := tx.Model(&model.SystemConfig{}).Count(&count).Error; err != nil {
-		log.Printf("[PostgreSQL] failed to check system_config table: %v\n", err)
-		return
-	}
-
-	if count > 0 {
-		return
-	}
-
 	defaultConfigs := []model.SystemConfig{
 		{
 			Key:         model.ConfigKeyMerchantOrderExpireMinutes,
@@ -129,12 +155,183 @@ func initSystemConfigs() {
 			Value:       "600",
 			Description: "用户余额统计缓存过期时间（秒）",
 		},
+		{
+			Key:         model.ConfigKeyRedEnvelopeClaimTokenRequired,
+			Value:       "0",
+			Description: "领取红包是否需要一次性领取凭证（1启用，0禁用）",
+		},
+		{
+			Key:         model.ConfigKeyRedEnvelopeRetentionDays,
+			Value:       "0",
+			Description: "已结束红包领取记录保留天数（0表示永久保留）",
+		},
+		{
+			Key:         model.ConfigKeyRedEnvelopeRetentionDryRun,
+			Value:       "1",
+			Description: "红包领取记录归档是否仅演练（1仅统计不删除，0实际删除）",
+		},
+		{
+			Key:         model.ConfigKeyRedEnvelopeClaimGateEnabled,
+			Value:       "0",
+			Description: "是否启用 Redis 剩余个数闸门，在加锁前快速拒绝争抢请求（1启用，0禁用）",
+		},
+		{
+			Key:         model.ConfigKeyRedEnvelopeBalanceCap,
+			Value:       "0",
+			Description: "领取红包后领取者可用余额上限（0表示不限制）",
+		},
+		{
+			Key:         model.ConfigKeyRedEnvelopeBalanceCapPartial,
+			Value:       "0",
+			Description: "超出余额上限时的处理方式（1部分入账并退还超出部分给创建者，0拒绝领取）",
+		},
+		{
+			Key:         model.ConfigKeyRedEnvelopeDetailRateLimit,
+			Value:       "0",
+			Description: "每个IP每分钟查询红包详情的次数上限（0表示不限制）",
+		},
+		{
+			Key:         model.ConfigKeyRedEnvelopeDetailMissLimit,
+			Value:       "0",
+			Description: "每个IP每分钟查询不存在红包的次数上限，超出后临时封禁（0表示不限制）",
+		},
+		{
+			Key:         model.ConfigKeyRedEnvelopeExpiryNoticeMinutes,
+			Value:       "60",
+			Description: "红包过期前提前通知创建者的时间（分钟）",
+		},
+		{
+			Key:         model.ConfigKeyRedEnvelopeDisplayCurrencies,
+			Value:       "",
+			Description: "红包金额展示用的货币换算表，格式 CNY=0.1,USD=0.014（留空表示不换算）",
+		},
+		{
+			Key:         model.ConfigKeyRedEnvelopeClaimGraceSeconds,
+			Value:       "0",
+			Description: "红包过期后仍允许领取的宽限时间（秒，0表示不允许）",
+		},
+		{
+			Key:         model.ConfigKeyRedEnvelopeDetailMaxClaims,
+			Value:       "500",
+			Description: "红包详情单次返回的领取记录数上限",
+		},
+		{
+			Key:         model.ConfigKeyRedEnvelopePayKeyMaxFailures,
+			Value:       "5",
+			Description: "发红包时支付密钥连续错误次数上限，超出后临时锁定（0表示不限制）",
+		},
+		{
+			Key:         model.ConfigKeyRedEnvelopeLiabilityCap,
+			Value:       "0",
+			Description: "全平台进行中红包待领取总额上限，超出后暂停创建红包（0表示不限制）",
+		},
+		{
+			Key:         model.ConfigKeyRedEnvelopeClaimReturnSeconds,
+			Value:       "0",
+			Description: "领取红包后可退回的时间窗口（秒，0表示不允许退回）",
+		},
+		{
+			Key:         model.ConfigKeyRedEnvelopeDefaultPageSize,
+			Value:       "20",
+			Description: "红包列表未指定每页数量时的默认值",
+		},
+		{
+			Key:         model.ConfigKeyRedEnvelopePayKeyCacheSeconds,
+			Value:       "0",
+			Description: "支付密钥校验通过后免重复校验的时间（秒，0表示不缓存，最长60秒）",
+		},
+		{
+			Key:         model.ConfigKeyRedEnvelopePauseExtendsExpiry,
+			Value:       "1",
+			Description: "恢复领取时是否按暂停时长顺延过期时间（1顺延，0不顺延）",
+		},
+		{
+			Key:         model.ConfigKeyRedEnvelopeWallets,
+			Value:       "",
+			Description: "红包可指定的专用钱包名称，逗号分隔（留空表示不启用）",
+		},
+		{
+			Key:         model.ConfigKeyRedEnvelopeCreatorClaimCap,
+			Value:       "0",
+			Description: "同一用户从同一创建者的红包中累计领取金额上限（0表示不限制）",
+		},
+		{
+			Key:         model.ConfigKeyRedEnvelopeClaimDenomination,
+			Value:       "0",
+			Description: "领取金额取整面额，如1或0.5（0或最小金额单位表示不取整）",
+		},
+		{
+			Key:         model.ConfigKeyRedEnvelopeAudienceOverflow,
+			Value:       "warn",
+			Description: "私密红包个数超过名单可领取总次数时的处理方式（warn提示，reject拒绝，cap自动调整个数）",
+		},
+		{
+			Key:         model.ConfigKeyRedEnvelopeGreetingVariables,
+			Value:       "",
+			Description: "祝福语模板变量，格式 festival=春节,site=LINUX DO，祝福语中以 {festival} 引用（留空表示不启用）",
+		},
+		{
+			Key:         model.ConfigKeyRedEnvelopeJackpotRate,
+			Value:       "0",
+			Description: "创建红包时额外扣除并计入奖池的比例（0-1之间的小数，0表示不抽成）",
+		},
+		{
+			Key:         model.ConfigKeyRedEnvelopeJackpotWindowHours,
+			Value:       "24",
+			Description: "奖池发放时参与抽奖的领取记录时间范围（小时）",
+		},
+		{
+			Key:         model.ConfigKeyRedEnvelopeGreetingGraphemes,
+			Value:       "100",
+			Description: "祝福语按字形簇计算的长度上限，组合 emoji 计为1个（0表示仅按100个字符限制）",
+		},
+		{
+			Key:         model.ConfigKeyRedEnvelopeBlackoutSchedule,
+			Value:       "",
+			Description: "暂停领取红包的时段，格式 mon-fri 00:00-09:00;daily 23:00-07:00，结束早于开始表示跨天（留空表示不限制）",
+		},
+		{
+			Key:         model.ConfigKeyRedEnvelopeBlackoutTimezone,
+			Value:       "Asia/Shanghai",
+			Description: "暂停领取时段所用的时区，IANA 时区名",
+		},
+		{
+			Key:         model.ConfigKeyRedEnvelopeTrustWeights,
+			Value:       "0.8,0.9,1,1.1,1.2",
+			Description: "按信任等级加权拆分时信任等级0-4的权重，逗号分隔的正数，期望金额与权重成正比",
+		},
+		{
+			Key:         model.ConfigKeyRedEnvelopeDustThreshold,
+			Value:       "0",
+			Description: "零头阈值，领取后剩余金额低于此值且不足以让剩余名额各领最低金额时红包提前结束并退还零头（0表示不启用）",
+		},
+		{
+			Key:         model.ConfigKeyRedEnvelopeRefundPaused,
+			Value:       "0",
+			Description: "是否暂停过期红包自动退款，数据库维护期间使用，恢复后过期红包会被补退（1暂停，0正常）",
+		},
+		{
+			Key:         model.ConfigKeyRedEnvelopeBannedCreatorAction,
+			Value:       "none",
+			Description: "创建者账户被封禁后其进行中红包的处理方式（none不处理，pause暂停领取，refund立即退款）",
+		},
+		{
+			Key:         model.ConfigKeyRedEnvelopeRejectBannedCreator,
+			Value:       "0",
+			Description: "是否拒绝领取已封禁创建者的红包（1拒绝，0允许）",
+		},
+		{
+			Key:         model.ConfigKeyRedEnvelopeTestModeUsers,
+			Value:       "",
+			Description: "可创建测试红包的用户名，逗号分隔，管理员始终可用（留空表示仅管理员）",
+		},
 	}
 
-	if err := tx.Create(&defaultConfigs).Error; err != nil {
-		log.Printf("[PostgreSQL] failed to create default system configs: %v\n", err)
-	} else {
-		log.Printf("[PostgreSQL] initialized %d default system configs\n", len(defaultConfigs))
+	result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&defaultConfigs)
+	if result.Error != nil {
+		log.Printf("[PostgreSQL] failed to create default system configs: %v\n", result.Error)
+	} else if result.RowsAffected > 0 {
+		log.Printf("[PostgreSQL] initialized %d default system configs\n", result.RowsAffected)
 	}
 }
 
@@ -73,7 +73,7 @@ func init() {
 		log.Fatalf("[PostgreSQL] init connection failed: %v\n", err)
 	}
 
-	// Trace 注入
+	// Trace 注入，初始化失败不影响数据库使用
 	if err = db.Use(
 		tracing.NewPlugin(
 			tracing.WithoutMetrics(),
@@ -85,7 +85,7 @@ func init() {
 			),
 		),
 	); err != nil {
-		log.Fatalf("[PostgreSQL] init trace failed: %v\n", err)
+		log.Printf("[PostgreSQL] init trace failed, continuing without tracing: %v\n", err)
 	}
 
 	if len(dbConfig.Replicas) > 0 {
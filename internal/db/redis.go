@@ -82,7 +82,7 @@ func init() {
 		}
 	}
 
-	// OpenTelemetry 追踪（UniversalClient 兼容）
+	// OpenTelemetry 追踪（UniversalClient 兼容），初始化失败不影响 Redis 使用
 	if err := redisotel.InstrumentTracing(
 		Redis,
 		redisotel.WithAttributes(
@@ -91,7 +91,7 @@ func init() {
 			attribute.String("db.system", "Redis"),
 		),
 	); err != nil {
-		log.Fatalf("[Redis] failed to init trace: %v\n", err)
+		log.Printf("[Redis] failed to init trace, continuing without tracing: %v\n", err)
 	}
 
 	// 测试连接
@@ -99,6 +99,52 @@ func init() {
 	if err != nil {
 		log.Fatalf("[Redis] failed to connect to redis: %v\n", err)
 	}
+
+	if cfg.PingInterval > 0 {
+		go keepalive(time.Duration(cfg.PingInterval) * time.Second)
+	}
+}
+
+// keepalive 定期 PING 连接池中的空闲连接，避免长时间空闲的连接被代理断开后首个请求失败
+func keepalive(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		ctx, cancel := context.WithTimeout(context.Background(), interval)
+		if err := pingIdleConns(ctx); err != nil {
+			log.Printf("[Redis] keepalive ping failed: %v\n", err)
+		}
+		cancel()
+	}
+}
+
+// pingIdleConns 并发 PING 与空闲连接数相同的次数，使每个空闲连接都被取出使用一次；Cluster 模式下对每个节点分别执行
+func pingIdleConns(ctx context.Context) error {
+	if cluster, ok := Redis.(*redis.ClusterClient); ok {
+		return cluster.ForEachShard(ctx, func(ctx context.Context, shard *redis.Client) error {
+			return pingConns(ctx, shard, int(shard.PoolStats().IdleConns))
+		})
+	}
+	return pingConns(ctx, Redis, int(Redis.PoolStats().IdleConns))
+}
+
+// pingConns 并发执行 count 次 PING（至少1次），返回首个错误
+func pingConns(ctx context.Context, client redis.UniversalClient, count int) error {
+	errs := make(chan error, max(count, 1))
+	for range max(count, 1) {
+		go func() {
+			errs <- client.Ping(ctx).Err()
+		}()
+	}
+
+	var firstErr error
+	for range max(count, 1) {
+		if err := <-errs; err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
 }
 
 // PrefixedKey 返回带前缀的 Key
@@ -0,0 +1,82 @@
+/*
+Copyright 2025 linux.do
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+)
+
+const (
+	// DefaultTxAttempts 可重试事务的默认最大尝试次数
+	DefaultTxAttempts = 3
+	// txRetryBackoff 可重试事务两次尝试之间的基础等待时间，按尝试次数线性递增
+	txRetryBackoff = 50 * time.Millisecond
+)
+
+// RetryTransaction 执行事务，遇到序列化冲突、死锁或连接中断等瞬时错误时整体回滚后重试
+// fn 可能被执行多次，闭包内对外部变量的赋值须在每次执行时重新初始化；业务错误不会重试
+// opts 可指定事务隔离级别，SERIALIZABLE 下的序列化冲突同样按瞬时错误重试
+func RetryTransaction(ctx context.Context, attempts int, fn func(tx *gorm.DB) error, opts ...*sql.TxOptions) error {
+	return retryTransient(ctx, attempts, func() error {
+		return DB(ctx).Transaction(fn, opts...)
+	})
+}
+
+// retryTransient 执行 run，遇到瞬时错误时按线性递增的间隔重试，最多执行 attempts 次，context 取消时返回最近一次的错误
+func retryTransient(ctx context.Context, attempts int, run func() error) error {
+	attempts = max(attempts, 1)
+
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if err = run(); err == nil || !IsTransientError(err) {
+			return err
+		}
+		if attempt == attempts {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(time.Duration(attempt) * txRetryBackoff):
+		}
+	}
+	return err
+}
+
+// IsTransientError 判断数据库错误是否为可重试的瞬时错误
+func IsTransientError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		// 40001 序列化失败，40P01 死锁，08 类为连接异常
+		return pgErr.Code == "40001" || pgErr.Code == "40P01" || strings.HasPrefix(pgErr.Code, "08")
+	}
+
+	// 请求尚未发送到服务端即失败（如连接已断开），重试是安全的
+	return pgconn.SafeToRetry(err)
+}
@@ -0,0 +1,131 @@
+/*
+Copyright 2025 linux.do
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// retryableError 模拟请求发送前失败、可安全重试的连接错误
+type retryableError struct{}
+
+func (retryableError) Error() string     { return "connection closed before sending" }
+func (retryableError) SafeToRetry() bool { return true }
+
+func TestIsTransientError(t *testing.T) {
+	cases := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"serialization failure", &pgconn.PgError{Code: "40001"}, true},
+		{"deadlock", &pgconn.PgError{Code: "40P01"}, true},
+		{"connection failure", &pgconn.PgError{Code: "08006"}, true},
+		{"wrapped serialization failure", fmt.Errorf("claim: %w", &pgconn.PgError{Code: "40001"}), true},
+		{"unique violation", &pgconn.PgError{Code: "23505"}, false},
+		{"check violation", &pgconn.PgError{Code: "23514"}, false},
+		{"safe to retry", retryableError{}, true},
+		{"wrapped safe to retry", fmt.Errorf("begin: %w", retryableError{}), true},
+		{"business error", errors.New("余额不足"), false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := IsTransientError(tc.err); got != tc.want {
+				t.Fatalf("IsTransientError(%v) = %v, want %v", tc.err, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestRetryTransientRecoversFromInjectedFailure(t *testing.T) {
+	calls := 0
+	err := retryTransient(context.Background(), DefaultTxAttempts, func() error {
+		calls++
+		if calls < DefaultTxAttempts {
+			return &pgconn.PgError{Code: "40001"}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("err = %v, want nil", err)
+	}
+	if calls != DefaultTxAttempts {
+		t.Fatalf("calls = %d, want %d", calls, DefaultTxAttempts)
+	}
+}
+
+func TestRetryTransientStopsOnBusinessError(t *testing.T) {
+	businessErr := errors.New("余额不足")
+	calls := 0
+	err := retryTransient(context.Background(), DefaultTxAttempts, func() error {
+		calls++
+		return businessErr
+	})
+	if !errors.Is(err, businessErr) {
+		t.Fatalf("err = %v, want %v", err, businessErr)
+	}
+	if calls != 1 {
+		t.Fatalf("calls = %d, want 1", calls)
+	}
+}
+
+func TestRetryTransientGivesUpAfterAttempts(t *testing.T) {
+	calls := 0
+	err := retryTransient(context.Background(), 2, func() error {
+		calls++
+		return &pgconn.PgError{Code: "40P01"}
+	})
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) || pgErr.Code != "40P01" {
+		t.Fatalf("err = %v, want deadlock error", err)
+	}
+	if calls != 2 {
+		t.Fatalf("calls = %d, want 2", calls)
+	}
+
+	// 尝试次数小于1时仍执行一次
+	calls = 0
+	_ = retryTransient(context.Background(), 0, func() error {
+		calls++
+		return &pgconn.PgError{Code: "40001"}
+	})
+	if calls != 1 {
+		t.Fatalf("calls with zero attempts = %d, want 1", calls)
+	}
+}
+
+func TestRetryTransientStopsWhenContextCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+	err := retryTransient(ctx, DefaultTxAttempts, func() error {
+		calls++
+		cancel()
+		return &pgconn.PgError{Code: "40001"}
+	})
+	if err == nil {
+		t.Fatal("err = nil, want serialization failure")
+	}
+	if calls != 1 {
+		t.Fatalf("calls = %d, want 1", calls)
+	}
+}
@@ -42,6 +42,10 @@ const (
 	OrderTypeRedEnvelopeSend    OrderType = "red_envelope_send"
 	OrderTypeRedEnvelopeReceive OrderType = "red_envelope_receive"
 	OrderTypeRedEnvelopeRefund  OrderType = "red_envelope_refund"
+	OrderTypeRedEnvelopeEscrow  OrderType = "red_envelope_escrow"
+	OrderTypeRedEnvelopeReturn  OrderType = "red_envelope_return"
+	OrderTypeRedEnvelopeWallet  OrderType = "red_envelope_wallet"
+	OrderTypeRedEnvelopeJackpot OrderType = "red_envelope_jackpot"
 )
 
 type OrderStatus string
@@ -66,12 +70,13 @@ type Order struct {
 	PayeeUserID     uint64          `json:"payee_user_id" gorm:"index:idx_orders_payee_status_type_created,priority:1;index:idx_orders_client_payee,priority:2"`
 	PayerUsername   string          `json:"payer_username" gorm:"-:migration;->"`
 	PayeeUsername   string          `json:"payee_username" gorm:"-:migration;->"`
-	Amount          decimal.Decimal `json:"amount" gorm:"type:numeric(20,2);not null;index"`
+	Amount          decimal.Decimal `json:"amount" gorm:"type:numeric(22,4);not null;index"`
 	Status          OrderStatus     `json:"status" gorm:"type:varchar(20);not null;index:idx_orders_payee_status_type_created,priority:2;index:idx_orders_payer_status_type_created,priority:2;index:idx_orders_client_status_created,priority:2;index:idx_orders_payer_status_type_trade,priority:2;index:idx_orders_payment_link_status,priority:2"`
 	Type            OrderType       `json:"type" gorm:"type:varchar(20);not null;index:idx_orders_payee_status_type_created,priority:3;index:idx_orders_payer_status_type_created,priority:3;index:idx_orders_payer_status_type_trade,priority:3"`
 	Remark          string          `json:"remark" gorm:"size:255"`
 	PaymentType     string          `json:"payment_type" gorm:"size:20"`
 	PaymentLinkID   *uint64         `json:"payment_link_id,string" gorm:"index:idx_orders_payment_link_status,priority:1"`
+	RedEnvelopeID   *uint64         `json:"red_envelope_id,string,omitempty" gorm:"index"`
 	TradeTime       time.Time       `json:"trade_time" gorm:"index:idx_orders_payer_status_type_trade,priority:4"`
 	ExpiresAt       time.Time       `json:"expires_at" gorm:"not null"`
 	CreatedAt       time.Time       `json:"created_at" gorm:"autoCreateTime;index:idx_orders_payee_status_type_created,priority:4;index:idx_orders_payer_status_type_created,priority:4;index:idx_orders_client_status_created,priority:3"`
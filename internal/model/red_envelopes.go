@@ -27,6 +27,7 @@ type RedEnvelopeType string
 const (
 	RedEnvelopeTypeFixed  RedEnvelopeType = "fixed"
 	RedEnvelopeTypeRandom RedEnvelopeType = "random"
+	RedEnvelopeTypeHybrid RedEnvelopeType = "hybrid"
 )
 
 type RedEnvelopeStatus string
@@ -35,33 +36,160 @@ const (
 	RedEnvelopeStatusActive   RedEnvelopeStatus = "active"
 	RedEnvelopeStatusFinished RedEnvelopeStatus = "finished"
 	RedEnvelopeStatusExpired  RedEnvelopeStatus = "expired"
+	RedEnvelopeStatusPaused   RedEnvelopeStatus = "paused"
+)
+
+type RedEnvelopeVisibility string
+
+const (
+	RedEnvelopeVisibilityPublic   RedEnvelopeVisibility = "public"   // 公开，出现在红包广场
+	RedEnvelopeVisibilityUnlisted RedEnvelopeVisibility = "unlisted" // 不公开，仅凭链接领取
+	RedEnvelopeVisibilityPrivate  RedEnvelopeVisibility = "private"  // 私密，仅可领取名单内的用户领取
+)
+
+// RedEnvelopeSplit 拼手气红包的金额拆分方式
+type RedEnvelopeSplit string
+
+const (
+	RedEnvelopeSplitUniform       RedEnvelopeSplit = "uniform"        // 二倍均值，每位领取者期望金额相同
+	RedEnvelopeSplitTrustWeighted RedEnvelopeSplit = "trust_weighted" // 按领取者信任等级加权，等级越高期望金额越大
+)
+
+type RedEnvelopeEventActor string
+
+const (
+	RedEnvelopeEventActorSystem  RedEnvelopeEventActor = "system"  // 系统任务，如过期退款
+	RedEnvelopeEventActorCreator RedEnvelopeEventActor = "creator" // 红包创建者
+	RedEnvelopeEventActorClaimer RedEnvelopeEventActor = "claimer" // 领取者，如领完或退回
 )
 
 // RedEnvelope 红包
 type RedEnvelope struct {
-	ID               uint64            `json:"id,string" gorm:"primaryKey"`
-	CreatorID        uint64            `json:"creator_id,string" gorm:"index;not null"`
-	CreatorUsername  string            `json:"creator_username" gorm:"-:migration;->"`
-	CreatorAvatarURL string            `json:"creator_avatar_url" gorm:"-:migration;->"`
-	Type             RedEnvelopeType   `json:"type" gorm:"type:varchar(20);not null"`
-	TotalAmount      decimal.Decimal   `json:"total_amount" gorm:"type:numeric(20,2);not null"`
-	RemainingAmount  decimal.Decimal   `json:"remaining_amount" gorm:"type:numeric(20,2);not null"`
-	TotalCount       int               `json:"total_count" gorm:"not null"`
-	RemainingCount   int               `json:"remaining_count" gorm:"not null"`
-	Greeting         string            `json:"greeting" gorm:"size:100"`
-	Status           RedEnvelopeStatus `json:"status" gorm:"type:varchar(20);not null"`
-	ExpiresAt        time.Time         `json:"expires_at" gorm:"not null;index"`
-	CreatedAt        time.Time         `json:"created_at" gorm:"autoCreateTime"`
-	UpdatedAt        time.Time         `json:"updated_at" gorm:"autoUpdateTime"`
+	ID               uint64                `json:"id,string" gorm:"primaryKey"`
+	Code             *string               `json:"code,omitempty" gorm:"size:32;uniqueIndex"`
+	LinkTokenHash    *string               `json:"-" gorm:"size:64;uniqueIndex"`
+	LinkToken        string                `json:"-" gorm:"-"`
+	CreatorID        uint64                `json:"creator_id,string" gorm:"index;not null"`
+	CreatorUsername  string                `json:"creator_username" gorm:"-:migration;->"`
+	CreatorAvatarURL string                `json:"creator_avatar_url" gorm:"-:migration;->"`
+	Type             RedEnvelopeType       `json:"type" gorm:"type:varchar(20);not null"`
+	Split            RedEnvelopeSplit      `json:"split" gorm:"type:varchar(20);not null;default:'uniform'"`
+	TotalAmount      decimal.Decimal       `json:"total_amount" gorm:"type:numeric(22,4);not null"`
+	RemainingAmount  decimal.Decimal       `json:"remaining_amount" gorm:"type:numeric(22,4);not null"`
+	BaseAmount       decimal.Decimal       `json:"base_amount" gorm:"type:numeric(22,4);not null;default:0"`
+	MinClaimAmount   decimal.Decimal       `json:"min_claim_amount" gorm:"type:numeric(22,4);not null;default:0"`
+	Denomination     decimal.Decimal       `json:"denomination" gorm:"type:numeric(22,4);not null;default:0"`
+	ReservedCount    int                   `json:"reserved_count" gorm:"not null;default:0"`
+	ReservedAmount   decimal.Decimal       `json:"reserved_amount" gorm:"type:numeric(22,4);not null;default:0"`
+	TotalCount       int                   `json:"total_count" gorm:"not null"`
+	RemainingCount   int                   `json:"remaining_count" gorm:"not null"`
+	Greeting         string                `json:"greeting" gorm:"size:100"`
+	GreetingHidden   bool                  `json:"greeting_hidden" gorm:"not null;default:false"`
+	SignedReceipt    bool                  `json:"signed_receipt" gorm:"not null;default:false"`
+	TestMode         bool                  `json:"test_mode" gorm:"not null;default:false;index"`
+	ClaimMessage     string                `json:"claim_message,omitempty" gorm:"size:100;not null;default:''"`
+	MaxClaimsPerUser int                   `json:"max_claims_per_user" gorm:"not null;default:1"`
+	RequireConfirm   bool                  `json:"require_confirm" gorm:"not null;default:false"`
+	FundedByEscrow   bool                  `json:"funded_by_escrow" gorm:"not null;default:false"`
+	TargetWallet     string                `json:"target_wallet,omitempty" gorm:"size:32;not null;default:''"`
+	Visibility       RedEnvelopeVisibility `json:"visibility" gorm:"type:varchar(20);not null;default:'unlisted';index"`
+	Status           RedEnvelopeStatus     `json:"status" gorm:"type:varchar(20);not null;index:idx_red_envelopes_status_expires_at,priority:1"`
+	ExpiresAt        time.Time             `json:"expires_at" gorm:"not null;index;index:idx_red_envelopes_status_expires_at,priority:2"`
+	ClaimsArchivedAt *time.Time            `json:"claims_archived_at,omitempty" gorm:"index"`
+	PausedAt         *time.Time            `json:"paused_at,omitempty"`
+	RefundedAt       *time.Time            `json:"refunded_at,omitempty" gorm:"index"`
+	ExpiryNotifiedAt *time.Time            `json:"expiry_notified_at,omitempty"`
+	FairnessGini     *decimal.Decimal      `json:"fairness_gini,omitempty" gorm:"type:numeric(5,4)"`
+	FairnessRatio    *decimal.Decimal      `json:"fairness_ratio,omitempty" gorm:"type:numeric(20,4)"`
+	CreatedAt        time.Time             `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt        time.Time             `json:"updated_at" gorm:"autoUpdateTime"`
+	Warnings         []string              `json:"warnings,omitempty" gorm:"-"`
 }
 
 // RedEnvelopeClaim 红包领取记录
 type RedEnvelopeClaim struct {
 	ID            uint64          `json:"id,string" gorm:"primaryKey"`
-	RedEnvelopeID uint64          `json:"red_envelope_id,string" gorm:"uniqueIndex:idx_red_envelope_user,priority:2;not null"`
-	UserID        uint64          `json:"user_id,string" gorm:"uniqueIndex:idx_red_envelope_user,priority:1;not null"`
-	Username      string          `json:"username" gorm:"-:migration;->"`
-	AvatarURL     string          `json:"avatar_url" gorm:"-:migration;->"`
-	Amount        decimal.Decimal `json:"amount" gorm:"type:numeric(20,2);not null"`
+	RedEnvelopeID uint64          `json:"red_envelope_id,string" gorm:"uniqueIndex:idx_red_envelope_user_seq,priority:2;not null"`
+	UserID        uint64          `json:"user_id,string" gorm:"uniqueIndex:idx_red_envelope_user_seq,priority:1;not null"`
+	Sequence      int             `json:"sequence" gorm:"uniqueIndex:idx_red_envelope_user_seq,priority:3;not null;default:1"`
+	Username      string          `json:"username" gorm:"size:64;not null;default:''"`
+	AvatarURL     string          `json:"avatar_url" gorm:"size:100;not null;default:''"`
+	Amount        decimal.Decimal `json:"amount" gorm:"type:numeric(22,4);not null"`
+	IPHash        string          `json:"-" gorm:"size:64;not null;default:'';index"`
+	DeviceHash    string          `json:"-" gorm:"size:64;not null;default:'';index"`
+	Channel       string          `json:"-" gorm:"size:32;not null;default:''"`
 	ClaimedAt     time.Time       `json:"claimed_at" gorm:"autoCreateTime"`
 }
+
+// RedEnvelopeAllowedUser 私密红包的可领取名单
+type RedEnvelopeAllowedUser struct {
+	RedEnvelopeID uint64    `json:"red_envelope_id,string" gorm:"primaryKey"`
+	UserID        uint64    `json:"user_id,string" gorm:"primaryKey"`
+	CreatedAt     time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// RedEnvelopeReservation 红包为指定用户预留的名额及金额，ClaimID 为空表示尚未领取
+type RedEnvelopeReservation struct {
+	RedEnvelopeID uint64          `json:"red_envelope_id,string" gorm:"primaryKey"`
+	UserID        uint64          `json:"user_id,string" gorm:"primaryKey"`
+	Amount        decimal.Decimal `json:"amount" gorm:"type:numeric(22,4);not null"`
+	ClaimID       *uint64         `json:"claim_id,string,omitempty" gorm:"index"`
+	CreatedAt     time.Time       `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// RedEnvelopeEscrow 红包托管资金，用户预先存入，创建红包时可从中扣款，退款也退回托管余额
+type RedEnvelopeEscrow struct {
+	UserID    uint64          `json:"user_id,string" gorm:"primaryKey"`
+	Balance   decimal.Decimal `json:"balance" gorm:"type:numeric(22,4);not null;default:0"`
+	CreatedAt time.Time       `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt time.Time       `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// RedEnvelopeWallet 用户的专用钱包（如 rewards），指定钱包的红包从创建者同名钱包扣款，领取及退款也计入同名钱包
+type RedEnvelopeWallet struct {
+	UserID    uint64          `json:"user_id,string" gorm:"primaryKey"`
+	Name      string          `json:"name" gorm:"primaryKey;size:32"`
+	Balance   decimal.Decimal `json:"balance" gorm:"type:numeric(22,4);not null;default:0"`
+	CreatedAt time.Time       `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt time.Time       `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// RedEnvelopeEvent 红包状态流转记录，与状态变更在同一事务中写入，创建红包时 FromStatus 为空
+type RedEnvelopeEvent struct {
+	ID            uint64                `json:"id,string" gorm:"primaryKey"`
+	RedEnvelopeID uint64                `json:"red_envelope_id,string" gorm:"index;not null"`
+	FromStatus    RedEnvelopeStatus     `json:"from_status" gorm:"type:varchar(20);not null;default:''"`
+	ToStatus      RedEnvelopeStatus     `json:"to_status" gorm:"type:varchar(20);not null"`
+	ActorType     RedEnvelopeEventActor `json:"actor_type" gorm:"type:varchar(20);not null"`
+	ActorID       uint64                `json:"actor_id,string" gorm:"not null;default:0"`
+	Reason        string                `json:"reason" gorm:"size:100;not null;default:''"`
+	CreatedAt     time.Time             `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// RedEnvelopeJackpotPoolID 红包奖池记录的固定ID，全平台共用一个奖池
+const RedEnvelopeJackpotPoolID uint64 = 1
+
+// RedEnvelopeJackpot 红包奖池，创建红包时按比例抽成累积，定期发放给随机一位近期领取过红包的用户
+type RedEnvelopeJackpot struct {
+	ID        uint64          `json:"id,string" gorm:"primaryKey"`
+	Balance   decimal.Decimal `json:"balance" gorm:"type:numeric(22,4);not null;default:0"`
+	CreatedAt time.Time       `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt time.Time       `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// RecurringEnvelope 定期发放红包计划，Spec 为创建红包的参数模板（JSON），按 Cron 表达式到期时从创建者余额扣款创建红包
+// EndsAt 为空表示不设结束时间，到达结束时间后计划自动停用
+type RecurringEnvelope struct {
+	ID                uint64     `json:"id,string" gorm:"primaryKey"`
+	CreatorID         uint64     `json:"creator_id,string" gorm:"index;not null"`
+	Spec              string     `json:"-" gorm:"type:text;not null"`
+	Cron              string     `json:"cron" gorm:"size:64;not null"`
+	Enabled           bool       `json:"enabled" gorm:"not null;default:true;index:idx_recurring_envelope_due,priority:1"`
+	EndsAt            *time.Time `json:"ends_at"`
+	NextRunAt         time.Time  `json:"next_run_at" gorm:"not null;index:idx_recurring_envelope_due,priority:2"`
+	LastRunAt         *time.Time `json:"last_run_at"`
+	LastRedEnvelopeID *uint64    `json:"last_red_envelope_id,string"`
+	LastError         string     `json:"last_error" gorm:"size:255;not null;default:''"`
+	CreatedAt         time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt         time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
+}
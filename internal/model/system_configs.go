@@ -43,6 +43,41 @@ const (
 	ConfigKeyRedEnvelopeFeeRate         = "red_envelope_fee_rate"         // 红包手续费率（0-1之间的小数，0表示不收费）
 	ConfigKeyRedEnvelopeMaxRecipients   = "red_envelope_max_recipients"   // 每个红包的最大可领取人数上限
 	ConfigKeyUserBalanceStatsCacheTTL   = "user_balance_stats_cache_ttl"  // 用户余额统计缓存过期时间（秒）
+
+	ConfigKeyRedEnvelopeClaimTokenRequired  = "red_envelope_claim_token_required"  // 领取红包是否需要一次性领取凭证（1启用，0禁用）
+	ConfigKeyRedEnvelopeRetentionDays       = "red_envelope_retention_days"        // 已结束红包领取记录保留天数（0表示永久保留）
+	ConfigKeyRedEnvelopeRetentionDryRun     = "red_envelope_retention_dry_run"     // 红包领取记录归档是否仅演练（1仅统计不删除，0实际删除）
+	ConfigKeyRedEnvelopeClaimGateEnabled    = "red_envelope_claim_gate_enabled"    // 是否启用 Redis 剩余个数闸门，在加锁前快速拒绝争抢请求（1启用，0禁用）
+	ConfigKeyRedEnvelopeBalanceCap          = "red_envelope_balance_cap"           // 领取红包后领取者可用余额上限（0表示不限制）
+	ConfigKeyRedEnvelopeBalanceCapPartial   = "red_envelope_balance_cap_partial"   // 超出余额上限时的处理方式（1部分入账并退还超出部分给创建者，0拒绝领取）
+	ConfigKeyRedEnvelopeDetailRateLimit     = "red_envelope_detail_rate_limit"     // 每个IP每分钟查询红包详情的次数上限（0表示不限制）
+	ConfigKeyRedEnvelopeDetailMissLimit     = "red_envelope_detail_miss_limit"     // 每个IP每分钟查询不存在红包的次数上限，超出后临时封禁（0表示不限制）
+	ConfigKeyRedEnvelopeExpiryNoticeMinutes = "red_envelope_expiry_notice_minutes" // 红包过期前提前通知创建者的时间（分钟）
+	ConfigKeyRedEnvelopeDisplayCurrencies   = "red_envelope_display_currencies"    // 红包金额展示用的货币换算表，格式 CNY=0.1,USD=0.014（留空表示不换算）
+	ConfigKeyRedEnvelopeClaimGraceSeconds   = "red_envelope_claim_grace_seconds"   // 红包过期后仍允许领取的宽限时间（秒，0表示不允许）
+	ConfigKeyRedEnvelopeDetailMaxClaims     = "red_envelope_detail_max_claims"     // 红包详情单次返回的领取记录数上限
+	ConfigKeyRedEnvelopePayKeyMaxFailures   = "red_envelope_pay_key_max_failures"  // 发红包时支付密钥连续错误次数上限，超出后临时锁定（0表示不限制）
+	ConfigKeyRedEnvelopeLiabilityCap        = "red_envelope_liability_cap"         // 全平台进行中红包待领取总额上限，超出后暂停创建红包（0表示不限制）
+	ConfigKeyRedEnvelopeClaimReturnSeconds  = "red_envelope_claim_return_seconds"  // 领取红包后可退回的时间窗口（秒，0表示不允许退回）
+	ConfigKeyRedEnvelopeDefaultPageSize     = "red_envelope_default_page_size"     // 红包列表未指定每页数量时的默认值
+	ConfigKeyRedEnvelopePayKeyCacheSeconds  = "red_envelope_pay_key_cache_seconds" // 支付密钥校验通过后免重复校验的时间（秒，0表示不缓存，最长60秒）
+	ConfigKeyRedEnvelopePauseExtendsExpiry  = "red_envelope_pause_extends_expiry"  // 恢复领取时是否按暂停时长顺延过期时间（1顺延，0不顺延）
+	ConfigKeyRedEnvelopeWallets             = "red_envelope_wallets"               // 红包可指定的专用钱包名称，逗号分隔（留空表示不启用）
+	ConfigKeyRedEnvelopeCreatorClaimCap     = "red_envelope_creator_claim_cap"     // 同一用户从同一创建者的红包中累计领取金额上限（0表示不限制）
+	ConfigKeyRedEnvelopeClaimDenomination   = "red_envelope_claim_denomination"    // 领取金额取整面额，如1或0.5（0或最小金额单位表示不取整）
+	ConfigKeyRedEnvelopeAudienceOverflow    = "red_envelope_audience_overflow"     // 私密红包个数超过名单可领取总次数时的处理方式（warn提示，reject拒绝，cap自动调整个数）
+	ConfigKeyRedEnvelopeGreetingVariables   = "red_envelope_greeting_variables"    // 祝福语模板变量，格式 festival=春节,site=LINUX DO，祝福语中以 {festival} 引用（留空表示不启用）
+	ConfigKeyRedEnvelopeJackpotRate         = "red_envelope_jackpot_rate"          // 创建红包时额外扣除并计入奖池的比例（0-1之间的小数，0表示不抽成）
+	ConfigKeyRedEnvelopeJackpotWindowHours  = "red_envelope_jackpot_window_hours"  // 奖池发放时参与抽奖的领取记录时间范围（小时）
+	ConfigKeyRedEnvelopeGreetingGraphemes   = "red_envelope_greeting_graphemes"    // 祝福语按字形簇计算的长度上限，组合 emoji 计为1个（0表示仅按100个字符限制）
+	ConfigKeyRedEnvelopeBlackoutSchedule    = "red_envelope_blackout_schedule"     // 暂停领取红包的时段，格式 mon-fri 00:00-09:00;daily 23:00-07:00，结束早于开始表示跨天（留空表示不限制）
+	ConfigKeyRedEnvelopeBlackoutTimezone    = "red_envelope_blackout_timezone"     // 暂停领取时段所用的时区，IANA 时区名
+	ConfigKeyRedEnvelopeTrustWeights        = "red_envelope_trust_weights"         // 按信任等级加权拆分时信任等级0-4的权重，逗号分隔的正数，期望金额与权重成正比
+	ConfigKeyRedEnvelopeDustThreshold       = "red_envelope_dust_threshold"        // 零头阈值，领取后剩余金额低于此值且不足以让剩余名额各领最低金额时红包提前结束并退还零头（0表示不启用）
+	ConfigKeyRedEnvelopeRefundPaused        = "red_envelope_refund_paused"         // 是否暂停过期红包自动退款，数据库维护期间使用，恢复后过期红包会被补退（1暂停，0正常）
+	ConfigKeyRedEnvelopeBannedCreatorAction = "red_envelope_banned_creator_action" // 创建者账户被封禁后其进行中红包的处理方式（none不处理，pause暂停领取，refund立即退款）
+	ConfigKeyRedEnvelopeRejectBannedCreator = "red_envelope_reject_banned_creator" // 是否拒绝领取已封禁创建者的红包（1拒绝，0允许）
+	ConfigKeyRedEnvelopeTestModeUsers       = "red_envelope_test_mode_users"       // 可创建测试红包的用户名，逗号分隔，管理员始终可用（留空表示仅管理员）
 )
 
 const (
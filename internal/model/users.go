@@ -101,12 +101,12 @@ type User struct {
 	PayScore         int64           `json:"pay_score" gorm:"default:0;index"`
 	PayKey           string          `json:"pay_key" gorm:"size:128"`
 	SignKey          string          `json:"sign_key" gorm:"size:64;uniqueIndex;not null"`
-	TotalReceive     decimal.Decimal `json:"total_receive" gorm:"type:numeric(20,2);default:0"`
-	TotalPayment     decimal.Decimal `json:"total_payment" gorm:"type:numeric(20,2);default:0"`
-	TotalTransfer    decimal.Decimal `json:"total_transfer" gorm:"type:numeric(20,2);default:0"`
-	TotalCommunity   decimal.Decimal `json:"total_community" gorm:"type:numeric(20,2);default:0"`
-	CommunityBalance decimal.Decimal `json:"community_balance" gorm:"type:numeric(20,2);default:0"`
-	AvailableBalance decimal.Decimal `json:"available_balance" gorm:"type:numeric(20,2);default:0;index:idx_users_avail_bal_id,priority:1"`
+	TotalReceive     decimal.Decimal `json:"total_receive" gorm:"type:numeric(22,4);default:0"`
+	TotalPayment     decimal.Decimal `json:"total_payment" gorm:"type:numeric(22,4);default:0"`
+	TotalTransfer    decimal.Decimal `json:"total_transfer" gorm:"type:numeric(22,4);default:0"`
+	TotalCommunity   decimal.Decimal `json:"total_community" gorm:"type:numeric(22,4);default:0"`
+	CommunityBalance decimal.Decimal `json:"community_balance" gorm:"type:numeric(22,4);default:0"`
+	AvailableBalance decimal.Decimal `json:"available_balance" gorm:"type:numeric(22,4);default:0;index:idx_users_avail_bal_id,priority:1"`
 	IsActive         bool            `json:"is_active" gorm:"default:true"`
 	IsAdmin          bool            `json:"is_admin" gorm:"default:false"`
 	LastLoginAt      time.Time       `json:"last_login_at" gorm:"index"`
@@ -238,7 +238,7 @@ func (u *User) CreateWithInitialCredit(ctx context.Context, oauthInfo *OAuthUser
 			}
 		}
 
-		newUserInitialCredit, err := GetDecimalByKey(ctx, ConfigKeyNewUserInitialCredit, 2)
+		newUserInitialCredit, err := GetDecimalByKey(ctx, ConfigKeyNewUserInitialCredit, util.AmountPrecision())
 		if err != nil {
 			return err
 		}
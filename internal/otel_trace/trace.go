@@ -31,10 +31,12 @@ func init() {
 	prop := newPropagator()
 	otel.SetTextMapPropagator(prop)
 
-	// 初始化 Trace Provider
+	// 初始化 Trace Provider，失败时使用默认的空实现，追踪不可用不影响服务运行
 	tracerProvider, err := newTracerProvider()
 	if err != nil {
-		log.Fatalf("[Trace] init trace provider failed: %v", err)
+		log.Printf("[Trace] init trace provider failed, tracing disabled: %v", err)
+		Tracer = otel.Tracer("github.com/linux-do/credit")
+		return
 	}
 	shutdownFuncs = append(shutdownFuncs, tracerProvider.Shutdown)
 	otel.SetTracerProvider(tracerProvider)
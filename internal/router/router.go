@@ -28,6 +28,7 @@ import (
 	"time"
 
 	"github.com/linux-do/credit/internal/apps/admin"
+	admin_red_envelope "github.com/linux-do/credit/internal/apps/admin/red_envelope"
 	admin_task "github.com/linux-do/credit/internal/apps/admin/task"
 	admin_user "github.com/linux-do/credit/internal/apps/admin/user"
 	publicconfig "github.com/linux-do/credit/internal/apps/config"
@@ -179,10 +180,49 @@ func Serve() {
 			// Red Envelope
 			redEnvelopeRouter := apiV1Router.Group("/redenvelope")
 			{
-				redEnvelopeRouter.GET("/:id", oauth.LoginRequired(), redenvelope.CheckRedEnvelopeEnabled(), redenvelope.GetDetail)
+				redEnvelopeRouter.GET("/escrow", oauth.LoginRequired(), redenvelope.CheckRedEnvelopeEnabled(), redenvelope.GetEscrow)
+				redEnvelopeRouter.POST("/escrow/deposit", oauth.LoginRequired(), redenvelope.CheckRedEnvelopeEnabled(), redenvelope.DepositEscrow)
+				redEnvelopeRouter.POST("/escrow/withdraw", oauth.LoginRequired(), redenvelope.CheckRedEnvelopeEnabled(), redenvelope.WithdrawEscrow)
+				redEnvelopeRouter.GET("/wallets", oauth.LoginRequired(), redenvelope.CheckRedEnvelopeEnabled(), redenvelope.ListWallets)
+				redEnvelopeRouter.POST("/wallets/deposit", oauth.LoginRequired(), redenvelope.CheckRedEnvelopeEnabled(), redenvelope.DepositWallet)
+				redEnvelopeRouter.POST("/wallets/withdraw", oauth.LoginRequired(), redenvelope.CheckRedEnvelopeEnabled(), redenvelope.WithdrawWallet)
+				redEnvelopeRouter.GET("/by-order/:order_id", oauth.LoginRequired(), redenvelope.CheckRedEnvelopeEnabled(), redenvelope.GetByOrder)
+				redEnvelopeRouter.GET("/refunds", oauth.LoginRequired(), redenvelope.CheckRedEnvelopeEnabled(), redenvelope.ListRefunds)
+				redEnvelopeRouter.GET("/public", oauth.LoginRequired(), redenvelope.CheckRedEnvelopeEnabled(), redenvelope.ListPublic)
+				redEnvelopeRouter.GET("/my-claims", oauth.LoginRequired(), redenvelope.CheckRedEnvelopeEnabled(), redenvelope.ListMyClaims)
+				redEnvelopeRouter.GET("/config", oauth.LoginRequired(), redenvelope.CheckRedEnvelopeEnabled(), redenvelope.GetConstraints)
+				redEnvelopeRouter.GET("/top-senders", oauth.LoginRequired(), redenvelope.CheckRedEnvelopeEnabled(), redenvelope.ListTopSenders)
+				redEnvelopeRouter.GET("/closing-soon", oauth.LoginRequired(), redenvelope.CheckRedEnvelopeEnabled(), redenvelope.ListClosingSoon)
+				redEnvelopeRouter.GET("/export", oauth.LoginRequired(), redenvelope.Export)
+				redEnvelopeRouter.GET("/ready", redenvelope.Ready)
+				redEnvelopeRouter.GET("/error-codes", redenvelope.ListErrorCodes)
+				redEnvelopeRouter.GET("/locked", oauth.LoginRequired(), redenvelope.CheckRedEnvelopeEnabled(), redenvelope.GetLocked)
+				redEnvelopeRouter.GET("/recurring", oauth.LoginRequired(), redenvelope.CheckRedEnvelopeEnabled(), redenvelope.ListRecurring)
+				redEnvelopeRouter.POST("/recurring", oauth.LoginRequired(), redenvelope.CheckRedEnvelopeEnabled(), redenvelope.CreateRecurring)
+				redEnvelopeRouter.PUT("/recurring/:id", oauth.LoginRequired(), redenvelope.CheckRedEnvelopeEnabled(), redenvelope.UpdateRecurring)
+				redEnvelopeRouter.GET("/:id", oauth.LoginRequired(), redenvelope.CheckRedEnvelopeEnabled(), redenvelope.LimitDetailRate(), redenvelope.GetDetail)
+				redEnvelopeRouter.GET("/:id/results", oauth.LoginRequired(), redenvelope.CheckRedEnvelopeEnabled(), redenvelope.GetResults)
+				redEnvelopeRouter.POST("/:id/rotate", oauth.LoginRequired(), redenvelope.CheckRedEnvelopeEnabled(), redenvelope.Rotate)
+				redEnvelopeRouter.POST("/:id/pause", oauth.LoginRequired(), redenvelope.CheckRedEnvelopeEnabled(), redenvelope.Pause)
+				redEnvelopeRouter.POST("/:id/resume", oauth.LoginRequired(), redenvelope.CheckRedEnvelopeEnabled(), redenvelope.Resume)
+				redEnvelopeRouter.GET("/:id/events", oauth.LoginRequired(), redenvelope.CheckRedEnvelopeEnabled(), redenvelope.ListEvents)
+				redEnvelopeRouter.GET("/:id/channels", oauth.LoginRequired(), redenvelope.CheckRedEnvelopeEnabled(), redenvelope.ListChannels)
+				redEnvelopeRouter.GET("/:id/can-claim/:user_id", oauth.LoginRequired(), redenvelope.CheckRedEnvelopeEnabled(), redenvelope.CanClaim)
+				redEnvelopeRouter.GET("/:id/stream", oauth.LoginRequired(), redenvelope.CheckRedEnvelopeEnabled(), redenvelope.Stream)
+				redEnvelopeRouter.POST("/:id/bulk-claim", oauth.LoginRequired(), redenvelope.CheckRedEnvelopeEnabled(), redenvelope.BulkClaim)
 				redEnvelopeRouter.POST("/create", oauth.LoginRequired(), redenvelope.CheckRedEnvelopeEnabled(), redenvelope.Create)
+				redEnvelopeRouter.POST("/preview", oauth.LoginRequired(), redenvelope.CheckRedEnvelopeEnabled(), redenvelope.Preview)
 				redEnvelopeRouter.POST("/claim", oauth.LoginRequired(), redenvelope.CheckRedEnvelopeEnabled(), redenvelope.Claim)
+				redEnvelopeRouter.POST("/claim-batch", oauth.LoginRequired(), redenvelope.CheckRedEnvelopeEnabled(), redenvelope.ClaimBatch)
+				redEnvelopeRouter.POST("/claim/return", oauth.LoginRequired(), redenvelope.CheckRedEnvelopeEnabled(), redenvelope.ReturnClaim)
+				redEnvelopeRouter.POST("/reserve", oauth.LoginRequired(), redenvelope.CheckRedEnvelopeEnabled(), redenvelope.Reserve)
+				redEnvelopeRouter.POST("/confirm", oauth.LoginRequired(), redenvelope.CheckRedEnvelopeEnabled(), redenvelope.Confirm)
+				redEnvelopeRouter.POST("/search", oauth.LoginRequired(), redenvelope.CheckRedEnvelopeEnabled(), redenvelope.Search)
 				redEnvelopeRouter.POST("/list", oauth.LoginRequired(), redenvelope.CheckRedEnvelopeEnabled(), redenvelope.List)
+				redEnvelopeRouter.POST("/deep-link/claim", redenvelope.CheckRedEnvelopeEnabled(), redenvelope.DeepLinkClaim)
+				redEnvelopeRouter.POST("/receipts/verify", redenvelope.CheckRedEnvelopeEnabled(), redenvelope.VerifyReceipt)
+				redEnvelopeRouter.POST("/webhook/claim", redenvelope.CheckRedEnvelopeEnabled(), redenvelope.RequireWebhookSignature(), redenvelope.WebhookClaim)
+				redEnvelopeRouter.POST("/webhook/deep-link", redenvelope.CheckRedEnvelopeEnabled(), redenvelope.RequireWebhookSignature(), redenvelope.IssueDeepLink)
 			}
 
 			// Config (public)
@@ -259,6 +299,15 @@ func Serve() {
 					userPayConfigRouter.PUT("", user_pay_config.UpdateUserPayConfig)
 					userPayConfigRouter.DELETE("", user_pay_config.DeleteUserPayConfig)
 				}
+
+				// Red Envelope
+				adminRouter.GET("/red-envelopes/exposure", admin_red_envelope.GetExposure)
+				adminRouter.GET("/red-envelopes/shared-devices", admin_red_envelope.ListSharedDevices)
+				adminRouter.GET("/red-envelopes/export", admin_red_envelope.ExportAll)
+				adminRouter.GET("/red-envelopes/refund-pause", admin_red_envelope.GetRefundPause)
+				adminRouter.PUT("/red-envelopes/refund-pause", admin_red_envelope.UpdateRefundPause)
+				adminRouter.GET("/red-envelopes/:id/events", admin_red_envelope.ListEnvelopeEvents)
+				adminRouter.POST("/red-envelopes/:id/bulk-claim", redenvelope.AdminBulkClaim)
 			}
 		}
 	}
@@ -27,6 +27,7 @@ import (
 	"github.com/linux-do/credit/internal/model"
 	"github.com/linux-do/credit/internal/task"
 	"github.com/linux-do/credit/internal/task/scheduler"
+	"github.com/linux-do/credit/internal/util"
 	"github.com/shopspring/decimal"
 	"gorm.io/gorm"
 )
@@ -132,7 +133,7 @@ func GetTodayUsedAmount(db *gorm.DB, userID uint64) (decimal.Decimal, error) {
 // CalculateFee 计算手续费和商户实收金额
 // 返回：手续费、商户实收金额、手续费百分比
 func CalculateFee(amount decimal.Decimal, feeRate decimal.Decimal) (fee decimal.Decimal, merchantAmount decimal.Decimal, feePercent int64) {
-	fee = amount.Mul(feeRate).Round(2)
+	fee = amount.Mul(feeRate).Round(util.PaymentAmountPrecision)
 	merchantAmount = amount.Sub(fee)
 	feePercent = feeRate.Mul(decimal.NewFromInt(100)).IntPart()
 	return
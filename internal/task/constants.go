@@ -24,6 +24,15 @@ const (
 	MerchantPaymentNotifyTask             = "payment:merchant_notify"
 	SyncOrdersToClickHouseTask            = "order:sync_to_clickhouse"
 	RefundExpiredRedEnvelopesTask         = "redenvelope:refund_expired"
+	ArchiveRedEnvelopeClaimsTask          = "redenvelope:archive_claims"
+	NotifyExpiringRedEnvelopesTask        = "redenvelope:notify_expiring"
+	RedEnvelopeExpiryNotifyTask           = "redenvelope:expiry_notify"
+	AwardRedEnvelopeJackpotTask           = "redenvelope:award_jackpot"
+	CleanupRedEnvelopeKeysTask            = "redenvelope:cleanup_keys"
+	RunRecurringEnvelopesTask             = "redenvelope:run_recurring"
+	RecurringEnvelopeNotifyTask           = "redenvelope:recurring_notify"
+	HandleBannedCreatorEnvelopesTask      = "redenvelope:banned_creators"
+	RefreshRedEnvelopeLiabilityTask       = "redenvelope:refresh_liability"
 )
 
 const (
@@ -34,10 +43,17 @@ const (
 
 // 管理员可下发的任务类型标识
 const (
-	TaskTypeOrderSync         = "order_sync"
-	TaskTypeUserGamification  = "user_gamification"
-	TaskTypeDisputeRefund     = "dispute_auto_refund"
-	TaskTypeRedEnvelopeRefund = "redenvelope_auto_refund"
+	TaskTypeOrderSync          = "order_sync"
+	TaskTypeUserGamification   = "user_gamification"
+	TaskTypeDisputeRefund      = "dispute_auto_refund"
+	TaskTypeRedEnvelopeRefund  = "redenvelope_auto_refund"
+	TaskTypeRedEnvelopeArchive = "redenvelope_archive_claims"
+	TaskTypeRedEnvelopeNotice  = "redenvelope_expiry_notice"
+	TaskTypeRedEnvelopeJackpot = "redenvelope_award_jackpot"
+	TaskTypeRedEnvelopeKeys    = "redenvelope_cleanup_keys"
+	TaskTypeRecurringEnvelope  = "redenvelope_run_recurring"
+	TaskTypeBannedCreator      = "redenvelope_banned_creators"
+	TaskTypeLiability          = "redenvelope_refresh_liability"
 )
 
 // TaskMeta 任务元数据
@@ -89,6 +105,69 @@ var DispatchableTasks = []TaskMeta{
 		MaxRetry:     5,
 		Queue:        QueueDefault,
 	},
+	{
+		Type:         TaskTypeRedEnvelopeArchive,
+		AsynqTask:    ArchiveRedEnvelopeClaimsTask,
+		Name:         "红包领取记录归档",
+		Description:  "清理超过保留期的已结束红包领取记录",
+		SupportsTime: false,
+		MaxRetry:     3,
+		Queue:        QueueDefault,
+	},
+	{
+		Type:         TaskTypeRedEnvelopeNotice,
+		AsynqTask:    NotifyExpiringRedEnvelopesTask,
+		Name:         "红包过期提醒",
+		Description:  "通知创建者即将过期且未领完的红包",
+		SupportsTime: false,
+		MaxRetry:     3,
+		Queue:        QueueDefault,
+	},
+	{
+		Type:         TaskTypeRedEnvelopeJackpot,
+		AsynqTask:    AwardRedEnvelopeJackpotTask,
+		Name:         "红包奖池发放",
+		Description:  "将红包奖池发放给随机一位近期领取过红包的用户",
+		SupportsTime: false,
+		MaxRetry:     3,
+		Queue:        QueueDefault,
+	},
+	{
+		Type:         TaskTypeRedEnvelopeKeys,
+		AsynqTask:    CleanupRedEnvelopeKeysTask,
+		Name:         "红包残留 key 清理",
+		Description:  "清理未设置过期时间或所属红包已结束的红包 Redis key",
+		SupportsTime: false,
+		MaxRetry:     1,
+		Queue:        QueueDefault,
+	},
+	{
+		Type:         TaskTypeRecurringEnvelope,
+		AsynqTask:    RunRecurringEnvelopesTask,
+		Name:         "定期发放红包",
+		Description:  "按定期发放计划创建到期的红包，余额不足时跳过本次并通知创建者",
+		SupportsTime: false,
+		MaxRetry:     0,
+		Queue:        QueueDefault,
+	},
+	{
+		Type:         TaskTypeBannedCreator,
+		AsynqTask:    HandleBannedCreatorEnvelopesTask,
+		Name:         "封禁创建者红包处理",
+		Description:  "按系统配置暂停或退款已封禁创建者的进行中红包",
+		SupportsTime: false,
+		MaxRetry:     3,
+		Queue:        QueueDefault,
+	},
+	{
+		Type:         TaskTypeLiability,
+		AsynqTask:    RefreshRedEnvelopeLiabilityTask,
+		Name:         "红包待领取总额重算",
+		Description:  "从数据库重新统计全平台进行中红包待领取总额并刷新缓存",
+		SupportsTime: false,
+		MaxRetry:     0,
+		Queue:        QueueDefault,
+	},
 }
 
 // GetTaskMeta 根据任务类型获取元数据
@@ -92,6 +92,90 @@ func StartScheduler() error {
 			return
 		}
 
+		// 红包领取记录归档任务（未配置时不调度）
+		if config.Config.Scheduler.ArchiveRedEnvelopeClaimsTaskCron != "" {
+			if _, err = scheduler.Register(
+				config.Config.Scheduler.ArchiveRedEnvelopeClaimsTaskCron,
+				asynq.NewTask(task.ArchiveRedEnvelopeClaimsTask, nil),
+				asynq.MaxRetry(3),
+				asynq.Unique(23*time.Hour),
+			); err != nil {
+				return
+			}
+		}
+
+		// 红包过期提醒任务（未配置时不调度）
+		if config.Config.Scheduler.NotifyExpiringRedEnvelopesTaskCron != "" {
+			if _, err = scheduler.Register(
+				config.Config.Scheduler.NotifyExpiringRedEnvelopesTaskCron,
+				asynq.NewTask(task.NotifyExpiringRedEnvelopesTask, nil),
+				asynq.MaxRetry(3),
+				asynq.Unique(5*time.Minute),
+			); err != nil {
+				return
+			}
+		}
+
+		// 红包奖池发放任务（未配置时不调度）
+		if config.Config.Scheduler.AwardRedEnvelopeJackpotTaskCron != "" {
+			if _, err = scheduler.Register(
+				config.Config.Scheduler.AwardRedEnvelopeJackpotTaskCron,
+				asynq.NewTask(task.AwardRedEnvelopeJackpotTask, nil),
+				asynq.MaxRetry(3),
+				asynq.Unique(5*time.Minute),
+			); err != nil {
+				return
+			}
+		}
+
+		// 红包残留 Redis key 清理任务（未配置时不调度）
+		if config.Config.Scheduler.CleanupRedEnvelopeKeysTaskCron != "" {
+			if _, err = scheduler.Register(
+				config.Config.Scheduler.CleanupRedEnvelopeKeysTaskCron,
+				asynq.NewTask(task.CleanupRedEnvelopeKeysTask, nil),
+				asynq.MaxRetry(1),
+				asynq.Unique(30*time.Minute),
+			); err != nil {
+				return
+			}
+		}
+
+		// 定期发放红包任务（未配置时不调度）
+		if config.Config.Scheduler.RunRecurringEnvelopesTaskCron != "" {
+			if _, err = scheduler.Register(
+				config.Config.Scheduler.RunRecurringEnvelopesTaskCron,
+				asynq.NewTask(task.RunRecurringEnvelopesTask, nil),
+				asynq.MaxRetry(0),
+				asynq.Unique(time.Minute),
+			); err != nil {
+				return
+			}
+		}
+
+		// 封禁创建者红包处理任务（未配置时不调度）
+		if config.Config.Scheduler.HandleBannedCreatorEnvelopesTaskCron != "" {
+			if _, err = scheduler.Register(
+				config.Config.Scheduler.HandleBannedCreatorEnvelopesTaskCron,
+				asynq.NewTask(task.HandleBannedCreatorEnvelopesTask, nil),
+				asynq.MaxRetry(3),
+				asynq.Unique(10*time.Minute),
+			); err != nil {
+				return
+			}
+		}
+
+		// 红包待领取总额重算任务（未配置时不调度）
+		if config.Config.Scheduler.RefreshRedEnvelopeLiabilityTaskCron != "" {
+			if _, err = scheduler.Register(
+				config.Config.Scheduler.RefreshRedEnvelopeLiabilityTaskCron,
+				asynq.NewTask(task.RefreshRedEnvelopeLiabilityTask, nil),
+				asynq.MaxRetry(0),
+				asynq.Unique(time.Minute),
+			); err != nil {
+				return
+			}
+		}
+
 		// 启动调度器
 		err = scheduler.Run()
 	})
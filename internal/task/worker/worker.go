@@ -79,6 +79,15 @@ func StartWorker() error {
 	mux.HandleFunc(task.MerchantPaymentNotifyTask, payment.HandleMerchantPaymentNotify)
 	mux.HandleFunc(task.SyncOrdersToClickHouseTask, order.HandleSyncOrdersToClickHouse)
 	mux.HandleFunc(task.RefundExpiredRedEnvelopesTask, redenvelope.HandleRefundExpiredRedEnvelopes)
+	mux.HandleFunc(task.ArchiveRedEnvelopeClaimsTask, redenvelope.HandleArchiveRedEnvelopeClaims)
+	mux.HandleFunc(task.NotifyExpiringRedEnvelopesTask, redenvelope.HandleNotifyExpiringRedEnvelopes)
+	mux.HandleFunc(task.RedEnvelopeExpiryNotifyTask, redenvelope.HandleRedEnvelopeExpiryNotify)
+	mux.HandleFunc(task.AwardRedEnvelopeJackpotTask, redenvelope.HandleAwardRedEnvelopeJackpot)
+	mux.HandleFunc(task.CleanupRedEnvelopeKeysTask, redenvelope.HandleCleanupRedEnvelopeKeys)
+	mux.HandleFunc(task.RunRecurringEnvelopesTask, redenvelope.HandleRunRecurringEnvelopes)
+	mux.HandleFunc(task.RecurringEnvelopeNotifyTask, redenvelope.HandleRecurringEnvelopeNotify)
+	mux.HandleFunc(task.HandleBannedCreatorEnvelopesTask, redenvelope.HandleBannedCreatorEnvelopes)
+	mux.HandleFunc(task.RefreshRedEnvelopeLiabilityTask, redenvelope.HandleRefreshRedEnvelopeLiability)
 	// 启动服务器
 	return asynqServer.Run(mux)
 }
@@ -0,0 +1,73 @@
+/*
+Copyright 2025 linux.do
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"strings"
+
+	"github.com/linux-do/credit/internal/config"
+	"github.com/shopspring/decimal"
+)
+
+// DefaultAmountLocale 未配置或配置了不支持的区域时使用的金额格式区域
+const DefaultAmountLocale = "zh-CN"
+
+// amountSeparators 金额的千位分隔符及小数点
+type amountSeparators struct {
+	group   string
+	decimal string
+}
+
+// amountLocales 支持的金额格式区域
+var amountLocales = map[string]amountSeparators{
+	"zh-CN": {group: ",", decimal: "."},
+	"en-US": {group: ",", decimal: "."},
+	"ja-JP": {group: ",", decimal: "."},
+	"de-DE": {group: ".", decimal: ","},
+	"fr-FR": {group: "\u202f", decimal: ","},
+	"ru-RU": {group: "\u00a0", decimal: ","},
+}
+
+// FormatAmount 按配置的金额格式区域格式化金额：按配置的金额小数位数保留小数并使用千位分隔符，如 zh-CN 下为 1,234,567.89
+func FormatAmount(amount decimal.Decimal) string {
+	return FormatAmountForLocale(amount, config.Config.App.AmountLocale)
+}
+
+// FormatAmountForLocale 按指定区域的千位分隔符及小数点格式化金额，不支持的区域按 DefaultAmountLocale 格式化
+func FormatAmountForLocale(amount decimal.Decimal, locale string) string {
+	separators, ok := amountLocales[locale]
+	if !ok {
+		separators = amountLocales[DefaultAmountLocale]
+	}
+
+	fixed := amount.Abs().StringFixed(AmountPrecision())
+	intPart, fracPart, _ := strings.Cut(fixed, ".")
+
+	var b strings.Builder
+	if amount.IsNegative() {
+		b.WriteByte('-')
+	}
+	for i, ch := range intPart {
+		if i > 0 && (len(intPart)-i)%3 == 0 {
+			b.WriteString(separators.group)
+		}
+		b.WriteRune(ch)
+	}
+	b.WriteString(separators.decimal)
+	b.WriteString(fracPart)
+	return b.String()
+}
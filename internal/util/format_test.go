@@ -0,0 +1,76 @@
+/*
+Copyright 2025 linux.do
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"testing"
+
+	"github.com/linux-do/credit/internal/config"
+	"github.com/shopspring/decimal"
+)
+
+func TestFormatAmountUsesConfiguredPrecision(t *testing.T) {
+	cases := []struct {
+		precision int
+		amount    string
+		want      string
+	}{
+		{2, "1234567.891", "1,234,567.89"},
+		{2, "-1000", "-1,000.00"},
+		{4, "0.0005", "0.0005"},
+		{4, "1234.5678", "1,234.5678"},
+	}
+	for _, tc := range cases {
+		setAmountPrecision(t, tc.precision)
+		if got := FormatAmount(decimal.RequireFromString(tc.amount)); got != tc.want {
+			t.Errorf("FormatAmount(%s) at %d places = %q, want %q", tc.amount, tc.precision, got, tc.want)
+		}
+	}
+}
+
+func TestFormatAmountForLocale(t *testing.T) {
+	setAmountPrecision(t, 2)
+	amount := decimal.RequireFromString("-1234567.891")
+	cases := []struct {
+		locale string
+		want   string
+	}{
+		{"zh-CN", "-1,234,567.89"},
+		{"en-US", "-1,234,567.89"},
+		{"de-DE", "-1.234.567,89"},
+		{"fr-FR", "-1\u202f234\u202f567,89"},
+		{"ru-RU", "-1\u00a0234\u00a0567,89"},
+		{"", "-1,234,567.89"},
+		{"xx-XX", "-1,234,567.89"},
+	}
+	for _, tc := range cases {
+		if got := FormatAmountForLocale(amount, tc.locale); got != tc.want {
+			t.Errorf("FormatAmountForLocale(%s, %q) = %q, want %q", amount, tc.locale, got, tc.want)
+		}
+	}
+}
+
+func TestFormatAmountUsesConfiguredLocale(t *testing.T) {
+	setAmountPrecision(t, 2)
+	previous := config.Config.App.AmountLocale
+	config.Config.App.AmountLocale = "de-DE"
+	t.Cleanup(func() { config.Config.App.AmountLocale = previous })
+
+	if got, want := FormatAmount(decimal.RequireFromString("1234.5")), "1.234,50"; got != want {
+		t.Fatalf("FormatAmount = %q, want %q", got, want)
+	}
+}
@@ -43,3 +43,8 @@ func OKNil() Response[any] {
 func Err(msg string) Response[any] {
 	return Response[any]{ErrorMsg: msg, Data: nil}
 }
+
+// ErrFields 构造带字段错误明细的错误响应
+func ErrFields(msg string, fields map[string]string) Response[map[string]string] {
+	return Response[map[string]string]{ErrorMsg: msg, Data: fields}
+}
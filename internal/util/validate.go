@@ -17,12 +17,26 @@ limitations under the License.
 package util
 
 import (
+	"encoding/json"
 	"errors"
+	"fmt"
+	"reflect"
+	"strings"
 
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
 	"github.com/linux-do/credit/internal/common"
+	"github.com/linux-do/credit/internal/config"
 	"github.com/shopspring/decimal"
 )
 
+func init() {
+	// 校验错误的字段路径使用 json 字段名，嵌套字段及切片元素可定位到具体位置（如 spec.total_amount、envelopes[0].total_amount）
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(jsonTagName)
+	}
+}
+
 // ValidateRates 所有 rate 必须在 [0, 1] 范围内，且小数位数不超过2位
 func ValidateRates(rates ...decimal.Decimal) error {
 	for _, rate := range rates {
@@ -40,13 +54,103 @@ func ValidateRates(rates ...decimal.Decimal) error {
 	return nil
 }
 
-// ValidateAmount 验证金额：必须大于0，且小数位数不超过2位
+// MaxAmountPrecision 金额可配置的最大小数位数，与金额列 numeric(22,4) 的小数位数一致
+const MaxAmountPrecision = 4
+
+// AmountPrecision 返回配置的金额小数位数，未配置时为2位，超出范围时截断到 [1, MaxAmountPrecision]
+func AmountPrecision() int32 {
+	precision := config.Config.App.AmountPrecision
+	if precision == 0 {
+		return 2
+	}
+	return int32(min(max(precision, 1), MaxAmountPrecision))
+}
+
+// MinAmountUnit 返回最小金额单位，如2位小数时为0.01
+func MinAmountUnit() decimal.Decimal {
+	return decimal.New(1, -AmountPrecision())
+}
+
+// PaymentAmountPrecision 支付及商户收款链接的金额小数位数，易支付签名及回调通知均按2位小数处理，不随配置的金额精度变化
+const PaymentAmountPrecision = 2
+
+// ValidateAmount 验证金额：必须大于0，且小数位数不超过配置的金额小数位数
 func ValidateAmount(amount decimal.Decimal) error {
+	return validateAmountPlaces(amount, AmountPrecision())
+}
+
+// ValidatePaymentAmount 验证支付金额：必须大于0，且小数位数不超过 PaymentAmountPrecision
+func ValidatePaymentAmount(amount decimal.Decimal) error {
+	return validateAmountPlaces(amount, PaymentAmountPrecision)
+}
+
+// validateAmountPlaces 验证金额大于0且小数位数不超过 places
+func validateAmountPlaces(amount decimal.Decimal, places int32) error {
 	if amount.LessThanOrEqual(decimal.Zero) {
 		return errors.New(common.AmountMustBeGreaterThanZero)
 	}
-	if amount.Exponent() < -2 {
+	if amount.Exponent() < -places {
 		return errors.New(common.AmountDecimalPlacesExceeded)
 	}
 	return nil
 }
+
+// FieldErrors 将请求绑定错误解析为 字段路径 -> 错误信息，字段路径由 json 字段名组成，嵌套字段以 . 连接
+// 无法按字段解析的错误返回 nil
+func FieldErrors(err error) map[string]string {
+	var validationErrors validator.ValidationErrors
+	if errors.As(err, &validationErrors) {
+		fields := make(map[string]string, len(validationErrors))
+		for _, fieldErr := range validationErrors {
+			fields[fieldPath(fieldErr)] = fieldErrorMessage(fieldErr)
+		}
+		return fields
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		return map[string]string{typeErr.Field: fmt.Sprintf("类型错误，应为 %s", typeErr.Type.String())}
+	}
+
+	return nil
+}
+
+// fieldErrorMessage 根据校验标签生成字段错误信息
+func fieldErrorMessage(fieldErr validator.FieldError) string {
+	switch fieldErr.Tag() {
+	case "required":
+		return "不能为空"
+	case "min":
+		if fieldErr.Kind() == reflect.String {
+			return fmt.Sprintf("长度不能小于 %s", fieldErr.Param())
+		}
+		return fmt.Sprintf("不能小于 %s", fieldErr.Param())
+	case "max":
+		if fieldErr.Kind() == reflect.String {
+			return fmt.Sprintf("长度不能大于 %s", fieldErr.Param())
+		}
+		return fmt.Sprintf("不能大于 %s", fieldErr.Param())
+	case "oneof":
+		return fmt.Sprintf("必须是以下值之一: %s", fieldErr.Param())
+	default:
+		return fmt.Sprintf("校验失败: %s", fieldErr.Tag())
+	}
+}
+
+// fieldPath 返回校验错误的字段路径，去掉 Namespace 开头的结构体类型名
+func fieldPath(fieldErr validator.FieldError) string {
+	namespace := fieldErr.Namespace()
+	if _, path, ok := strings.Cut(namespace, "."); ok {
+		return path
+	}
+	return namespace
+}
+
+// jsonTagName 返回结构体字段的 json 字段名，未设置 json 标签时返回空字符串以使用原字段名
+func jsonTagName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "-" {
+		return ""
+	}
+	return name
+}
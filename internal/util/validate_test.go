@@ -0,0 +1,114 @@
+/*
+Copyright 2025 linux.do
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"testing"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/linux-do/credit/internal/config"
+	"github.com/shopspring/decimal"
+)
+
+// setAmountPrecision 在测试期间修改配置的金额小数位数，测试结束后恢复
+func setAmountPrecision(t *testing.T, precision int) {
+	t.Helper()
+	previous := config.Config.App.AmountPrecision
+	config.Config.App.AmountPrecision = precision
+	t.Cleanup(func() { config.Config.App.AmountPrecision = previous })
+}
+
+func TestValidateAmountFollowsConfiguredPrecision(t *testing.T) {
+	setAmountPrecision(t, 4)
+
+	cases := []struct {
+		amount  string
+		wantErr bool
+	}{
+		{"1.2345", false},
+		{"0.0001", false},
+		{"1.23456", true},
+		{"0", true},
+		{"-0.0001", true},
+	}
+	for _, tc := range cases {
+		err := ValidateAmount(decimal.RequireFromString(tc.amount))
+		if (err != nil) != tc.wantErr {
+			t.Errorf("ValidateAmount(%s) error = %v, wantErr %v", tc.amount, err, tc.wantErr)
+		}
+	}
+}
+
+func TestValidatePaymentAmountKeepsTwoDecimals(t *testing.T) {
+	setAmountPrecision(t, 4)
+
+	cases := []struct {
+		amount  string
+		wantErr bool
+	}{
+		{"1.23", false},
+		{"1.2345", true},
+		{"0.001", true},
+	}
+	for _, tc := range cases {
+		err := ValidatePaymentAmount(decimal.RequireFromString(tc.amount))
+		if (err != nil) != tc.wantErr {
+			t.Errorf("ValidatePaymentAmount(%s) error = %v, wantErr %v", tc.amount, err, tc.wantErr)
+		}
+	}
+}
+
+func TestAmountPrecisionBounds(t *testing.T) {
+	cases := []struct {
+		configured int
+		want       int32
+	}{
+		{0, 2},
+		{1, 1},
+		{4, 4},
+		{8, MaxAmountPrecision},
+		{-3, 1},
+	}
+	for _, tc := range cases {
+		setAmountPrecision(t, tc.configured)
+		if got := AmountPrecision(); got != tc.want {
+			t.Errorf("AmountPrecision() with %d configured = %d, want %d", tc.configured, got, tc.want)
+		}
+	}
+}
+
+func TestFieldErrorsUseJSONPaths(t *testing.T) {
+	type item struct {
+		Amount int `json:"amount" binding:"min=1"`
+	}
+	type request struct {
+		Name  string `json:"name" binding:"required"`
+		Spec  item   `json:"spec"`
+		Items []item `json:"items" binding:"dive"`
+	}
+
+	err := binding.Validator.ValidateStruct(&request{Items: []item{{Amount: 1}, {Amount: 0}}})
+	fields := FieldErrors(err)
+	for _, want := range []string{"name", "spec.amount", "items[1].amount"} {
+		if _, ok := fields[want]; !ok {
+			t.Errorf("field errors = %v, want key %q", fields, want)
+		}
+	}
+	if len(fields) != 3 {
+		t.Errorf("got %d field errors, want 3: %v", len(fields), fields)
+	}
+}
@@ -0,0 +1,6 @@
+#!/bin/sh
+
+# 单元测试加载独立的测试配置，不依赖本地 config.yaml 及外部服务
+set -ex
+
+CONFIG_PATH="$(pwd)/internal/config/testdata/config.test.yaml" go test "$@" ./...
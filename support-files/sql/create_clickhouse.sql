@@ -10,7 +10,7 @@ CREATE TABLE IF NOT EXISTS orders
     client_id         String,
     payer_user_id     UInt64,
     payee_user_id     UInt64,
-    amount            Decimal(20, 2),
+    amount            Decimal(22, 4),
     status            LowCardinality(String),
     type              LowCardinality(String),
     remark            String,
@@ -25,6 +25,10 @@ CREATE TABLE IF NOT EXISTS orders
         ORDER BY (created_at, id)
         SETTINGS index_granularity = 8192;
 
+-- 升级：金额列由 Decimal(20, 2) 扩大为 Decimal(22, 4)，以容纳 app.amount_precision 配置的最多4位小数
+-- 已有部署须执行，否则从 PostgreSQL 同步的4位小数金额会被截断；新建的表已是目标类型，重复执行无影响
+ALTER TABLE orders MODIFY COLUMN amount Decimal(22, 4);
+
 -- ============================================================
 -- 常用查询示例
 -- ============================================================